@@ -0,0 +1,555 @@
+package lisp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Errors are signaled as conditions, which Lisp code may catch with
+// handler-case, handler-bind or ignore-errors. The type of a condition is
+// the name of its error kind, or a type defined with define-condition.
+//
+// A failed evaluation is handed up as an UnsuccessfulEvaluationResult. Its
+// condition is signaled, which calls the handlers of handler-bind, at the
+// first form that has something to undo: unwind-protect, the forms
+// rebinding dynamic variables and handler-bind itself. Until then, nothing
+// observable has been undone, so the handlers run as if they were called
+// where the error occurred.
+
+// Condition is an error handed to Lisp code by a handler.
+type Condition struct {
+	BaseTypeExpression
+	Error *EvaluationError
+	Span  Span
+}
+
+func (c Condition) GetType() string {
+	return "condition"
+}
+
+func (c Condition) GetSpan() Span {
+	return c.Span
+}
+
+func (c Condition) Print() string {
+	return fmt.Sprintf("#<%s: %s>", c.Error.TypeName(), c.Error.Message)
+}
+
+func (c Condition) Evaluate(context EvaluationContext) EvaluationResult {
+	return SuccessfulEvaluationResult{
+		Expression: c,
+	}
+}
+
+// builtinConditionParents maps each builtin condition type to its direct
+// parents.
+var builtinConditionParents = map[string][]string{
+	"error":                {"condition"},
+	"simple-error":         {"error"},
+	"internal-error":       {"error"},
+	"unbound-variable":     {"error"},
+	"undefined-function":   {"error"},
+	"arity-mismatch":       {"error"},
+	"type-error":           {"error"},
+	"arithmetic-error":     {"error"},
+	"division-by-zero":     {"arithmetic-error"},
+	"invalid-form":         {"error"},
+	"reader-error":         {"error"},
+	"no-applicable-method": {"error"},
+	"file-error":           {"error"},
+	"call-depth-exceeded":  {"error"},
+}
+
+// conditionRegistry maps the condition types an interpreter defined with
+// define-condition to their direct parents.
+type conditionRegistry struct {
+	parents map[string][]string
+}
+
+func newConditionRegistry() *conditionRegistry {
+	return &conditionRegistry{parents: make(map[string][]string)}
+}
+
+func isBuiltinConditionType(name string) bool {
+	_, ok := builtinConditionParents[name]
+	return ok || name == "condition"
+}
+
+func (registry *conditionRegistry) parentsOf(name string) []string {
+	if parents, ok := builtinConditionParents[name]; ok {
+		return parents
+	}
+	return registry.parents[name]
+}
+
+func (registry *conditionRegistry) isConditionType(name string) bool {
+	_, ok := registry.parents[name]
+	return ok || isBuiltinConditionType(name)
+}
+
+// isConditionSubtype reports whether a condition type is parentType or
+// inherits from it.
+func (registry *conditionRegistry) isConditionSubtype(conditionType string, parentType string) bool {
+	for _, supertype := range registry.conditionSupertypes(conditionType) {
+		if supertype == parentType {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionSupertypes returns a condition type followed by the types it
+// inherits from, closest first.
+func (registry *conditionRegistry) conditionSupertypes(conditionType string) []string {
+	result := []string{}
+	seen := map[string]bool{}
+	pending := []string{conditionType}
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		if !seen[current] {
+			seen[current] = true
+			result = append(result, current)
+			pending = append(pending, registry.parentsOf(current)...)
+		}
+	}
+	return result
+}
+
+// defineCondition implements (define-condition name (parents...) ...),
+// which defines a condition type inheriting from parents, or from condition
+// when there is none. Slot specifications are accepted and ignored. The
+// builtin condition types cannot be redefined, and a type cannot inherit
+// from itself.
+func defineCondition(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) < 2 || arguments[0].GetType() != "symbol" {
+		return newEvaluationError(InvalidFormError, span, "define-condition expects a name and a list of parent types")
+	}
+
+	name := arguments[0].(Symbol)
+	if isBuiltinConditionType(name.Name()) {
+		return newEvaluationError(InvalidFormError, arguments[0].GetSpan(), "define-condition cannot redefine %s, which is built in", name.Name())
+	}
+
+	parentExpressions, ok := listToSlice(arguments[1])
+	if !ok {
+		return newEvaluationError(InvalidFormError, arguments[1].GetSpan(), "define-condition expects a list of parent types but got %s", describe(arguments[1]))
+	}
+
+	registry := context.Global().conditions
+	parents := []string{}
+	for _, parentExpression := range parentExpressions {
+		parent, ok := parentExpression.(Symbol)
+		if !ok || !registry.isConditionType(parent.Name()) {
+			return newEvaluationError(InvalidFormError, parentExpression.GetSpan(), "define-condition expects condition types as parents but got %s", describe(parentExpression))
+		}
+		if registry.isConditionSubtype(parent.Name(), name.Name()) {
+			return newEvaluationError(InvalidFormError, parentExpression.GetSpan(), "define-condition cannot make %s inherit from %s, which inherits from it", name.Name(), parent.Name())
+		}
+		parents = append(parents, parent.Name())
+	}
+	if len(parents) == 0 {
+		parents = []string{"condition"}
+	}
+
+	registry.parents[name.Name()] = parents
+
+	return SuccessfulEvaluationResult{
+		Expression: name,
+	}
+}
+
+// formatMessage renders a format control string, where ~a inserts an
+// argument as princ would, ~s as prin1 would, ~d inserts a number, ~% a
+// newline and ~~ a tilde.
+func formatMessage(functionName string, control string, arguments []Expression) (string, EvaluationResult) {
+	var result strings.Builder
+	runes := []rune(control)
+
+	for idx := 0; idx < len(runes); idx++ {
+		if runes[idx] != '~' {
+			result.WriteRune(runes[idx])
+			continue
+		}
+
+		idx++
+		if idx == len(runes) {
+			return "", newEvaluationError(TypeMismatchError, Span{}, "%s: the format control %q ends with ~", functionName, control)
+		}
+
+		directive := runes[idx]
+		switch directive {
+		case '%':
+			result.WriteRune('\n')
+			continue
+		case '~':
+			result.WriteRune('~')
+			continue
+		case 'a', 'A', 's', 'S', 'd', 'D':
+		default:
+			return "", newEvaluationError(TypeMismatchError, Span{}, "%s: the format directive ~%c is not supported", functionName, directive)
+		}
+
+		if len(arguments) == 0 {
+			return "", newEvaluationError(ArityMismatchError, Span{}, "%s: the format control %q expects more arguments", functionName, control)
+		}
+		argument := arguments[0]
+		arguments = arguments[1:]
+
+		switch directive {
+		case 'a', 'A':
+			result.WriteString(argument.Print())
+		case 's', 'S':
+			if value, ok := argument.(String); ok {
+				result.WriteString(fmt.Sprintf("%q", value.Value))
+			} else {
+				result.WriteString(argument.Print())
+			}
+		case 'd', 'D':
+			if _, ok := numberLevel(argument); !ok || toBigInt(argument) == nil {
+				return "", newEvaluationError(TypeMismatchError, Span{}, "%s: ~d expects an integer but got %s", functionName, describe(argument))
+			}
+			result.WriteString(argument.Print())
+		}
+	}
+
+	return result.String(), nil
+}
+
+// errorFunction implements (error "format" arguments...), which signals a
+// simple-error, (error 'type ["format" arguments...]), which signals a
+// condition of the given type, and (error condition), which signals a
+// condition again.
+func errorFunction(arguments []Expression, global EvaluationContext) EvaluationResult {
+	if len(arguments) == 0 {
+		return newEvaluationError(ArityMismatchError, Span{}, "error expects a format control or a condition type")
+	}
+
+	if condition, ok := arguments[0].(Condition); ok {
+		if len(arguments) != 1 {
+			return newEvaluationError(ArityMismatchError, Span{}, "error expects no argument after a condition but got %d", len(arguments)-1)
+		}
+		signaled := *condition.Error
+		signaled.Backtrace = nil
+		signaled.signaled = false
+		return UnsuccessfulEvaluationResult{Error: &signaled}
+	}
+
+	conditionType := ""
+	if symbol, ok := arguments[0].(Symbol); ok {
+		if !global.conditions.isConditionType(symbol.Name()) {
+			return newEvaluationError(TypeMismatchError, Span{}, "error expects a condition type but got %s", describe(symbol))
+		}
+		conditionType = symbol.Name()
+		arguments = arguments[1:]
+	}
+
+	message := fmt.Sprintf("a condition of type %s was signaled", conditionType)
+	if len(arguments) > 0 {
+		control, failure := expectString("error", arguments[0])
+		if failure != nil {
+			return failure
+		}
+		message, failure = formatMessage("error", control, arguments[1:])
+		if failure != nil {
+			return failure
+		}
+	}
+
+	return UnsuccessfulEvaluationResult{
+		Error: &EvaluationError{
+			Kind:          SimpleError,
+			ConditionType: conditionType,
+			Message:       message,
+		},
+	}
+}
+
+func errorMessage(arguments []Expression) EvaluationResult {
+	if failure := checkArity("error-message", arguments, 1); failure != nil {
+		return failure
+	}
+
+	condition, ok := arguments[0].(Condition)
+	if !ok {
+		return newEvaluationError(TypeMismatchError, Span{}, "error-message expects a condition but got %s", describe(arguments[0]))
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: String{Value: condition.Error.Message},
+	}
+}
+
+// handlerBinding is a handler established by handler-bind, or a clause of
+// handler-case when function is nil.
+type handlerBinding struct {
+	conditionType string
+	function      Expression
+	span          Span
+}
+
+// handlerStack holds the handlers of the handler-bind, handler-case and
+// ignore-errors forms being evaluated, one cluster per form, innermost
+// last.
+type handlerStack struct {
+	clusters [][]handlerBinding
+}
+
+// push establishes the handlers of a form, and returns the function
+// disestablishing them.
+func (stack *handlerStack) push(cluster []handlerBinding) func() {
+	stack.clusters = append(stack.clusters, cluster)
+	return func() { stack.clusters = stack.clusters[:len(stack.clusters)-1] }
+}
+
+// callHandler calls a handler of the cluster at depth with the handlers of
+// the enclosing clusters only, and signals the condition it may signal.
+func (stack *handlerStack) callHandler(depth int, binding handlerBinding, err *EvaluationError, context EvaluationContext) EvaluationResult {
+	clusters := stack.clusters
+	stack.clusters = clusters[:depth:depth]
+	defer func() { stack.clusters = clusters }()
+
+	return signalCondition(applyFunction(binding.function, []Expression{Condition{Error: err}}, binding.span), context)
+}
+
+// evaluateWithHandlers evaluates forms with the handlers of a cluster
+// established.
+func evaluateWithHandlers(cluster []handlerBinding, forms []Expression, context EvaluationContext) EvaluationResult {
+	defer context.Global().handlers.push(cluster)()
+	return evaluateLoopBody(forms, context)
+}
+
+// signalCondition calls the handler-bind handlers matching the error of a
+// failed evaluation, innermost first, until one of them signals another
+// condition, which replaces it, or a handler-case clause matches, which
+// will catch it. A handler runs with the handlers outside its cluster only.
+// An error is signaled once, and timeouts are not signaled.
+func signalCondition(result EvaluationResult, context EvaluationContext) EvaluationResult {
+	failure, ok := result.(UnsuccessfulEvaluationResult)
+	if !ok || failure.Error.signaled || failure.Error.Kind == TimeoutError {
+		return result
+	}
+	failure.Error.signaled = true
+
+	global := context.Global()
+	stack := global.handlers
+
+	for i := len(stack.clusters) - 1; i >= 0; i-- {
+		for _, binding := range stack.clusters[i] {
+			if !global.conditions.isConditionSubtype(failure.Error.TypeName(), binding.conditionType) {
+				continue
+			}
+			if binding.function == nil {
+				return result
+			}
+
+			if handlerResult := stack.callHandler(i, binding, failure.Error, context); !handlerResult.IsSuccessful() {
+				return handlerResult
+			}
+		}
+	}
+
+	return result
+}
+
+// caughtError returns the error of a failed evaluation if it is a condition
+// of the given type. Returns from loops are not conditions, and neither are
+// timeouts, which must stop the evaluation.
+func caughtError(result EvaluationResult, conditionType string, context EvaluationContext) (*EvaluationError, bool) {
+	failure, ok := result.(UnsuccessfulEvaluationResult)
+	if !ok || failure.Error.Kind == TimeoutError || !context.Global().conditions.isConditionSubtype(failure.Error.TypeName(), conditionType) {
+		return nil, false
+	}
+	return failure.Error, true
+}
+
+// handlerCaseFunction implements (handler-case form (type ([variable])
+// forms...)...). When form signals a condition, the forms of the first
+// clause whose type matches are evaluated with variable bound to the
+// condition.
+func handlerCaseFunction(arguments []Expression, span Span, context EvaluationContext) (result EvaluationResult) {
+	if len(arguments) == 0 {
+		return newEvaluationError(InvalidFormError, span, "handler-case expects a form")
+	}
+
+	type handlerClause struct {
+		conditionType string
+		variable      string
+		body          []Expression
+	}
+
+	clauses := []handlerClause{}
+	for _, clause := range arguments[1:] {
+		elements, ok := listToSlice(clause)
+		if !ok || len(elements) < 2 || elements[0].GetType() != "symbol" {
+			return newEvaluationError(InvalidFormError, clause.GetSpan(), "handler-case expects clauses of the form (type ([variable]) forms...) but got %s", describe(clause))
+		}
+
+		variables, ok := listToSlice(elements[1])
+		if !ok || len(variables) > 1 || (len(variables) == 1 && variables[0].GetType() != "symbol") {
+			return newEvaluationError(InvalidFormError, elements[1].GetSpan(), "handler-case expects a list of at most one variable but got %s", describe(elements[1]))
+		}
+
+		variable := ""
+		if len(variables) == 1 {
+			if failure := checkAssignable("handler-case", variables[0].(Symbol), context); failure != nil {
+				return failure
+			}
+			variable = variables[0].(Symbol).Name()
+		}
+
+		clauses = append(clauses, handlerClause{
+			conditionType: elements[0].(Symbol).Name(),
+			variable:      variable,
+			body:          elements[2:],
+		})
+	}
+
+	cluster := []handlerBinding{}
+	for _, clause := range clauses {
+		cluster = append(cluster, handlerBinding{conditionType: clause.conditionType})
+	}
+	result = evaluateWithHandlers(cluster, arguments[:1], context)
+
+	handlerContext := NewChildContext(context)
+	bindings := newBinder(handlerContext)
+	defer func() { result = bindings.restore(result) }()
+
+	for _, clause := range clauses {
+		caught, ok := caughtError(result, clause.conditionType, context)
+		if !ok {
+			continue
+		}
+
+		if clause.variable != "" {
+			bindings.bind(clause.variable, Condition{Error: caught})
+		}
+		if bindings.isDynamic() {
+			// the forms must be done before the variable is restored
+			return resolveTailCall(evaluateTailBody(clause.body, handlerContext))
+		}
+		return evaluateTailBody(clause.body, handlerContext)
+	}
+
+	return result
+}
+
+// handlerBindFunction implements (handler-bind ((type handler)...)
+// forms...). When forms signal a condition, the handlers whose type matches
+// are called in turn with the condition, before the cleanup forms of
+// unwind-protect run and the dynamic variables are restored. A handler
+// declines by returning normally, and the condition then goes on to the
+// enclosing handlers; it handles the condition by signaling another one.
+func handlerBindFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) == 0 {
+		return newEvaluationError(InvalidFormError, span, "handler-bind expects a list of bindings")
+	}
+
+	bindings, ok := listToSlice(arguments[0])
+	if !ok {
+		return newEvaluationError(InvalidFormError, arguments[0].GetSpan(), "handler-bind expects a list of bindings but got %s", describe(arguments[0]))
+	}
+
+	cluster := []handlerBinding{}
+
+	for _, binding := range bindings {
+		elements, ok := listToSlice(binding)
+		if !ok || len(elements) != 2 || elements[0].GetType() != "symbol" {
+			return newEvaluationError(InvalidFormError, binding.GetSpan(), "handler-bind expects bindings of the form (type handler) but got %s", describe(binding))
+		}
+
+		handlerResult := elements[1].Evaluate(context)
+		if !handlerResult.IsSuccessful() {
+			return handlerResult
+		}
+
+		cluster = append(cluster, handlerBinding{
+			conditionType: elements[0].(Symbol).Name(),
+			function:      handlerResult.(SuccessfulEvaluationResult).Expression,
+			span:          binding.GetSpan(),
+		})
+	}
+
+	defer context.Global().handlers.push(cluster)()
+
+	return signalCondition(evaluateLoopBody(arguments[1:], context), context)
+}
+
+// ignoreErrorsFunction implements (ignore-errors forms...), which returns
+// NIL instead of signaling an error.
+func ignoreErrorsFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	result := evaluateWithHandlers([]handlerBinding{{conditionType: "error"}}, arguments, context)
+
+	if _, ok := caughtError(result, "error", context); ok {
+		return SuccessfulEvaluationResult{
+			Expression: Boolean{Value: false},
+		}
+	}
+	return result
+}
+
+// unwindProtectFunction implements (unwind-protect form cleanup...), which
+// evaluates the cleanup forms however form is left, and returns the result
+// of form unless the cleanup forms fail. The condition signaled by form
+// reaches the handlers before the cleanup forms run.
+func unwindProtectFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) == 0 {
+		return newEvaluationError(InvalidFormError, span, "unwind-protect expects a form")
+	}
+
+	result := signalCondition(arguments[0].Evaluate(context), context)
+
+	if cleanupResult := evaluateLoopBody(arguments[1:], context); !cleanupResult.IsSuccessful() {
+		return cleanupResult
+	}
+	return result
+}
+
+// assertFunction implements (assert test [(places...)] ["format"
+// arguments...]), which signals a simple-error when test is false, and
+// returns NIL otherwise.
+func assertFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) == 0 {
+		return newEvaluationError(InvalidFormError, span, "assert expects a test")
+	}
+
+	testResult := arguments[0].Evaluate(context)
+	if !testResult.IsSuccessful() {
+		return testResult
+	}
+	if isTrue(testResult.(SuccessfulEvaluationResult).Expression) {
+		return SuccessfulEvaluationResult{
+			Expression: Boolean{Value: false},
+		}
+	}
+
+	messageArguments := arguments[1:]
+	if len(messageArguments) > 0 && (messageArguments[0].GetType() == "list" || isNil(messageArguments[0])) {
+		messageArguments = messageArguments[1:]
+	}
+
+	if len(messageArguments) == 0 {
+		return newEvaluationError(SimpleError, span, "the assertion %s failed", arguments[0].Print())
+	}
+
+	values := []Expression{}
+	for _, argument := range messageArguments {
+		evaluationResult := argument.Evaluate(context)
+		if !evaluationResult.IsSuccessful() {
+			return evaluationResult
+		}
+		values = append(values, evaluationResult.(SuccessfulEvaluationResult).Expression)
+	}
+
+	control, failure := expectString("assert", values[0])
+	if failure == nil {
+		var message string
+		message, failure = formatMessage("assert", control, values[1:])
+		if failure == nil {
+			return newEvaluationError(SimpleError, span, "%s", message)
+		}
+	}
+
+	failure.(UnsuccessfulEvaluationResult).Error.Span = span
+	return failure
+}
@@ -0,0 +1,89 @@
+package lisp
+
+import (
+	"testing"
+)
+
+func TestConditions(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "error", source: `(error "bad value ~a, ~d" 'x 3)`, want: "bad value x, 3", wantError: "simple-error"},
+		{name: "handler-case", source: `(handler-case (error "oops") (error (e) (error-message e)))`, want: "oops"},
+		{name: "handler-case without an error", source: "(handler-case (+ 1 2) (error () :failed))", want: "3"},
+		{name: "handler-case without a variable", source: "(handler-case (car 1) (type-error () :caught))", want: ":caught"},
+		{name: "the first matching clause", source: "(handler-case (/ 1 0) (type-error () :type) (arithmetic-error () :arithmetic) (error () :error))", want: ":arithmetic"},
+		{name: "an unmatched clause", source: "(handler-case (handler-case (car 1) (division-by-zero () :inner)) (error () :outer))", want: ":outer"},
+		{name: "a builtin error", source: "(handler-case (undefined-thing) (undefined-function (e) (error-message e)))", want: "the function undefined-thing is undefined"},
+		{name: "ignore-errors", source: "(list (ignore-errors (car 1)) (ignore-errors 5))", want: "(NIL 5)"},
+		{name: "unwind-protect on success", source: "(setq log NIL) (list (unwind-protect :value (push :cleanup log)) log)", want: "(:value (:cleanup))"},
+		{name: "unwind-protect on error", source: "(setq log NIL) (ignore-errors (unwind-protect (car 1) (push :cleanup log))) log", want: "(:cleanup)"},
+		{name: "unwind-protect on return", source: "(setq log NIL) (dotimes (i 3) (unwind-protect (return i) (push i log))) log", want: "(0)"},
+		{name: "handler-bind declines", source: "(setq seen NIL) (handler-case (handler-bind ((error (lambda (c) (setq seen T)))) (car 1)) (error () seen))", want: "T"},
+		{name: "handler-bind handles", source: "(handler-case (handler-bind ((error (lambda (c) (error 'simple-error \"again\")))) (car 1)) (simple-error (e) (error-message e)))", want: "again"},
+		{name: "assert", source: "(assert (= 1 1))", want: "NIL"},
+		{name: "assert fails", source: "(assert (= 1 2))", want: "the assertion (= 1 2) failed", wantError: "simple-error"},
+		{name: "assert with a message", source: "(setq x 2) (assert (= x 1) (x) \"x is ~a\" x)", want: "x is 2", wantError: "simple-error"},
+
+		{name: "define-condition", source: "(define-condition my-error (error)) (handler-case (error 'my-error \"mine\") (my-error (e) (error-message e)))", want: "mine"},
+		{name: "a user condition is an error", source: "(define-condition my-error (error)) (handler-case (error 'my-error) (error () :caught))", want: ":caught"},
+		{name: "a user condition is signaled with its type", source: "(define-condition my-error (error)) (error 'my-error)", want: "a condition of type my-error was signaled", wantError: "my-error"},
+		{name: "a hierarchy of conditions", source: `
+			(define-condition base-error (error))
+			(define-condition left-error (base-error))
+			(define-condition right-error (base-error))
+			(define-condition bottom-error (left-error right-error))
+			(handler-case (error 'bottom-error) (right-error () :right))`, want: ":right"},
+		{name: "a sibling does not catch", source: `
+			(define-condition base-error (error))
+			(define-condition left-error (base-error))
+			(define-condition right-error (base-error))
+			(handler-case (handler-case (error 'left-error) (right-error () :right)) (base-error () :base))`, want: ":base"},
+		{name: "redefining a user condition", source: "(define-condition my-error (error)) (define-condition my-error (type-error)) (handler-case (error 'my-error) (type-error () :type))", want: ":type"},
+		{name: "signaling a caught condition again", source: "(handler-case (handler-case (car 1) (error (e) (error e))) (type-error (e) (error-message e)))", want: "car expects a list but got 1 (int)"},
+
+		{name: "error without arguments", source: "(error)", want: "error expects a format control or a condition type", wantError: "arity-mismatch"},
+		{name: "error of an unknown type", source: "(error 'no-such-condition)", want: "error expects a condition type but got no-such-condition", wantError: "type-error"},
+		{name: "an unsupported directive", source: `(error "~q" 1)`, want: "the format directive ~q is not supported", wantError: "type-error"},
+		{name: "missing format arguments", source: `(error "~a ~a" 1)`, want: "expects more arguments", wantError: "arity-mismatch"},
+		{name: "error-message of a string", source: `(error-message "a")`, want: "error-message expects a condition", wantError: "type-error"},
+		{name: "handler-case without a form", source: "(handler-case)", want: "handler-case expects a form", wantError: "invalid-form"},
+		{name: "a bad handler-case clause", source: "(handler-case 1 error)", want: "handler-case expects clauses of the form", wantError: "invalid-form"},
+		{name: "two handler variables", source: "(handler-case 1 (error (a b) a))", want: "handler-case expects a list of at most one variable", wantError: "invalid-form"},
+		{name: "a bad handler-bind binding", source: "(handler-bind ((error)) 1)", want: "handler-bind expects bindings of the form (type handler)", wantError: "invalid-form"},
+		{name: "unwind-protect without a form", source: "(unwind-protect)", want: "unwind-protect expects a form", wantError: "invalid-form"},
+		{name: "define-condition without parents", source: "(define-condition my-error)", want: "define-condition expects a name and a list of parent types", wantError: "invalid-form"},
+		{name: "an unknown parent", source: "(define-condition my-error (no-such-condition))", want: "define-condition expects condition types as parents", wantError: "invalid-form"},
+		{name: "redefining a builtin condition", source: "(define-condition type-error (error))", want: "define-condition cannot redefine type-error, which is built in", wantError: "invalid-form"},
+		{name: "redefining the root condition", source: "(define-condition condition (error))", want: "cannot redefine condition, which is built in", wantError: "invalid-form"},
+		{name: "inheriting from itself", source: "(define-condition a (error)) (define-condition a (a))", want: "cannot make a inherit from a, which inherits from it", wantError: "invalid-form"},
+		{name: "a cycle of conditions", source: "(define-condition a (error)) (define-condition b (a)) (define-condition a (b))", want: "cannot make a inherit from b, which inherits from it", wantError: "invalid-form"},
+		{name: "a failed redefinition keeps the old parents", source: "(define-condition a (error)) (define-condition b (a)) (ignore-errors (define-condition a (b))) (handler-case (error 'b) (error () :still-an-error))", want: ":still-an-error"},
+	})
+}
+
+func TestConditionTypesArePerInterpreter(t *testing.T) {
+	first := NewInterpreter(InterpreterOptions{})
+	second := NewInterpreter(InterpreterOptions{})
+
+	checkResult(t, evalTest{want: "my-error"}, first.Eval("(define-condition my-error (type-error)) 'my-error"))
+	checkResult(t, evalTest{want: "my-error"}, second.Eval("(define-condition my-error (arithmetic-error)) 'my-error"))
+	checkResult(t, evalTest{want: ":type"}, first.Eval("(handler-case (error 'my-error) (arithmetic-error () :arithmetic) (type-error () :type))"))
+	checkResult(t, evalTest{want: ":arithmetic"}, second.Eval("(handler-case (error 'my-error) (arithmetic-error () :arithmetic) (type-error () :type))"))
+}
+
+func TestHandlerBind(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "the handler runs before the cleanup forms", source: "(setq log NIL) (ignore-errors (handler-bind ((error (lambda (c) (push :handler log)))) (unwind-protect (car 1) (push :cleanup log)))) log", want: "(:cleanup :handler)"},
+		{name: "the handler sees the dynamic bindings", source: "(defvar *v* :global) (setq seen NIL) (ignore-errors (handler-bind ((error (lambda (c) (setq seen *v*)))) (let ((*v* :inner)) (car 1)))) seen", want: ":inner"},
+		{name: "an inner handler-case catches first", source: "(setq seen NIL) (list (handler-bind ((error (lambda (c) (setq seen T)))) (handler-case (car 1) (error () :caught))) seen)", want: "(:caught NIL)"},
+		{name: "an inner handler-case of another type", source: "(setq seen NIL) (ignore-errors (handler-bind ((error (lambda (c) (setq seen T)))) (handler-case (car 1) (division-by-zero () :caught)))) seen", want: "T"},
+		{name: "the innermost handler runs first", source: "(setq log NIL) (ignore-errors (handler-bind ((error (lambda (c) (push :outer log)))) (handler-bind ((error (lambda (c) (push :inner log)))) (car 1)))) log", want: "(:outer :inner)"},
+		{name: "a handler runs once", source: "(setq n 0) (ignore-errors (handler-bind ((error (lambda (c) (setq n (+ n 1))))) (unwind-protect (unwind-protect (car 1) 1) 2))) n", want: "1"},
+		{name: "a handler replaces the condition", source: "(define-condition retry (error)) (setq log NIL) (ignore-errors (handler-bind ((error (lambda (c) (push (type-of c) log)))) (handler-bind ((type-error (lambda (c) (error 'retry)))) (car 1)))) log", want: "(retry)"},
+		{name: "a handler does not see its own cluster", source: "(setq n 0) (ignore-errors (handler-bind ((error (lambda (c) (setq n (+ n 1)) (error \"again\")))) (car 1))) n", want: "1"},
+		{name: "a caught condition signaled again", source: "(setq n 0) (ignore-errors (handler-bind ((error (lambda (c) (setq n (+ n 1))))) (handler-case (car 1) (error (e) (error e))))) n", want: "1"},
+		{name: "handler-bind without an error", source: "(handler-bind ((error (lambda (c) :unused))) 1 2)", want: "2"},
+
+		{name: "a declined condition goes on", source: "(handler-bind ((error (lambda (c) :declined))) (car 1))", want: "car expects a list but got 1", wantError: "type-error"},
+		{name: "a handler that is not a function", source: "(handler-bind ((error 1)) (car 1))", want: "1 (int) is not a function", wantError: "type-error"},
+	})
+}
@@ -0,0 +1,191 @@
+package lisp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Conses, vectors and structures are mutable, so they may contain
+// themselves, for instance once the cdr of a list is set to the list with
+// rplacd. The functions walking values must not follow such cycles
+// forever: lists are printed with labels, compared as their infinite
+// unfoldings, and the builtins expecting a list reject circular ones.
+
+// splitList returns the elements of a list and its final cdr, which is NIL
+// unless the list is dotted. It fails for a circular list.
+func splitList(expression Expression) ([]Expression, Expression, bool) {
+	elements := []Expression{}
+	slow := expression
+
+	for {
+		cons, ok := expression.(*List)
+		if !ok {
+			return elements, expression, true
+		}
+		elements = append(elements, cons.left)
+		expression = cons.right
+
+		// slow moves half as fast, and is caught up with on a cycle
+		if len(elements)%2 == 0 {
+			slow = slow.(*List).right
+			if next, ok := expression.(*List); ok && next == slow.(*List) {
+				return nil, nil, false
+			}
+		}
+	}
+}
+
+// findCycles returns the conses, vectors and structures inside a value
+// which contain themselves.
+func findCycles(root Expression) map[Expression]int {
+	cycles := map[Expression]int{}
+	ancestors := map[Expression]bool{}
+	done := map[Expression]bool{}
+
+	var visit func(expression Expression)
+	visit = func(expression Expression) {
+		switch value := expression.(type) {
+		case *List:
+			// the cdrs are followed in a loop, as lists can be long
+			chain := []*List{}
+			for cons := value; cons != nil; {
+				if ancestors[cons] {
+					cycles[cons] = 0
+					break
+				}
+				if done[cons] {
+					break
+				}
+				ancestors[cons] = true
+				chain = append(chain, cons)
+				visit(cons.left)
+				cons, _ = cons.right.(*List)
+			}
+			if len(chain) == 0 {
+				return
+			}
+			for _, cons := range chain {
+				delete(ancestors, cons)
+				done[cons] = true
+			}
+			if last := chain[len(chain)-1]; last.right.GetType() != "list" {
+				visit(last.right)
+			}
+		case *Vector:
+			visitChildren(expression, value.elements, ancestors, done, cycles, visit)
+		case *Structure:
+			visitChildren(expression, value.values, ancestors, done, cycles, visit)
+		}
+	}
+
+	visit(root)
+	return cycles
+}
+
+func visitChildren(container Expression, children []Expression, ancestors map[Expression]bool, done map[Expression]bool, cycles map[Expression]int, visit func(Expression)) {
+	if ancestors[container] {
+		cycles[container] = 0
+		return
+	}
+	if done[container] {
+		return
+	}
+	ancestors[container] = true
+	for _, child := range children {
+		visit(child)
+	}
+	delete(ancestors, container)
+	done[container] = true
+}
+
+// isCircular reports whether a value contains itself, as code never does.
+func isCircular(expression Expression) bool {
+	return len(findCycles(expression)) > 0
+}
+
+// printer renders a value which may contain itself. As with *print-circle*
+// in Common Lisp, a container that contains itself is labelled #n= where it
+// is first printed, and printed as #n# inside itself.
+type printer struct {
+	builder strings.Builder
+	labels  map[Expression]int
+	count   int
+}
+
+func printExpression(expression Expression) string {
+	p := &printer{labels: findCycles(expression)}
+	p.print(expression)
+	return p.builder.String()
+}
+
+// printLabel writes the label of a container that contains itself, and
+// reports whether the container was already printed, in which case the
+// label stands for it.
+func (p *printer) printLabel(container Expression) bool {
+	label, circular := p.labels[container]
+	switch {
+	case !circular:
+		return false
+	case label > 0:
+		fmt.Fprintf(&p.builder, "#%d#", label)
+		return true
+	}
+	p.count++
+	p.labels[container] = p.count
+	fmt.Fprintf(&p.builder, "#%d=", p.count)
+	return false
+}
+
+func (p *printer) print(expression Expression) {
+	switch value := expression.(type) {
+	case *List:
+		p.printList(value)
+	case *Vector:
+		if p.printLabel(value) {
+			return
+		}
+		p.builder.WriteString("#(")
+		for i, element := range value.elements {
+			if i > 0 {
+				p.builder.WriteString(" ")
+			}
+			p.print(element)
+		}
+		p.builder.WriteString(")")
+	case *Structure:
+		if p.printLabel(value) {
+			return
+		}
+		p.builder.WriteString("#S(" + value.structureType.name)
+		for i, slot := range value.structureType.slots {
+			p.builder.WriteString(" :" + slot + " ")
+			p.print(value.values[i])
+		}
+		p.builder.WriteString(")")
+	default:
+		p.builder.WriteString(expression.Print())
+	}
+}
+
+func (p *printer) printList(list *List) {
+	if p.printLabel(list) {
+		return
+	}
+
+	p.builder.WriteString("(")
+	p.print(list.left)
+
+	for rest := list.right; !isNil(rest); {
+		cons, ok := rest.(*List)
+		if _, circular := p.labels[cons]; !ok || circular {
+			p.builder.WriteString(" . ")
+			p.print(rest)
+			break
+		}
+		p.builder.WriteString(" ")
+		p.print(cons.left)
+		rest = cons.right
+	}
+
+	p.builder.WriteString(")")
+}
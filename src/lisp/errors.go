@@ -0,0 +1,168 @@
+package lisp
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+type ErrorKind int
+
+const (
+	InternalError ErrorKind = iota
+	UnboundVariableError
+	UnknownFunctionError
+	ArityMismatchError
+	TypeMismatchError
+	DivisionByZeroError
+	InvalidFormError
+	ReaderError
+	// SimpleError is signaled by the error function.
+	SimpleError
+	NoApplicableMethodError
+	// FileError is signaled when a file cannot be loaded.
+	FileError
+	// CallDepthError is signaled when calls are nested too deeply.
+	CallDepthError
+	// ArithmeticError is signaled when a number would be too large.
+	ArithmeticError
+	// TimeoutError is signaled when an evaluation runs for longer than
+	// the time limit of its interpreter. Handlers do not catch it.
+	TimeoutError
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case UnboundVariableError:
+		return "unbound-variable"
+	case UnknownFunctionError:
+		return "undefined-function"
+	case ArityMismatchError:
+		return "arity-mismatch"
+	case TypeMismatchError:
+		return "type-error"
+	case DivisionByZeroError:
+		return "division-by-zero"
+	case InvalidFormError:
+		return "invalid-form"
+	case ReaderError:
+		return "reader-error"
+	case SimpleError:
+		return "simple-error"
+	case NoApplicableMethodError:
+		return "no-applicable-method"
+	case FileError:
+		return "file-error"
+	case CallDepthError:
+		return "call-depth-exceeded"
+	case ArithmeticError:
+		return "arithmetic-error"
+	case TimeoutError:
+		return "timeout"
+	}
+	return "internal-error"
+}
+
+// Frame is a Lisp function call that was in progress when an error
+// occurred.
+type Frame struct {
+	FunctionName string
+	Span         Span
+}
+
+func (f Frame) String() string {
+	if f.Span.IsZero() {
+		return fmt.Sprintf("(%s ...)", f.FunctionName)
+	}
+	return fmt.Sprintf("(%s ...) at %s", f.FunctionName, f.Span)
+}
+
+// EvaluationError describes why an evaluation failed. Backtrace lists the
+// Lisp function calls the error went through, innermost first.
+type EvaluationError struct {
+	Kind ErrorKind
+	// ConditionType is the name of the condition type signaled with
+	// (error 'type ...), if any.
+	ConditionType string
+	Message       string
+	Span          Span
+	Backtrace     []Frame
+	// signaled is set once the handlers established with handler-bind
+	// have been called for the error.
+	signaled bool
+}
+
+// TypeName returns the name of the condition type of the error.
+func (e *EvaluationError) TypeName() string {
+	if e.ConditionType != "" {
+		return e.ConditionType
+	}
+	return e.Kind.String()
+}
+
+func (e *EvaluationError) Error() string {
+	if e.Span.IsZero() {
+		return fmt.Sprintf("%s: %s", e.TypeName(), e.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.Span, e.TypeName(), e.Message)
+}
+
+// Report returns the error message followed by its Lisp backtrace, one
+// frame per line.
+func (e *EvaluationError) Report() string {
+	lines := []string{e.Error()}
+	for _, frame := range e.Backtrace {
+		lines = append(lines, "  in "+frame.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// maxBacktraceFrames bounds the backtrace of errors raised by deep
+// recursions, which keeps the innermost frames.
+const maxBacktraceFrames = 50
+
+func (e *EvaluationError) pushFrame(functionName string, span Span) {
+	if len(e.Backtrace) >= maxBacktraceFrames {
+		return
+	}
+	e.Backtrace = append(e.Backtrace, Frame{FunctionName: functionName, Span: span})
+}
+
+func newEvaluationError(kind ErrorKind, span Span, format string, arguments ...interface{}) UnsuccessfulEvaluationResult {
+	return UnsuccessfulEvaluationResult{
+		Error: &EvaluationError{
+			Kind:    kind,
+			Message: fmt.Sprintf(format, arguments...),
+			Span:    span,
+		},
+	}
+}
+
+// countArguments renders a number of arguments for error messages, such as
+// "1 argument" or "2 arguments".
+func countArguments(count int) string {
+	if count == 1 {
+		return "1 argument"
+	}
+	return fmt.Sprintf("%d arguments", count)
+}
+
+// describe renders a value for error messages, with its type.
+func describe(expression Expression) string {
+	if expression == nil {
+		return "nothing"
+	}
+	if expression.GetType() == "string" {
+		return fmt.Sprintf("%q (string)", expression.Print())
+	}
+	return fmt.Sprintf("%s (%s)", expression.Print(), expression.GetType())
+}
+
+// recoverEvaluationError converts a Go panic raised while evaluating into
+// an unsuccessful evaluation result.
+func recoverEvaluationError(recovered interface{}, span Span) UnsuccessfulEvaluationResult {
+	if runtimeError, ok := recovered.(runtime.Error); ok && strings.Contains(runtimeError.Error(), "divide by zero") {
+		return newEvaluationError(DivisionByZeroError, span, "division by zero")
+	}
+	return newEvaluationError(InternalError, span, "%v", recovered)
+}
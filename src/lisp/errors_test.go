@@ -0,0 +1,74 @@
+package lisp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestErrorKinds(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "unbound variable", source: "(+ 1 x)", want: "the variable x is unbound", wantError: "unbound-variable"},
+		{name: "undefined function", source: "(frobnicate 1)", want: "frobnicate is undefined", wantError: "undefined-function"},
+		{name: "arity mismatch", source: "(car 1 2)", want: "car expects 1 argument but got 2", wantError: "arity-mismatch"},
+		{name: "type mismatch", source: "(car 1)", want: "car expects a list but got 1 (int)", wantError: "type-error"},
+		{name: "division by zero", source: "(/ 1 0)", wantError: "division-by-zero"},
+		{name: "invalid form", source: "(defun)", wantError: "invalid-form"},
+		{name: "user function arity", source: "(defun f (a) a) (f 1 2)", want: "f expects 1 argument", wantError: "arity-mismatch"},
+		{name: "deep recursion", source: "(defun f (n) (+ 1 (f (- n 1)))) (f 1)", want: "maximum call depth", wantError: "call-depth-exceeded"},
+		{name: "recursive macro", source: "(defmacro m () '(m)) (m)", want: "maximum call depth", wantError: "call-depth-exceeded"},
+		{name: "tail recursion is not limited", source: "(defun f (n) (if (= n 0) :done (f (- n 1)))) (f 100000)", want: ":done"},
+	})
+}
+
+func TestErrorReport(t *testing.T) {
+	interpreter := NewInterpreter(InterpreterOptions{})
+	interpreter.Eval("(defun inner (x) (car x))\n(defun outer (x) (list (inner x)))")
+
+	result := interpreter.Eval("(outer 5)")
+	failure, ok := result.(UnsuccessfulEvaluationResult)
+	if !ok {
+		t.Fatalf("(outer 5) = %#v, want an error", result)
+	}
+
+	if failure.Error.Kind != TypeMismatchError {
+		t.Errorf("the error kind is %s, want type-error", failure.Error.Kind)
+	}
+	if got := failure.Error.Span.String(); got != "1:18" {
+		t.Errorf("the error is located at %s, want 1:18, the call to car", got)
+	}
+
+	functionNames := []string{}
+	for _, frame := range failure.Error.Backtrace {
+		functionNames = append(functionNames, frame.FunctionName)
+	}
+	if got := strings.Join(functionNames, " "); got != "inner outer" {
+		t.Errorf("the backtrace is %s, want inner outer", got)
+	}
+
+	report := failure.Error.Report()
+	want := "1:18: type-error: car expects a list but got 5 (int)\n  in (inner ...) at 2:24\n  in (outer ...) at 1:1"
+	if report != want {
+		t.Errorf("the report is\n%s\nwant\n%s", report, want)
+	}
+}
+
+func TestBacktraceIsBounded(t *testing.T) {
+	interpreter := NewInterpreter(InterpreterOptions{MaxCallDepth: 500})
+	result := interpreter.Eval("(defun f (n) (+ 1 (f (- n 1)))) (f 1)")
+
+	failure, ok := result.(UnsuccessfulEvaluationResult)
+	if !ok || failure.Error.Kind != CallDepthError {
+		t.Fatalf("the recursion returned %#v, want a call-depth-exceeded error", result)
+	}
+	if !strings.Contains(failure.Error.Message, "500") {
+		t.Errorf("the message %q does not give the configured depth", failure.Error.Message)
+	}
+	if len(failure.Error.Backtrace) != maxBacktraceFrames {
+		t.Errorf("the backtrace has %d frames, want %d", len(failure.Error.Backtrace), maxBacktraceFrames)
+	}
+
+	// the depth is back to zero after the error
+	if result := interpreter.Eval("(defun g (n) (if (= n 0) 0 (+ 1 (g (- n 1))))) (g 400)"); !result.IsSuccessful() {
+		t.Errorf("a recursion within the limit failed: %s", result.(UnsuccessfulEvaluationResult).Error.Report())
+	}
+}
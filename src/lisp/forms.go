@@ -0,0 +1,232 @@
+package lisp
+
+// Special forms binding variables and controlling which expressions are
+// evaluated.
+
+func isTrue(expression Expression) bool {
+	return !isNil(expression)
+}
+
+func prognFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	return evaluateTailBody(arguments, context)
+}
+
+// parseBinding reads a binding of a let form, either a symbol bound to NIL
+// or a list (symbol [value]).
+func parseBinding(formName string, binding Expression) (Symbol, Expression, EvaluationResult) {
+	if symbol, ok := binding.(Symbol); ok {
+		return symbol, Boolean{Value: false}, nil
+	}
+
+	elements, ok := listToSlice(binding)
+	if ok && len(elements) >= 1 && len(elements) <= 2 {
+		if symbol, ok := elements[0].(Symbol); ok {
+			if len(elements) == 1 {
+				return symbol, Boolean{Value: false}, nil
+			}
+			return symbol, elements[1], nil
+		}
+	}
+
+	return Symbol{}, nil, newEvaluationError(InvalidFormError, binding.GetSpan(), "%s expects bindings of the form (variable value) but got %s", formName, describe(binding))
+}
+
+// letFunction implements let, which evaluates every value before binding
+// the variables, and let*, which binds each variable before evaluating the
+// next value.
+func letFunction(formName string, arguments []Expression, span Span, context EvaluationContext, sequential bool) (result EvaluationResult) {
+	if len(arguments) == 0 {
+		return newEvaluationError(InvalidFormError, span, "%s expects a list of bindings", formName)
+	}
+
+	specifications, ok := listToSlice(arguments[0])
+	if !ok {
+		return newEvaluationError(InvalidFormError, arguments[0].GetSpan(), "%s expects a list of bindings but got %s", formName, describe(arguments[0]))
+	}
+
+	letContext := NewChildContext(context)
+	valuesContext := context
+	if sequential {
+		valuesContext = letContext
+	}
+
+	symbols := []Symbol{}
+	values := []Expression{}
+
+	bindings := newBinder(letContext)
+	defer func() { result = bindings.restore(result) }()
+
+	for _, specification := range specifications {
+		symbol, valueExpression, failure := parseBinding(formName, specification)
+		if failure != nil {
+			return failure
+		}
+		if failure := checkAssignable(formName, symbol, context); failure != nil {
+			return failure
+		}
+
+		evaluationResult := valueExpression.Evaluate(valuesContext)
+		if !evaluationResult.IsSuccessful() {
+			return evaluationResult
+		}
+		value := evaluationResult.(SuccessfulEvaluationResult).Expression
+
+		if sequential {
+			bindings.bind(symbol.Name(), value)
+		}
+		symbols = append(symbols, symbol)
+		values = append(values, value)
+	}
+
+	if !sequential {
+		for i, symbol := range symbols {
+			bindings.bind(symbol.Name(), values[i])
+		}
+	}
+
+	if bindings.isDynamic() {
+		// the body must be done before the dynamic bindings are restored
+		return resolveTailCall(evaluateTailBody(arguments[1:], letContext))
+	}
+	return evaluateTailBody(arguments[1:], letContext)
+}
+
+// condFunction implements (cond (test forms...)...): the forms of the first
+// clause whose test is true are evaluated. A clause without forms returns
+// the value of its test.
+func condFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	for _, clause := range arguments {
+		elements, ok := listToSlice(clause)
+		if !ok || len(elements) == 0 {
+			return newEvaluationError(InvalidFormError, clause.GetSpan(), "cond expects clauses of the form (test forms...) but got %s", describe(clause))
+		}
+
+		testResult := elements[0].Evaluate(context)
+		if !testResult.IsSuccessful() {
+			return testResult
+		}
+
+		if isTrue(testResult.(SuccessfulEvaluationResult).Expression) {
+			if len(elements) == 1 {
+				return testResult
+			}
+			return evaluateTailBody(elements[1:], context)
+		}
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: Boolean{Value: false},
+	}
+}
+
+// whenFunction implements when, which evaluates its body if the test is
+// true, and unless, which evaluates it if the test is false.
+func whenFunction(formName string, arguments []Expression, span Span, context EvaluationContext, expected bool) EvaluationResult {
+	if len(arguments) == 0 {
+		return newEvaluationError(InvalidFormError, span, "%s expects a test", formName)
+	}
+
+	testResult := arguments[0].Evaluate(context)
+	if !testResult.IsSuccessful() {
+		return testResult
+	}
+
+	if isTrue(testResult.(SuccessfulEvaluationResult).Expression) != expected {
+		return SuccessfulEvaluationResult{
+			Expression: Boolean{Value: false},
+		}
+	}
+
+	return evaluateTailBody(arguments[1:], context)
+}
+
+// andFunction returns NIL as soon as an argument is NIL, or the value of
+// the last argument.
+func andFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) == 0 {
+		return SuccessfulEvaluationResult{
+			Expression: Boolean{Value: true},
+		}
+	}
+
+	for _, argument := range arguments[:len(arguments)-1] {
+		result := argument.Evaluate(context)
+		if !result.IsSuccessful() || isNil(result.(SuccessfulEvaluationResult).Expression) {
+			return result
+		}
+	}
+
+	return evaluateTail(arguments[len(arguments)-1], context)
+}
+
+// orFunction returns the value of the first argument that is not NIL.
+func orFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) == 0 {
+		return SuccessfulEvaluationResult{
+			Expression: Boolean{Value: false},
+		}
+	}
+
+	for _, argument := range arguments[:len(arguments)-1] {
+		result := argument.Evaluate(context)
+		if !result.IsSuccessful() || isTrue(result.(SuccessfulEvaluationResult).Expression) {
+			return result
+		}
+	}
+
+	return evaluateTail(arguments[len(arguments)-1], context)
+}
+
+// caseFunction implements (case key (keys forms...)...), where keys is a
+// single key or a list of keys compared to the value of key with eql, and
+// T or otherwise introduces the default clause. ecase reports an error
+// instead of returning NIL when no clause matches.
+func caseFunction(formName string, arguments []Expression, span Span, context EvaluationContext, exhaustive bool) EvaluationResult {
+	if len(arguments) == 0 {
+		return newEvaluationError(InvalidFormError, span, "%s expects a key form", formName)
+	}
+
+	keyResult := arguments[0].Evaluate(context)
+	if !keyResult.IsSuccessful() {
+		return keyResult
+	}
+	key := keyResult.(SuccessfulEvaluationResult).Expression
+
+	for idx, clause := range arguments[1:] {
+		elements, ok := listToSlice(clause)
+		if !ok || len(elements) == 0 {
+			return newEvaluationError(InvalidFormError, clause.GetSpan(), "%s expects clauses of the form (keys forms...) but got %s", formName, describe(clause))
+		}
+
+		isLastClause := idx == len(arguments)-2
+		isDefault := isEq(elements[0], Boolean{Value: true}) || isFormSymbol(elements[0], "otherwise")
+		if isDefault && !exhaustive && isLastClause {
+			return evaluateTailBody(elements[1:], context)
+		}
+
+		keys, ok := listToSlice(elements[0])
+		if !ok || isNil(elements[0]) {
+			keys = []Expression{elements[0]}
+		}
+
+		for _, clauseKey := range keys {
+			if isEql(clauseKey, key) {
+				return evaluateTailBody(elements[1:], context)
+			}
+		}
+	}
+
+	if exhaustive {
+		return newEvaluationError(TypeMismatchError, span, "%s fell through: no clause matches %s", formName, describe(key))
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: Boolean{Value: false},
+	}
+}
+
+// isFormSymbol reports whether an expression is the symbol of a given name.
+func isFormSymbol(expression Expression, name string) bool {
+	symbol, ok := expression.(Symbol)
+	return ok && unqualifiedName(symbol.Name()) == name
+}
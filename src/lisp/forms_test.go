@@ -0,0 +1,41 @@
+package lisp
+
+import (
+	"testing"
+)
+
+func TestForms(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "let", source: "(let ((x 1) (y 2)) (+ x y))", want: "3"},
+		{name: "let binds in parallel", source: "(setq x 10) (let ((x 1) (y x)) y)", want: "10"},
+		{name: "let*", source: "(let* ((x 1) (y (+ x 1))) y)", want: "2"},
+		{name: "let without a value", source: "(let (x) x)", want: "NIL"},
+		{name: "let restores the outer binding", source: "(setq x 1) (let ((x 2)) x) x", want: "1"},
+		{name: "progn", source: "(progn 1 2 3)", want: "3"},
+		{name: "empty progn", source: "(progn)", want: "NIL"},
+		{name: "cond", source: "(cond ((= 1 2) :a) ((= 1 1) :b) (T :c))", want: ":b"},
+		{name: "cond without a match", source: "(cond ((= 1 2) :a))", want: "NIL"},
+		{name: "cond returns the test", source: "(cond ((+ 1 2)))", want: "3"},
+		{name: "when", source: "(list (when T 1 2) (when NIL 1))", want: "(2 NIL)"},
+		{name: "unless", source: "(list (unless NIL 1 2) (unless T 1))", want: "(2 NIL)"},
+		{name: "and", source: "(list (and) (and 1 2) (and 1 NIL 2))", want: "(T 2 NIL)"},
+		{name: "or", source: "(list (or) (or NIL 2) (or NIL NIL))", want: "(NIL 2 NIL)"},
+		{name: "and short-circuits", source: "(setq x 0) (and NIL (setq x 1)) x", want: "0"},
+		{name: "or short-circuits", source: "(setq x 0) (or T (setq x 1)) x", want: "0"},
+		{name: "case", source: "(case 2 (1 :one) ((2 3) :two-or-three) (otherwise :other))", want: ":two-or-three"},
+		{name: "case of a symbol", source: "(case 'b (a 1) (b 2))", want: "2"},
+		{name: "case otherwise", source: "(case 9 (1 :one) (T :other))", want: ":other"},
+		{name: "case without a match", source: "(case 9 (1 :one))", want: "NIL"},
+		{name: "ecase", source: "(ecase :b (:a 1) (:b 2))", want: "2"},
+
+		{name: "let without bindings", source: "(let)", want: "let expects a list of bindings", wantError: "invalid-form"},
+		{name: "let with a number as bindings", source: "(let 1 1)", want: "let expects a list of bindings but got 1", wantError: "invalid-form"},
+		{name: "let* with a bad binding", source: "(let* ((1 2)) 1)", want: "let* expects bindings of the form (variable value)", wantError: "invalid-form"},
+		{name: "a bad cond clause", source: "(cond 1)", want: "cond expects clauses of the form (test forms...)", wantError: "invalid-form"},
+		{name: "when without a test", source: "(when)", want: "when expects a test", wantError: "invalid-form"},
+		{name: "case without a key", source: "(case)", want: "case expects a key form", wantError: "invalid-form"},
+		{name: "a bad case clause", source: "(case 1 2)", want: "case expects clauses of the form (keys forms...)", wantError: "invalid-form"},
+		{name: "ecase without a match", source: "(ecase 3 (1 :one))", want: "ecase fell through: no clause matches 3", wantError: "type-error"},
+		{name: "errors in a binding", source: "(let ((x (car 1))) x)", wantError: "type-error"},
+	})
+}
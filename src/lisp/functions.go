@@ -0,0 +1,388 @@
+package lisp
+
+import (
+	"fmt"
+	"time"
+)
+
+// Builtin is a function implemented in Go. Builtins receive their
+// arguments already evaluated.
+type Builtin struct {
+	BaseTypeExpression
+	Name     string
+	function func(arguments []Expression) EvaluationResult
+	Span     Span
+}
+
+func (b Builtin) GetType() string {
+	return "builtin"
+}
+
+func (b Builtin) GetSpan() Span {
+	return b.Span
+}
+
+func (b Builtin) Print() string {
+	return fmt.Sprintf("#%s", b.Name)
+}
+
+func (b Builtin) Evaluate(context EvaluationContext) EvaluationResult {
+	return SuccessfulEvaluationResult{
+		Expression: b,
+	}
+}
+
+func (fd FunctionDeclaration) Evaluate(context EvaluationContext) EvaluationResult {
+	return SuccessfulEvaluationResult{
+		Expression: fd,
+	}
+}
+
+func isFunction(expression Expression) bool {
+	return expression.GetType() == "builtin" || expression.GetType() == "functionDeclaration"
+}
+
+// lookupFunctionValue finds the function bound to a name, builtins taking
+// precedence over user defined functions.
+func lookupFunctionValue(name string, context EvaluationContext) (Expression, bool) {
+	if function, ok := builtinFunctions[name]; ok {
+		return Builtin{Name: name, function: function}, true
+	}
+	if function, ok := interpreterBuiltins[name]; ok {
+		global := context.Global()
+		return Builtin{Name: name, function: func(arguments []Expression) EvaluationResult {
+			return function(arguments, global)
+		}}, true
+	}
+	if functionDeclaration, ok := context.LookupFunction(name); ok {
+		return functionDeclaration, true
+	}
+	return nil, false
+}
+
+// callBuiltin runs a builtin function, converting the Go panics it may
+// raise into evaluation errors located at the function call.
+func callBuiltin(builtin Builtin, arguments []Expression, span Span) (result EvaluationResult) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result = recoverEvaluationError(recovered, span)
+		}
+	}()
+
+	result = builtin.function(arguments)
+
+	if failure, ok := result.(UnsuccessfulEvaluationResult); ok {
+		if failure.Error.Span.IsZero() {
+			failure.Error.Span = span
+		}
+		// a builtin running Lisp code, such as load, adds itself to the
+		// backtrace without knowing where it is called
+		backtrace := failure.Error.Backtrace
+		if last := len(backtrace) - 1; last >= 0 && backtrace[last].FunctionName == builtin.Name && backtrace[last].Span.IsZero() {
+			backtrace[last].Span = span
+		}
+	}
+	return result
+}
+
+// applyFunction calls a builtin or a user defined function with already
+// evaluated arguments. The span is the one of the call, used to report
+// errors.
+func applyFunction(function Expression, arguments []Expression, span Span) EvaluationResult {
+	switch f := function.(type) {
+	case Builtin:
+		return callBuiltin(f, arguments, span)
+	case FunctionDeclaration:
+		if f.isMacro {
+			return newEvaluationError(TypeMismatchError, span, "%s is a macro, not a function", f.functionName)
+		}
+		return callFunctionDeclaration(f, arguments, span)
+	}
+
+	return newEvaluationError(TypeMismatchError, span, "%s is not a function", describe(function))
+}
+
+// tailCall is the result of a call to a user defined function evaluated
+// in tail position: the call is left to callFunctionDeclaration, which
+// runs the calls returned by a function body in a loop instead of nesting
+// them. A tailCall never escapes Evaluate.
+type tailCall struct {
+	function  FunctionDeclaration
+	arguments []Expression
+	span      Span
+}
+
+func (tc tailCall) IsSuccessful() bool {
+	return false
+}
+
+// evaluateTail evaluates an expression in tail position, where a call to a
+// user defined function may be returned as a tailCall.
+func evaluateTail(expression Expression, context EvaluationContext) EvaluationResult {
+	if form, ok := expression.(*List); ok {
+		return form.evaluateForm(context)
+	}
+	return expression.Evaluate(context)
+}
+
+// evaluateTailBody evaluates expressions in turn, the last one in tail
+// position, and returns NIL when there is none.
+func evaluateTailBody(expressions []Expression, context EvaluationContext) EvaluationResult {
+	if len(expressions) == 0 {
+		return SuccessfulEvaluationResult{
+			Expression: Boolean{Value: false},
+		}
+	}
+
+	for _, expression := range expressions[:len(expressions)-1] {
+		evaluationResult := expression.Evaluate(context)
+		if !evaluationResult.IsSuccessful() {
+			return evaluationResult
+		}
+	}
+
+	return evaluateTail(expressions[len(expressions)-1], context)
+}
+
+func resolveTailCall(result EvaluationResult) EvaluationResult {
+	if call, ok := result.(tailCall); ok {
+		return callFunctionDeclaration(call.function, call.arguments, call.span)
+	}
+	return result
+}
+
+// callFunctionDeclaration binds the parameters of a user defined function
+// or macro to the given arguments and evaluates its body, then does the
+// same for the function called in tail position, if any.
+func callFunctionDeclaration(f FunctionDeclaration, arguments []Expression, span Span) EvaluationResult {
+	for {
+		result := invokeFunctionDeclaration(f, arguments, span)
+
+		call, ok := result.(tailCall)
+		if !ok {
+			return result
+		}
+		f, arguments, span = call.function, call.arguments, call.span
+	}
+}
+
+// DefaultMaxCallDepth is the number of nested calls after which an
+// evaluation fails, rather than exhausting the Go stack.
+const DefaultMaxCallDepth = 10000
+
+// callDepth counts the calls in progress in an interpreter.
+type callDepth struct {
+	current int
+	maximum int
+}
+
+// enterCall counts a nested call, and fails when there are too many. The
+// function returned is to be called once the call is over.
+func (context EvaluationContext) enterCall(functionName string, span Span) (func(), EvaluationResult) {
+	if failure := context.checkTimeout(functionName, span); failure != nil {
+		return nil, failure
+	}
+
+	depth := context.Global().depth
+	if depth == nil {
+		return func() {}, nil
+	}
+	if depth.current >= depth.maximum {
+		return nil, newEvaluationError(CallDepthError, span, "%s exceeds the maximum call depth of %d", functionName, depth.maximum)
+	}
+	depth.current += 1
+	return func() { depth.current -= 1 }, nil
+}
+
+// evaluationTimeout is the time limit of the evaluations of an
+// interpreter. The deadline is zero when no evaluation is in progress.
+type evaluationTimeout struct {
+	limit    time.Duration
+	deadline time.Time
+}
+
+// start sets the deadline of an evaluation, unless there is no limit or an
+// evaluation is already in progress. The function returned is to be called
+// once the evaluation is over.
+func (timeout *evaluationTimeout) start() func() {
+	if timeout.limit <= 0 || !timeout.deadline.IsZero() {
+		return func() {}
+	}
+	timeout.deadline = time.Now().Add(timeout.limit)
+	return func() { timeout.deadline = time.Time{} }
+}
+
+// checkTimeout fails once the evaluation in progress has run past its
+// deadline. It is checked on each call and on each iteration of a loop,
+// which are the only ways for an evaluation to last.
+func (context EvaluationContext) checkTimeout(formName string, span Span) EvaluationResult {
+	timeout := context.Global().timeout
+	if timeout == nil || timeout.deadline.IsZero() || time.Now().Before(timeout.deadline) {
+		return nil
+	}
+	return newEvaluationError(TimeoutError, span, "%s exceeds the time limit of %s", formName, timeout.limit)
+}
+
+func invokeFunctionDeclaration(f FunctionDeclaration, arguments []Expression, span Span) (result EvaluationResult) {
+	leave, failure := f.context.enterCall(f.functionName, span)
+	if failure != nil {
+		return failure
+	}
+	defer leave()
+
+	if f.generic != nil {
+		return f.generic.call(arguments, span, f.context)
+	}
+
+	functionContext := NewChildContext(f.context)
+	bindings := newBinder(functionContext)
+	defer func() { result = bindings.restore(result) }()
+
+	if failure := f.parameters.bind(f.functionName, arguments, span, bindings); failure != nil {
+		return failure
+	}
+
+	result = evaluateTailBody(f.body.SubExpressions, functionContext)
+	if bindings.isDynamic() {
+		// the body must be done before the dynamic parameters are restored
+		result = resolveTailCall(result)
+	}
+	if rr, ok := result.(returnResult); ok {
+		result = newEvaluationError(InvalidFormError, rr.span, "return is only allowed inside a loop")
+	}
+	if failure, ok := result.(UnsuccessfulEvaluationResult); ok {
+		failure.Error.pushFrame(f.functionName, span)
+	}
+	return result
+}
+
+// makeFunction builds a function out of a lambda list followed by an
+// optional documentation string and a body. The function closes over the
+// given context.
+func makeFunction(functionName string, expressions []Expression, span Span, context EvaluationContext) (FunctionDeclaration, EvaluationResult) {
+	if len(expressions) == 0 {
+		return FunctionDeclaration{}, newEvaluationError(InvalidFormError, span, "%s is missing its parameter list", functionName)
+	}
+
+	parameters, failure := parseLambdaList(functionName, expressions[0])
+	if failure != nil {
+		return FunctionDeclaration{}, failure
+	}
+	if failure := parameters.checkConstants(functionName, expressions[0].GetSpan(), context); failure != nil {
+		return FunctionDeclaration{}, failure
+	}
+
+	functionDocumentation := ""
+	bodyExpressions := expressions[1:]
+
+	if len(bodyExpressions) > 1 && bodyExpressions[0].GetType() == "string" {
+		functionDocumentation = bodyExpressions[0].(String).Value
+		bodyExpressions = bodyExpressions[1:]
+	}
+
+	return FunctionDeclaration{
+		functionName:          functionName,
+		functionDocumentation: functionDocumentation,
+		parameters:            parameters,
+		body:                  Block{SubExpressions: bodyExpressions},
+		context:               context,
+		identity:              new(int),
+		Span:                  span,
+	}, nil
+}
+
+func defineFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) == 0 || arguments[0].GetType() != "symbol" {
+		return newEvaluationError(InvalidFormError, span, "defun expects a function name")
+	}
+
+	functionName := arguments[0].(Symbol).Name()
+	if failure := checkDefinable("defun", functionName, span, context); failure != nil {
+		return failure
+	}
+
+	functionDeclaration, failure := makeFunction(functionName, arguments[1:], span, context)
+	if failure != nil {
+		return failure
+	}
+
+	context.DefineFunction(functionName, functionDeclaration)
+
+	return SuccessfulEvaluationResult{
+		Expression: functionDeclaration,
+	}
+}
+
+func lambdaFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	functionDeclaration, failure := makeFunction("lambda", arguments, span, context)
+	if failure != nil {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: functionDeclaration,
+	}
+}
+
+// functionFunction implements (function name), also written #'name, which
+// returns the function bound to a name, and (function (lambda ...)).
+func functionFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) != 1 {
+		return newEvaluationError(ArityMismatchError, span, "function expects 1 argument but got %d", len(arguments))
+	}
+
+	argument := arguments[0]
+
+	if isFormNamed(argument, "lambda") {
+		return argument.Evaluate(context)
+	}
+
+	if argument.GetType() != "symbol" {
+		return newEvaluationError(InvalidFormError, argument.GetSpan(), "function expects a function name but got %s", describe(argument))
+	}
+
+	functionName := argument.(Symbol).Name()
+
+	if function, ok := lookupFunctionValue(functionName, context); ok {
+		return SuccessfulEvaluationResult{
+			Expression: function,
+		}
+	}
+
+	return newEvaluationError(UnknownFunctionError, argument.GetSpan(), "the function %s is undefined", functionName)
+}
+
+func funcall(arguments []Expression) EvaluationResult {
+	if len(arguments) == 0 {
+		return newEvaluationError(ArityMismatchError, Span{}, "funcall expects a function")
+	}
+
+	return applyFunction(arguments[0], arguments[1:], Span{})
+}
+
+func apply(arguments []Expression) EvaluationResult {
+	if len(arguments) < 2 {
+		return newEvaluationError(ArityMismatchError, Span{}, "apply expects a function and a list of arguments but got %s", countArguments(len(arguments)))
+	}
+
+	lastArgument := arguments[len(arguments)-1]
+	spreadArguments, ok := listToSlice(lastArgument)
+	if !ok {
+		return newEvaluationError(TypeMismatchError, Span{}, "apply expects its last argument to be a list but got %s", describe(lastArgument))
+	}
+
+	functionArguments := append([]Expression{}, arguments[1:len(arguments)-1]...)
+	functionArguments = append(functionArguments, spreadArguments...)
+
+	return applyFunction(arguments[0], functionArguments, Span{})
+}
+
+// listToSlice returns the elements of a proper list, which is neither
+// dotted nor circular.
+func listToSlice(expression Expression) ([]Expression, bool) {
+	elements, tail, ok := splitList(expression)
+	if !ok || !isNil(tail) {
+		return nil, false
+	}
+	return elements, true
+}
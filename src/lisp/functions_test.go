@@ -0,0 +1,72 @@
+package lisp
+
+import (
+	"testing"
+)
+
+func TestFunctions(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "defun", source: "(defun square (x) (* x x)) (square 7)", want: "49"},
+		{name: "defun returns the function", source: "(defun square (x) (* x x))", want: "#square"},
+		{name: "lambda call", source: "((lambda (x y) (+ x y)) 1 2)", want: "3"},
+		{name: "function quote", source: "(funcall #'car '(1 2))", want: "1"},
+		{name: "function quote of a lambda", source: "(funcall #'(lambda (x) (* 2 x)) 4)", want: "8"},
+		{name: "funcall", source: "(defun add (a b) (+ a b)) (funcall #'add 1 2)", want: "3"},
+		{name: "apply spreads the last argument", source: "(apply #'+ 1 2 '(3 4))", want: "10"},
+		{name: "apply to a lambda", source: "(apply (lambda (&rest xs) xs) '(a b))", want: "(a b)"},
+		{name: "functions as values", source: "(setq f (lambda (x) (+ x 1))) (funcall f 1)", want: "2"},
+		{name: "higher order", source: "(defun twice (f x) (funcall f (funcall f x))) (twice (lambda (x) (* x 3)) 2)", want: "18"},
+		{name: "closure captures a binding", source: "(defun adder (n) (lambda (x) (+ x n))) (funcall (adder 10) 5)", want: "15"},
+		{name: "closures share a binding", source: `
+			(defun make-counter ()
+			  (let ((count 0))
+			    (list (lambda () (setq count (+ count 1)))
+			          (lambda () count))))
+			(setq counter (make-counter))
+			(funcall (car counter))
+			(funcall (car counter))
+			(funcall (car (cdr counter)))`, want: "2"},
+		{name: "closures are independent", source: `
+			(defun make-counter () (let ((count 0)) (lambda () (setq count (+ count 1)))))
+			(setq a (make-counter))
+			(setq b (make-counter))
+			(funcall a) (funcall a)
+			(list (funcall a) (funcall b))`, want: "(3 1)"},
+		{name: "parameters shadow globals", source: "(setq x 1) (defun f (x) x) (list (f 2) x)", want: "(2 1)"},
+		{name: "redefinition", source: "(defun f () 1) (defun f () 2) (f)", want: "2"},
+
+		{name: "too many arguments", source: "(defun f (a) a) (f 1 2)", want: "f expects 1 argument", wantError: "arity-mismatch"},
+		{name: "too few arguments to a lambda", source: "((lambda (a b) a) 1)", wantError: "arity-mismatch"},
+		{name: "funcall without a function", source: "(funcall)", want: "funcall expects a function", wantError: "arity-mismatch"},
+		{name: "funcall of a number", source: "(funcall 1 2)", want: "1 (int) is not a function", wantError: "type-error"},
+		{name: "funcall of a macro", source: "(defmacro m () 1) (funcall #'m)", want: "m is a macro, not a function", wantError: "type-error"},
+		{name: "apply without a list", source: "(apply #'+ 1 2)", want: "apply expects its last argument to be a list", wantError: "type-error"},
+		{name: "apply without arguments", source: "(apply #'+)", wantError: "arity-mismatch"},
+		{name: "function of an undefined name", source: "#'missing", want: "the function missing is undefined", wantError: "undefined-function"},
+		{name: "function of a number", source: "(function 1)", want: "function expects a function name", wantError: "invalid-form"},
+		{name: "defun without a body", source: "(defun f)", wantError: "invalid-form"},
+		{name: "lambda with a bad parameter list", source: "(lambda x x)", wantError: "invalid-form"},
+	})
+}
+
+func TestTailCalls(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "if", source: "(defun f (n) (if (= n 0) :done (f (- n 1)))) (f 20000)", want: ":done"},
+		{name: "cond", source: "(defun f (n) (cond ((= n 0) :done) (T (f (- n 1))))) (f 20000)", want: ":done"},
+		{name: "let and progn", source: "(defun f (n) (let ((m (- n 1))) (progn (if (< m 0) :done (f m))))) (f 20000)", want: ":done"},
+		{name: "when and unless", source: "(defun f (n) (unless (= n 0) (when T (f (- n 1))))) (f 20000)", want: "NIL"},
+		{name: "and and or", source: "(defun f (n) (or (= n 0) (and T (f (- n 1))))) (f 20000)", want: "T"},
+		{name: "case", source: "(defun f (n) (case n (0 :done) (T (f (- n 1))))) (f 20000)", want: ":done"},
+		{name: "an accumulator", source: "(defun sum (n acc) (if (= n 0) acc (sum (- n 1) (+ acc n)))) (sum 20000 0)", want: "200010000"},
+		{name: "mutual recursion", source: `
+			(defun my-even (n) (if (= n 0) T (my-odd (- n 1))))
+			(defun my-odd (n) (if (= n 0) NIL (my-even (- n 1))))
+			(my-even 20001)`, want: "NIL"},
+		{name: "a macro in tail position", source: "(defmacro again (n) `(f (- ,n 1))) (defun f (n) (if (= n 0) :done (again n))) (f 20000)", want: ":done"},
+		{name: "a lambda", source: "(setq f (lambda (n) (if (= n 0) :done (funcall f (- n 1))))) (funcall f 500)", want: ":done"},
+
+		{name: "a call that is not in tail position", source: "(defun f (n) (if (= n 0) 0 (+ 1 (f (- n 1))))) (f 100000)", wantError: "call-depth-exceeded"},
+		{name: "an error in a tail call", source: "(defun f (n) (if (= n 0) (car n) (f (- n 1)))) (f 1000)", want: "car expects a list but got 0", wantError: "type-error"},
+		{name: "arity of a tail call", source: "(defun f (n) (f n n)) (f 1)", want: "f expects 1 argument", wantError: "arity-mismatch"},
+	})
+}
@@ -0,0 +1,411 @@
+package lisp
+
+import (
+	"strings"
+)
+
+// Generic functions are defined with defgeneric and defmethod. A call runs
+// the methods whose specializers match the types of the required
+// arguments: the :before methods, most specific first, then the most
+// specific primary method, which may call the next one with
+// call-next-method, then the :after methods, least specific first.
+
+type genericFunction struct {
+	name       string
+	parameters lambdaList
+	methods    []method
+}
+
+type method struct {
+	// qualifier is "" for primary methods, ":before" or ":after"
+	qualifier    string
+	specializers []string
+	function     FunctionDeclaration
+}
+
+// The next methods of a running method are bound to variables whose names
+// cannot be read, so that only call-next-method and next-method-p see them.
+const (
+	callNextMethodVariable = "(call-next-method)"
+	nextMethodPVariable    = "(next-method-p)"
+)
+
+// typeSupertypes maps the types returned by type-of to the types they
+// belong to, closest first, besides t. Int is accepted as a synonym of
+// integer.
+var typeSupertypes = map[string][]string{
+	"integer": {"int", "rational", "real", "number"},
+	"ratio":   {"rational", "real", "number"},
+	"float":   {"real", "number"},
+	"string":  {"sequence"},
+	"vector":  {"sequence"},
+	"cons":    {"list", "sequence"},
+	"null":    {"list", "sequence", "boolean"},
+	"keyword": {"symbol"},
+}
+
+// isTypeName reports whether a method may be specialized on a type: one
+// returned by type-of or one of their supertypes, or a structure or
+// condition type of the interpreter of the context.
+func isTypeName(name string, context EvaluationContext) bool {
+	switch name {
+	case "t", "boolean", "function", "macro", "hash-table", "structure-object":
+		return true
+	}
+	for typeName, supertypes := range typeSupertypes {
+		if typeName == name {
+			return true
+		}
+		for _, supertype := range supertypes {
+			if supertype == name {
+				return true
+			}
+		}
+	}
+
+	global := context.Global()
+	_, isStructure := global.structures[name]
+	return isStructure || global.conditions.isConditionType(name)
+}
+
+// typePrecedence returns the types a value belongs to, most specific first.
+// The condition types are the ones of the interpreter of the context.
+func typePrecedence(expression Expression, context EvaluationContext) []string {
+	name := typeName(expression)
+
+	result := []string{name}
+	switch expression.(type) {
+	case Condition:
+		result = context.Global().conditions.conditionSupertypes(name)
+	case *Structure:
+		result = append(result, "structure-object")
+	default:
+		result = append(result, typeSupertypes[name]...)
+	}
+	return append(result, "t")
+}
+
+func precedenceIndex(precedence []string, specializer string) int {
+	for i, name := range precedence {
+		if name == specializer {
+			return i
+		}
+	}
+	return -1
+}
+
+// applicableMethods returns the methods applicable to the arguments, most
+// specific first: methods are ordered by the specificity of their first
+// specializer, then of the second one, and so on.
+func (g *genericFunction) applicableMethods(arguments []Expression, context EvaluationContext) []method {
+	precedences := [][]string{}
+	for _, argument := range arguments[:len(g.parameters.required)] {
+		precedences = append(precedences, typePrecedence(argument, context))
+	}
+
+	applicable := []method{}
+	ranks := [][]int{}
+	for _, m := range g.methods {
+		rank := []int{}
+		for i, specializer := range m.specializers {
+			index := precedenceIndex(precedences[i], specializer)
+			if index < 0 {
+				break
+			}
+			rank = append(rank, index)
+		}
+		if len(rank) < len(m.specializers) {
+			continue
+		}
+
+		position := len(applicable)
+		for position > 0 && lessRank(rank, ranks[position-1]) {
+			position--
+		}
+		applicable = append(applicable[:position], append([]method{m}, applicable[position:]...)...)
+		ranks = append(ranks[:position], append([][]int{rank}, ranks[position:]...)...)
+	}
+	return applicable
+}
+
+func lessRank(a []int, b []int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// call runs the methods applicable to the arguments of a call.
+func (g *genericFunction) call(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if failure := g.parameters.checkArity(g.name, len(arguments), span); failure != nil {
+		return failure
+	}
+
+	befores, primaries, afters := []method{}, []method{}, []method{}
+	for _, m := range g.applicableMethods(arguments, context) {
+		switch m.qualifier {
+		case ":before":
+			befores = append(befores, m)
+		case ":after":
+			afters = append([]method{m}, afters...)
+		default:
+			primaries = append(primaries, m)
+		}
+	}
+
+	if len(primaries) == 0 {
+		described := []string{}
+		for _, argument := range arguments {
+			described = append(described, describe(argument))
+		}
+		return newEvaluationError(NoApplicableMethodError, span, "the generic function %s has no method applicable to %s", g.name, strings.Join(described, ", "))
+	}
+
+	for _, before := range befores {
+		if result := callMethod(before, nil, arguments, span); !result.IsSuccessful() {
+			return result
+		}
+	}
+
+	result := callMethod(primaries[0], primaries[1:], arguments, span)
+	if !result.IsSuccessful() {
+		return result
+	}
+
+	for _, after := range afters {
+		if afterResult := callMethod(after, nil, arguments, span); !afterResult.IsSuccessful() {
+			return afterResult
+		}
+	}
+
+	return result
+}
+
+// callMethod runs a method, with call-next-method calling the first of the
+// next methods with the same arguments, unless new ones are given.
+func callMethod(m method, next []method, arguments []Expression, span Span) EvaluationResult {
+	function := m.function
+	function.context = NewChildContext(function.context)
+
+	function.context.DefineVariable(callNextMethodVariable, Builtin{
+		Name: "call-next-method",
+		function: func(nextArguments []Expression) EvaluationResult {
+			if len(next) == 0 {
+				return newEvaluationError(NoApplicableMethodError, Span{}, "%s has no next method to call", function.functionName)
+			}
+			if len(nextArguments) == 0 {
+				nextArguments = arguments
+			}
+			return callMethod(next[0], next[1:], nextArguments, span)
+		},
+	})
+	function.context.DefineVariable(nextMethodPVariable, Boolean{Value: len(next) > 0})
+
+	return callFunctionDeclaration(function, arguments, span)
+}
+
+// callNextMethodFunction implements (call-next-method [argument...]).
+func callNextMethodFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	next, ok := context.LookupVariable(callNextMethodVariable)
+	if !ok {
+		return newEvaluationError(InvalidFormError, span, "call-next-method is only allowed inside a method")
+	}
+
+	values := []Expression{}
+	for _, argument := range arguments {
+		evaluationResult := argument.Evaluate(context)
+		if !evaluationResult.IsSuccessful() {
+			return evaluationResult
+		}
+		values = append(values, evaluationResult.(SuccessfulEvaluationResult).Expression)
+	}
+
+	return applyFunction(next, values, span)
+}
+
+// nextMethodPFunction implements (next-method-p).
+func nextMethodPFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) != 0 {
+		return newEvaluationError(ArityMismatchError, span, "next-method-p expects 0 arguments but got %d", len(arguments))
+	}
+
+	value, ok := context.LookupVariable(nextMethodPVariable)
+	if !ok {
+		return newEvaluationError(InvalidFormError, span, "next-method-p is only allowed inside a method")
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: value,
+	}
+}
+
+// lookupGeneric returns the generic function of a name, if any, and fails
+// when the name is bound to a function which is not generic.
+func lookupGeneric(formName string, name Symbol, context EvaluationContext) (*genericFunction, EvaluationResult) {
+	if failure := checkDefinable(formName, name.Name(), name.GetSpan(), context); failure != nil {
+		return nil, failure
+	}
+
+	function, ok := context.LookupFunction(name.Name())
+	if !ok {
+		return nil, nil
+	}
+	if function.generic == nil {
+		return nil, newEvaluationError(InvalidFormError, name.GetSpan(), "%s cannot define %s, which is already a function but not a generic one", formName, name.Name())
+	}
+	return function.generic, nil
+}
+
+// defineGeneric builds the function which calls a generic function.
+func defineGeneric(generic *genericFunction, documentation string, span Span, context EvaluationContext) FunctionDeclaration {
+	function := FunctionDeclaration{
+		functionName:          generic.name,
+		functionDocumentation: documentation,
+		parameters:            generic.parameters,
+		context:               context.Global(),
+		generic:               generic,
+		identity:              new(int),
+		Span:                  span,
+	}
+	context.DefineFunction(generic.name, function)
+	return function
+}
+
+// defgenericFunction implements (defgeneric name lambda-list [option...]),
+// where the only option supported is (:documentation string). Redefining
+// a generic function keeps its methods.
+func defgenericFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) < 2 || arguments[0].GetType() != "symbol" {
+		return newEvaluationError(InvalidFormError, span, "defgeneric expects a name and a lambda list")
+	}
+
+	name := arguments[0].(Symbol)
+	parameters, failure := parseLambdaList(name.Name(), arguments[1])
+	if failure != nil {
+		return failure
+	}
+
+	documentation := ""
+	for _, option := range arguments[2:] {
+		elements, _ := listToSlice(option)
+		if len(elements) != 2 || !isFormSymbol(elements[0], ":documentation") || elements[1].GetType() != "string" {
+			return newEvaluationError(InvalidFormError, option.GetSpan(), "defgeneric does not support the option %s", option.Print())
+		}
+		documentation = elements[1].(String).Value
+	}
+
+	generic, failure := lookupGeneric("defgeneric", name, context)
+	if failure != nil {
+		return failure
+	}
+	if generic == nil {
+		generic = &genericFunction{name: name.Name()}
+	}
+	for _, m := range generic.methods {
+		if len(m.specializers) != len(parameters.required) {
+			return newEvaluationError(InvalidFormError, arguments[1].GetSpan(), "defgeneric cannot change the number of required parameters of %s, which has methods", name.Name())
+		}
+	}
+	generic.parameters = parameters
+
+	return SuccessfulEvaluationResult{
+		Expression: defineGeneric(generic, documentation, span, context),
+	}
+}
+
+// defmethodFunction implements (defmethod name [qualifier] lambda-list
+// body...), where the required parameters may be written (variable type).
+// It defines the generic function when it does not exist yet.
+func defmethodFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) < 2 || arguments[0].GetType() != "symbol" {
+		return newEvaluationError(InvalidFormError, span, "defmethod expects a name and a lambda list")
+	}
+
+	name := arguments[0].(Symbol)
+	rest := arguments[1:]
+
+	qualifier := ""
+	if isKeywordSymbol(rest[0]) {
+		qualifier = rest[0].(Symbol).Name()
+		if qualifier != ":before" && qualifier != ":after" {
+			return newEvaluationError(InvalidFormError, rest[0].GetSpan(), "defmethod does not support the qualifier %s, only :before and :after", qualifier)
+		}
+		rest = rest[1:]
+		if len(rest) == 0 {
+			return newEvaluationError(InvalidFormError, span, "defmethod expects a lambda list after %s", qualifier)
+		}
+	}
+
+	parameters, ok := listToSlice(rest[0])
+	if !ok {
+		return newEvaluationError(InvalidFormError, rest[0].GetSpan(), "defmethod expects a lambda list but got %s", describe(rest[0]))
+	}
+
+	// the required parameters come before the first lambda list keyword
+	specializers := []string{}
+	plainParameters := append([]Expression{}, parameters...)
+	for i, parameter := range parameters {
+		if symbol, ok := parameter.(Symbol); ok {
+			if strings.HasPrefix(symbol.Name(), "&") {
+				break
+			}
+			specializers = append(specializers, "t")
+			continue
+		}
+
+		elements, _ := listToSlice(parameter)
+		if len(elements) != 2 || elements[0].GetType() != "symbol" {
+			return newEvaluationError(InvalidFormError, parameter.GetSpan(), "defmethod expects a parameter or (parameter type) but got %s", parameter.Print())
+		}
+		specializer := ""
+		switch value := elements[1].(type) {
+		case Symbol:
+			specializer = value.Name()
+		case Boolean:
+			if value.Value {
+				specializer = "t"
+			}
+		}
+		if specializer == "" {
+			return newEvaluationError(InvalidFormError, elements[1].GetSpan(), "defmethod expects a type name but got %s", describe(elements[1]))
+		}
+		if !isTypeName(specializer, context) {
+			return newEvaluationError(InvalidFormError, elements[1].GetSpan(), "defmethod cannot specialize on %s, which is not a type", specializer)
+		}
+		specializers = append(specializers, specializer)
+		plainParameters[i] = elements[0]
+	}
+
+	function, failure := makeFunction(name.Name(), append([]Expression{makeListFromSlice(plainParameters, rest[0].GetSpan())}, rest[1:]...), span, context)
+	if failure != nil {
+		return failure
+	}
+
+	generic, failure := lookupGeneric("defmethod", name, context)
+	if failure != nil {
+		return failure
+	}
+	if generic == nil {
+		// the generic function accepts any arguments after the required ones
+		generic = &genericFunction{name: name.Name(), parameters: lambdaList{required: function.parameters.required, rest: "arguments"}}
+		defineGeneric(generic, "", span, context)
+	}
+	if len(specializers) != len(generic.parameters.required) {
+		return newEvaluationError(InvalidFormError, rest[0].GetSpan(), "defmethod expects %d required parameters for %s but got %d", len(generic.parameters.required), name.Name(), len(specializers))
+	}
+
+	added := method{qualifier: qualifier, specializers: specializers, function: function}
+	for i, m := range generic.methods {
+		if m.qualifier == qualifier && strings.Join(m.specializers, " ") == strings.Join(specializers, " ") {
+			generic.methods[i] = added
+			return SuccessfulEvaluationResult{Expression: function}
+		}
+	}
+	generic.methods = append(generic.methods, added)
+
+	return SuccessfulEvaluationResult{
+		Expression: function,
+	}
+}
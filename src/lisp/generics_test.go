@@ -0,0 +1,95 @@
+package lisp
+
+import (
+	"testing"
+)
+
+func TestGenerics(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "dispatch on the type", source: `
+			(defgeneric describe-it (x))
+			(defmethod describe-it ((x integer)) :integer)
+			(defmethod describe-it ((x string)) :string)
+			(defmethod describe-it (x) :other)
+			(list (describe-it 1) (describe-it "s") (describe-it 'a))`, want: "(:integer :string :other)"},
+		{name: "the most specific method", source: `
+			(defmethod kind ((x number)) :number)
+			(defmethod kind ((x rational)) :rational)
+			(list (kind 1) (kind 1/2) (kind 1.5))`, want: "(:rational :rational :number)"},
+		{name: "dispatch on several arguments", source: `
+			(defmethod combine ((a integer) (b integer)) :ints)
+			(defmethod combine ((a integer) b) :int-any)
+			(defmethod combine (a (b integer)) :any-int)
+			(list (combine 1 2) (combine 1 "s") (combine "s" 1))`, want: "(:ints :int-any :any-int)"},
+		{name: "the first argument comes first", source: `
+			(defmethod pick ((a integer) b) :first)
+			(defmethod pick (a (b integer)) :second)
+			(pick 1 2)`, want: ":first"},
+		{name: "lists, NIL and sequences", source: `
+			(defmethod shape ((x cons)) :cons)
+			(defmethod shape ((x null)) :null)
+			(defmethod shape ((x sequence)) :sequence)
+			(list (shape '(1)) (shape NIL) (shape "s") (shape (vector)))`, want: "(:cons :null :sequence :sequence)"},
+		{name: "structures", source: `
+			(defstruct circle r)
+			(defstruct square side)
+			(defmethod area ((s circle)) (* 3 (circle-r s) (circle-r s)))
+			(defmethod area ((s square)) (* (square-side s) (square-side s)))
+			(list (area (make-circle :r 2)) (area (make-square :side 3)))`, want: "(12 9)"},
+		{name: "conditions", source: `
+			(define-condition my-error (type-error))
+			(defmethod handle ((c type-error)) :type)
+			(defmethod handle ((c error)) :error)
+			(list (handler-case (error 'my-error) (error (c) (handle c))) (handler-case (/ 1 0) (error (c) (handle c))))`, want: "(:type :error)"},
+		{name: "call-next-method", source: `
+			(defmethod greet ((x number)) (list :number x))
+			(defmethod greet ((x integer)) (cons :integer (call-next-method)))
+			(greet 5)`, want: "(:integer :number 5)"},
+		{name: "call-next-method with arguments", source: `
+			(defmethod twice ((x number)) (* 2 x))
+			(defmethod twice ((x integer)) (call-next-method (+ x 1)))
+			(twice 5)`, want: "12"},
+		{name: "next-method-p", source: `
+			(defmethod probe ((x number)) (next-method-p))
+			(defmethod probe ((x integer)) (list (next-method-p) (call-next-method)))
+			(probe 1)`, want: "(T NIL)"},
+		{name: "before and after methods", source: `
+			(setq log NIL)
+			(defmethod act ((x number)) (push :primary-number log))
+			(defmethod act ((x integer)) (push :primary-integer log) (call-next-method))
+			(defmethod act :before ((x number)) (push :before-number log))
+			(defmethod act :before ((x integer)) (push :before-integer log))
+			(defmethod act :after ((x number)) (push :after-number log))
+			(defmethod act :after ((x integer)) (push :after-integer log))
+			(act 1)
+			(reverse log)`, want: "(:before-integer :before-number :primary-integer :primary-number :after-number :after-integer)"},
+		{name: "the value of the primary method", source: `
+			(defmethod value ((x integer)) :primary)
+			(defmethod value :after ((x integer)) :after)
+			(value 1)`, want: ":primary"},
+		{name: "redefining a method", source: "(defmethod f ((x integer)) 1) (defmethod f ((x integer)) 2) (f 0)", want: "2"},
+		{name: "defgeneric keeps the methods", source: "(defmethod f ((x integer)) 1) (defgeneric f (x) (:documentation \"doc\")) (f 0)", want: "1"},
+		{name: "optional parameters of methods", source: "(defmethod f ((x integer) &optional (y 10)) (+ x y)) (list (f 1) (f 1 2))", want: "(11 3)"},
+		{name: "generic functions are functions", source: "(defmethod f ((x integer)) (* x x)) (mapcar #'f '(1 2 3))", want: "(1 4 9)"},
+
+		{name: "no applicable method", source: "(defmethod f ((x integer)) x) (f \"s\")", want: "the generic function f has no method applicable to \"s\" (string)", wantError: "no-applicable-method"},
+		{name: "no method at all", source: "(defgeneric f (x)) (f 1)", want: "has no method applicable to 1 (int)", wantError: "no-applicable-method"},
+		{name: "no next method", source: "(defmethod f ((x integer)) (call-next-method)) (f 1)", want: "f has no next method to call", wantError: "no-applicable-method"},
+		{name: "call-next-method outside a method", source: "(defun f () (call-next-method)) (f)", want: "call-next-method is only allowed inside a method", wantError: "invalid-form"},
+		{name: "next-method-p outside a method", source: "(next-method-p)", want: "next-method-p is only allowed inside a method", wantError: "invalid-form"},
+		{name: "next-method-p arity", source: "(defmethod f (x) (next-method-p 1)) (f 1)", want: "next-method-p expects 0 arguments but got 1", wantError: "arity-mismatch"},
+		{name: "a method for a plain function", source: "(defun f (x) x) (defmethod f ((x integer)) x)", want: "defmethod cannot define f, which is already a function but not a generic one", wantError: "invalid-form"},
+		{name: "defgeneric of a plain function", source: "(defun f (x) x) (defgeneric f (x))", want: "defgeneric cannot define f", wantError: "invalid-form"},
+		{name: "an unknown qualifier", source: "(defmethod f :around ((x integer)) x)", want: "defmethod does not support the qualifier :around, only :before and :after", wantError: "invalid-form"},
+		{name: "a qualifier without a lambda list", source: "(defmethod f :before)", want: "defmethod expects a lambda list after :before", wantError: "invalid-form"},
+		{name: "a bad specialized parameter", source: "(defmethod f ((x integer float)) x)", want: "defmethod expects a parameter or (parameter type)", wantError: "invalid-form"},
+		{name: "a number as a type", source: "(defmethod f ((x 1)) x)", want: "defmethod expects a type name but got 1", wantError: "invalid-form"},
+		{name: "an unknown type", source: "(defmethod f ((x integr)) x)", want: "defmethod cannot specialize on integr, which is not a type", wantError: "invalid-form"},
+		{name: "a structure defined later", source: "(defmethod f ((x point)) x) (defstruct point x)", want: "defmethod cannot specialize on point, which is not a type", wantError: "invalid-form"},
+		{name: "a different number of parameters", source: "(defmethod f ((x integer)) x) (defmethod f (x y) x)", want: "defmethod expects 1 required parameters for f but got 2", wantError: "invalid-form"},
+		{name: "changing the parameters of defgeneric", source: "(defmethod f ((x integer)) x) (defgeneric f (x y))", want: "defgeneric cannot change the number of required parameters of f", wantError: "invalid-form"},
+		{name: "an unknown defgeneric option", source: "(defgeneric f (x) (:method-combination +))", want: "defgeneric does not support the option (:method-combination +)", wantError: "invalid-form"},
+		{name: "defgeneric without a lambda list", source: "(defgeneric f)", want: "defgeneric expects a name and a lambda list", wantError: "invalid-form"},
+		{name: "defmethod without a name", source: "(defmethod)", want: "defmethod expects a name and a lambda list", wantError: "invalid-form"},
+	})
+}
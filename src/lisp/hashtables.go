@@ -0,0 +1,266 @@
+package lisp
+
+import (
+	"fmt"
+)
+
+// HashTable maps keys to values, comparing the keys with eq, eql or equal.
+// Its entries are kept in insertion order, which maphash follows.
+type HashTable struct {
+	BaseTypeExpression
+	test string
+	// buckets groups the entries by hashKey, and the test tells apart the
+	// keys of a bucket.
+	buckets map[string][]*hashEntry
+	// entries holds the entries in insertion order, including removed ones
+	// until they are compacted.
+	entries []*hashEntry
+	count   int
+	Span    Span
+}
+
+type hashEntry struct {
+	key     Expression
+	value   Expression
+	removed bool
+}
+
+var hashTableTests = map[string]func(a Expression, b Expression) bool{
+	"eq":    isEq,
+	"eql":   isEql,
+	"equal": isEqual,
+}
+
+func (h *HashTable) GetType() string {
+	return "hash-table"
+}
+
+func (h *HashTable) GetSpan() Span {
+	return h.Span
+}
+
+func (h *HashTable) Print() string {
+	return fmt.Sprintf("#<hash-table :test %s :count %d>", h.test, h.count)
+}
+
+func (h *HashTable) Evaluate(context EvaluationContext) EvaluationResult {
+	return SuccessfulEvaluationResult{
+		Expression: h,
+	}
+}
+
+// hashKey returns a key equal for any two values the test of the table
+// considers the same. Lists are only hashed by their car under equal, to
+// keep hashing cheap, and not even by it when it is a list, which may be
+// circular.
+func (h *HashTable) hashKey(expression Expression) string {
+	switch value := expression.(type) {
+	case *List:
+		if h.test == "equal" {
+			if value.left.GetType() == "list" {
+				return "list list"
+			}
+			return "list " + h.hashKey(value.left)
+		}
+		return fmt.Sprintf("list %p", value)
+	case *Vector, *HashTable, *Structure:
+		return fmt.Sprintf("%s %p", value.GetType(), value)
+	case Symbol:
+		return "symbol " + value.Name()
+	}
+	if level, ok := numberLevel(expression); ok {
+		return fmt.Sprintf("number %d %s", level, expression.Print())
+	}
+	return expression.GetType() + " " + expression.Print()
+}
+
+func (h *HashTable) lookup(key Expression) *hashEntry {
+	test := hashTableTests[h.test]
+	for _, entry := range h.buckets[h.hashKey(key)] {
+		if test(entry.key, key) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (h *HashTable) put(key Expression, value Expression) {
+	if entry := h.lookup(key); entry != nil {
+		entry.value = value
+		return
+	}
+
+	entry := &hashEntry{key: key, value: value}
+	hashKey := h.hashKey(key)
+	h.buckets[hashKey] = append(h.buckets[hashKey], entry)
+	h.entries = append(h.entries, entry)
+	h.count++
+}
+
+func (h *HashTable) remove(key Expression) bool {
+	entry := h.lookup(key)
+	if entry == nil {
+		return false
+	}
+
+	hashKey := h.hashKey(key)
+	bucket := []*hashEntry{}
+	for _, other := range h.buckets[hashKey] {
+		if other != entry {
+			bucket = append(bucket, other)
+		}
+	}
+	if len(bucket) == 0 {
+		delete(h.buckets, hashKey)
+	} else {
+		h.buckets[hashKey] = bucket
+	}
+
+	entry.removed = true
+	h.count--
+
+	if len(h.entries) > 2*h.count+16 {
+		entries := []*hashEntry{}
+		for _, other := range h.entries {
+			if !other.removed {
+				entries = append(entries, other)
+			}
+		}
+		h.entries = entries
+	}
+	return true
+}
+
+func expectHashTable(functionName string, argument Expression) (*HashTable, EvaluationResult) {
+	table, ok := argument.(*HashTable)
+	if !ok {
+		return nil, newEvaluationError(TypeMismatchError, Span{}, "%s expects a hash table but got %s", functionName, describe(argument))
+	}
+	return table, nil
+}
+
+// makeHashTable implements (make-hash-table &key test), where the test is
+// eq, eql or equal, given as a symbol or as a function.
+func makeHashTable(arguments []Expression) EvaluationResult {
+	_, keywords, failure := keywordArguments("make-hash-table", arguments, 0, ":test")
+	if failure != nil {
+		return failure
+	}
+
+	test := "eql"
+	if value, ok := keywords[":test"]; ok {
+		switch name := value.(type) {
+		case Symbol:
+			test = name.Name()
+		case Builtin:
+			test = name.Name
+		default:
+			test = ""
+		}
+		if hashTableTests[test] == nil {
+			return newEvaluationError(TypeMismatchError, Span{}, "make-hash-table expects the test eq, eql or equal but got %s", describe(value))
+		}
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: &HashTable{test: test, buckets: map[string][]*hashEntry{}},
+	}
+}
+
+// gethash implements (gethash key table [default]), which returns the value
+// of key, or default, NIL if not given, when the key is absent.
+func gethash(arguments []Expression) EvaluationResult {
+	if failure := checkArityRange("gethash", arguments, 2, 3); failure != nil {
+		return failure
+	}
+
+	table, failure := expectHashTable("gethash", arguments[1])
+	if failure != nil {
+		return failure
+	}
+
+	if entry := table.lookup(arguments[0]); entry != nil {
+		return SuccessfulEvaluationResult{Expression: entry.value}
+	}
+	if len(arguments) == 3 {
+		return SuccessfulEvaluationResult{Expression: arguments[2]}
+	}
+	return SuccessfulEvaluationResult{
+		Expression: Boolean{Value: false},
+	}
+}
+
+func gethashSetter(arguments []Expression, value Expression, global EvaluationContext) EvaluationResult {
+	if failure := checkArityRange("gethash", arguments, 2, 3); failure != nil {
+		return failure
+	}
+
+	table, failure := expectHashTable("gethash", arguments[1])
+	if failure != nil {
+		return failure
+	}
+
+	table.put(arguments[0], value)
+
+	return SuccessfulEvaluationResult{
+		Expression: value,
+	}
+}
+
+// remhash implements (remhash key table), which returns whether the key was
+// present.
+func remhash(arguments []Expression) EvaluationResult {
+	if failure := checkArity("remhash", arguments, 2); failure != nil {
+		return failure
+	}
+
+	table, failure := expectHashTable("remhash", arguments[1])
+	if failure != nil {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: Boolean{Value: table.remove(arguments[0])},
+	}
+}
+
+// maphash implements (maphash function table), which calls function with
+// each key and value. The function may assign or remove the current entry.
+func maphash(arguments []Expression) EvaluationResult {
+	if failure := checkArity("maphash", arguments, 2); failure != nil {
+		return failure
+	}
+
+	table, failure := expectHashTable("maphash", arguments[1])
+	if failure != nil {
+		return failure
+	}
+
+	for _, entry := range append([]*hashEntry{}, table.entries...) {
+		if entry.removed {
+			continue
+		}
+		if _, failure := callFunction(arguments[0], entry.key, entry.value); failure != nil {
+			return failure
+		}
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: Boolean{Value: false},
+	}
+}
+
+func hashTableCount(arguments []Expression) EvaluationResult {
+	if failure := checkArity("hash-table-count", arguments, 1); failure != nil {
+		return failure
+	}
+
+	table, failure := expectHashTable("hash-table-count", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: Int{Value: table.count},
+	}
+}
@@ -0,0 +1,30 @@
+package lisp
+
+import (
+	"testing"
+)
+
+func TestHashTables(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "gethash", source: "(setq h (make-hash-table)) (setf (gethash 'a h) 1) (list (gethash 'a h) (gethash 'b h) (gethash 'b h :none))", want: "(1 NIL :none)"},
+		{name: "replacing a value", source: "(setq h (make-hash-table)) (setf (gethash 1 h) :a) (setf (gethash 1 h) :b) (list (gethash 1 h) (hash-table-count h))", want: "(:b 1)"},
+		{name: "remhash", source: "(setq h (make-hash-table)) (setf (gethash 1 h) :a) (list (remhash 1 h) (remhash 1 h) (hash-table-count h))", want: "(T NIL 0)"},
+		{name: "eql keys", source: "(setq h (make-hash-table)) (setf (gethash 1 h) :int) (list (gethash 1 h) (gethash 1.0 h))", want: "(:int NIL)"},
+		{name: "eql does not compare strings", source: "(setq h (make-hash-table)) (setf (gethash \"k\" h) 1) (gethash \"k\" h)", want: "NIL"},
+		{name: "equal compares strings", source: "(setq h (make-hash-table :test 'equal)) (setf (gethash \"k\" h) 1) (gethash \"k\" h)", want: "1"},
+		{name: "equal compares lists", source: "(setq h (make-hash-table :test #'equal)) (setf (gethash '(1 2) h) :a) (setf (gethash '(1 3) h) :b) (list (gethash (list 1 2) h) (gethash (list 1 3) h))", want: "(:a :b)"},
+		{name: "eq compares lists by identity", source: "(setq h (make-hash-table :test 'eq)) (setq k (list 1)) (setf (gethash k h) :a) (list (gethash k h) (gethash (list 1) h))", want: "(:a NIL)"},
+		{name: "maphash follows insertion order", source: "(setq h (make-hash-table)) (setf (gethash :b h) 2 (gethash :a h) 1) (setq out NIL) (maphash (lambda (k v) (push (list k v) out)) h) (reverse out)", want: "((:b 2) (:a 1))"},
+		{name: "maphash may remove entries", source: "(setq h (make-hash-table)) (dotimes (i 4) (setf (gethash i h) i)) (maphash (lambda (k v) (remhash k h)) h) (hash-table-count h)", want: "0"},
+		{name: "many entries", source: "(setq h (make-hash-table)) (dotimes (i 1000) (setf (gethash i h) (* i i))) (dotimes (i 990) (remhash i h)) (list (hash-table-count h) (gethash 999 h))", want: "(10 998001)"},
+		{name: "printing", source: "(setq h (make-hash-table :test 'equal)) (setf (gethash 1 h) 1) h", want: "#<hash-table :test equal :count 1>"},
+
+		{name: "an unknown test", source: "(make-hash-table :test 'string=)", want: "make-hash-table expects the test eq, eql or equal but got string=", wantError: "type-error"},
+		{name: "an unknown keyword", source: "(make-hash-table :size 10)", wantError: "arity-mismatch"},
+		{name: "gethash of a list", source: "(gethash 1 '(1))", want: "gethash expects a hash table but got (1)", wantError: "type-error"},
+		{name: "gethash arity", source: "(gethash 1)", wantError: "arity-mismatch"},
+		{name: "remhash of a vector", source: "(remhash 1 (vector))", want: "remhash expects a hash table", wantError: "type-error"},
+		{name: "an error in maphash", source: "(setq h (make-hash-table)) (setf (gethash 1 h) 1) (maphash (lambda (k v) (car v)) h)", want: "car expects a list", wantError: "type-error"},
+		{name: "hash-table-count of NIL", source: "(hash-table-count NIL)", wantError: "type-error"},
+	})
+}
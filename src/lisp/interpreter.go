@@ -0,0 +1,94 @@
+package lisp
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+type InterpreterOptions struct {
+	// Variables are global variables defined when the interpreter is
+	// created, for instance to hand values over from embedding code.
+	Variables map[string]Expression
+	// SearchPath lists the directories in which load and require look
+	// for files. When nil, it is read from the GOLISP_PATH environment
+	// variable.
+	SearchPath []string
+	// DisableLoad turns load and require off, for interpreters running
+	// code that must not read files. LoadFile still works.
+	DisableLoad bool
+	// MaxCallDepth is the number of nested calls after which an
+	// evaluation fails. Zero means DefaultMaxCallDepth. Each call takes
+	// about 10KB of Go stack, which is limited to 1GB by default, so
+	// values much beyond 50000 may still crash the process.
+	MaxCallDepth int
+	// Timeout is the time after which a call to Eval, EvalExpr or
+	// LoadFile fails with a timeout error. Zero means no limit.
+	Timeout time.Duration
+}
+
+// Interpreter evaluates expressions against a global environment that is
+// kept between evaluations, so that variables set with setq and functions
+// defined with defun stay available. An Interpreter is not safe for
+// concurrent use.
+type Interpreter struct {
+	options InterpreterOptions
+	global  EvaluationContext
+}
+
+func NewInterpreter(options InterpreterOptions) *Interpreter {
+	interpreter := &Interpreter{
+		options: options,
+		global:  NewGlobalContext(),
+	}
+
+	if options.SearchPath != nil {
+		interpreter.global.loader.searchPath = options.SearchPath
+	}
+	interpreter.global.loader.disabled = options.DisableLoad
+	if options.MaxCallDepth > 0 {
+		interpreter.global.depth.maximum = options.MaxCallDepth
+	}
+	interpreter.global.timeout.limit = options.Timeout
+
+	for name, value := range options.Variables {
+		interpreter.global.DefineVariable(name, value)
+	}
+
+	return interpreter
+}
+
+// Eval reads and evaluates every expression of a source text in turn, and
+// returns the result of the last one. Reading stops at the first error.
+func (interpreter *Interpreter) Eval(source string) EvaluationResult {
+	defer interpreter.global.timeout.start()()
+	return evaluateSource(NewReader(source), interpreter.global)
+}
+
+// EvalExpr evaluates an already parsed expression in the global
+// environment.
+func (interpreter *Interpreter) EvalExpr(expression Expression) EvaluationResult {
+	defer interpreter.global.timeout.start()()
+	return evaluateTopLevel(expression, interpreter.global)
+}
+
+// LoadFile evaluates the expressions of a file, as load does, and returns
+// T or the first error. Errors are located in the file.
+func (interpreter *Interpreter) LoadFile(file string) EvaluationResult {
+	if absolute, err := filepath.Abs(file); err == nil {
+		file = absolute
+	}
+	defer interpreter.global.timeout.start()()
+	return interpreter.global.loader.load("load", file, Span{}, interpreter.global)
+}
+
+// SetDispatchMacro defines the dispatch macro #character, read by the
+// expressions given to Eval and the files loaded afterwards. It fails for
+// the dispatch macros of the reader itself, such as #( and #'.
+func (interpreter *Interpreter) SetDispatchMacro(character rune, macro ReaderMacro) error {
+	if !isDefinableDispatchCharacter(character) {
+		return fmt.Errorf("cannot define the dispatch macro #%c", character)
+	}
+	interpreter.global.readtable.dispatch[character] = macro
+	return nil
+}
@@ -0,0 +1,79 @@
+package lisp
+
+import (
+	"testing"
+)
+
+func TestInterpreterKeepsDefinitions(t *testing.T) {
+	interpreter := NewInterpreter(InterpreterOptions{})
+
+	steps := []evalTest{
+		{source: "(setq counter 1)", want: "1"},
+		{source: "(defun next () (setq counter (+ counter 1)))", want: "#next"},
+		{source: "(next)", want: "2"},
+		{source: "(next) (next)", want: "4"},
+		{source: "counter", want: "4"},
+		{source: "(undefined-function)", want: "undefined-function is undefined", wantError: "undefined-function"},
+		{source: "counter", want: "4"},
+	}
+	for _, step := range steps {
+		checkResult(t, step, interpreter.Eval(step.source))
+	}
+}
+
+func TestInterpretersAreIsolated(t *testing.T) {
+	first := NewInterpreter(InterpreterOptions{})
+	second := NewInterpreter(InterpreterOptions{})
+
+	first.Eval(`(setq x 1)
+		(defun f () :first)
+		(put 'sym 'color 'red)
+		(define-condition my-error (error))
+		(defpackage :mine)
+		(defstruct point x)`)
+
+	tests := []evalTest{
+		{name: "variables", source: "x", want: "the variable x is unbound", wantError: "unbound-variable"},
+		{name: "functions", source: "(f)", wantError: "undefined-function"},
+		{name: "property lists", source: "(symbol-plist 'sym)", want: "NIL"},
+		{name: "condition types", source: "(error 'my-error)", want: "expects a condition type", wantError: "type-error"},
+		{name: "packages", source: "(in-package :mine)", want: "the package mine does not exist", wantError: "invalid-form"},
+		{name: "structures", source: "#S(point :x 1)", want: "not a structure", wantError: "reader-error"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkResult(t, test, second.Eval(test.source))
+		})
+	}
+}
+
+func TestInterpreterOptions(t *testing.T) {
+	interpreter := NewInterpreter(InterpreterOptions{
+		Variables:    map[string]Expression{"answer": Int{Value: 42}},
+		MaxCallDepth: 50,
+	})
+
+	checkResult(t, evalTest{want: "43"}, interpreter.Eval("(+ answer 1)"))
+	checkResult(t, evalTest{want: "maximum call depth of 50", wantError: "call-depth-exceeded"},
+		interpreter.Eval("(defun f (n) (if (= n 0) 0 (+ 1 (f (- n 1))))) (f 60)"))
+	checkResult(t, evalTest{want: "40"}, interpreter.Eval("(f 40)"))
+}
+
+func TestEvalExpr(t *testing.T) {
+	interpreter := NewInterpreter(InterpreterOptions{})
+	interpreter.Eval("(defun double (x) (* 2 x))")
+
+	expression := makeListFromSlice([]Expression{Intern("double"), Int{Value: 21}}, Span{})
+	checkResult(t, evalTest{want: "42"}, interpreter.EvalExpr(expression))
+	checkResult(t, evalTest{want: "42"}, Evaluate(makeListFromSlice([]Expression{Intern("*"), Int{Value: 2}, Int{Value: 21}}, Span{})))
+	checkResult(t, evalTest{wantError: "undefined-function"}, Evaluate(expression))
+}
+
+func TestEvalReturnsTheLastValue(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "several expressions", source: "1 2 3", want: "3"},
+		{name: "no expression", source: "", want: "NIL"},
+		{name: "stops at the first error", source: "(setq a 1) (car 1) (setq a 2)", wantError: "type-error"},
+		{name: "stray return", source: "(return 1)", want: "only allowed inside a loop", wantError: "invalid-form"},
+	})
+}
@@ -0,0 +1,296 @@
+package lisp
+
+import (
+	"strings"
+)
+
+// lambdaList holds the parameters of a function, as written after defun,
+// lambda or defmacro:
+//
+//	(required... &optional optional... &rest rest &key key... &allow-other-keys)
+type lambdaList struct {
+	required []Symbol
+	optional []parameter
+	// rest, when not empty, is bound to the list of the arguments following
+	// the required and optional ones. &body is a synonym of &rest.
+	rest           string
+	keys           []parameter
+	hasKeys        bool
+	allowOtherKeys bool
+}
+
+// parameter is an optional or keyword parameter. Its default value is
+// evaluated when the argument is not supplied, and its supplied variable,
+// if any, is bound to whether it was.
+type parameter struct {
+	variable         Symbol
+	keyword          Symbol
+	defaultValue     Expression
+	suppliedVariable string
+}
+
+// parseParameter reads an optional or keyword parameter, written either as
+// a variable name or as (variable [default [supplied-variable]]). A keyword
+// parameter may also be written ((:keyword variable) ...).
+func parseParameter(functionName string, specification Expression, isKey bool) (parameter, EvaluationResult) {
+	result := parameter{defaultValue: Boolean{Value: false}}
+
+	elements := []Expression{specification}
+	if specification.GetType() == "list" {
+		elements, _ = listToSlice(specification)
+		if elements == nil || len(elements) == 0 || len(elements) > 3 {
+			return parameter{}, newEvaluationError(InvalidFormError, specification.GetSpan(), "the parameter %s of %s must be of the form (variable [default [supplied-variable]])", specification.Print(), functionName)
+		}
+	}
+
+	if isKey && elements[0].GetType() == "list" {
+		names, _ := listToSlice(elements[0])
+		if len(names) != 2 || !isKeywordSymbol(names[0]) || names[1].GetType() != "symbol" {
+			return parameter{}, newEvaluationError(InvalidFormError, elements[0].GetSpan(), "the parameter %s of %s must be of the form (:keyword variable)", elements[0].Print(), functionName)
+		}
+		result.keyword = names[0].(Symbol)
+		result.variable = names[1].(Symbol)
+	} else {
+		variable, ok := elements[0].(Symbol)
+		if !ok {
+			return parameter{}, newEvaluationError(InvalidFormError, elements[0].GetSpan(), "the parameter %s of %s is not a variable name", describe(elements[0]), functionName)
+		}
+		result.variable = variable
+		result.keyword = Intern(":" + unqualifiedName(variable.Name()))
+	}
+
+	if len(elements) >= 2 {
+		result.defaultValue = elements[1]
+	}
+
+	if len(elements) == 3 {
+		supplied, ok := elements[2].(Symbol)
+		if !ok {
+			return parameter{}, newEvaluationError(InvalidFormError, elements[2].GetSpan(), "the supplied variable %s of %s is not a variable name", describe(elements[2]), functionName)
+		}
+		result.suppliedVariable = supplied.Name()
+	}
+
+	return result, nil
+}
+
+func isKeywordSymbol(expression Expression) bool {
+	symbol, ok := expression.(Symbol)
+	return ok && symbol.IsKeyword()
+}
+
+// parseLambdaList reads the lambda list of a function definition.
+func parseLambdaList(functionName string, expression Expression) (lambdaList, EvaluationResult) {
+	result := lambdaList{}
+
+	parameters, ok := listToSlice(expression)
+	if !ok {
+		return lambdaList{}, newEvaluationError(InvalidFormError, expression.GetSpan(), "the parameters of %s must be a list of variable names but got %s", functionName, describe(expression))
+	}
+
+	// section is the last lambda list keyword read, in the order they must
+	// appear in
+	sections := []string{"", "&optional", "&rest", "&key", "&allow-other-keys"}
+	section := 0
+
+	for idx := 0; idx < len(parameters); idx++ {
+		specification := parameters[idx]
+
+		if symbol, ok := specification.(Symbol); ok && strings.HasPrefix(symbol.Name(), "&") {
+			name := symbol.Name()
+			if name == "&body" {
+				name = "&rest"
+			}
+
+			next := 0
+			for i, sectionName := range sections {
+				if sectionName == name {
+					next = i
+				}
+			}
+			if next == 0 {
+				return lambdaList{}, newEvaluationError(InvalidFormError, symbol.GetSpan(), "%s is not a lambda list keyword supported in the parameters of %s", symbol.Name(), functionName)
+			}
+			if next <= section {
+				return lambdaList{}, newEvaluationError(InvalidFormError, symbol.GetSpan(), "%s is misplaced in the parameters of %s", symbol.Name(), functionName)
+			}
+			section = next
+
+			switch name {
+			case "&rest":
+				if idx+1 >= len(parameters) || parameters[idx+1].GetType() != "symbol" || strings.HasPrefix(parameters[idx+1].(Symbol).Name(), "&") {
+					return lambdaList{}, newEvaluationError(InvalidFormError, symbol.GetSpan(), "%s must be followed by a variable name in the parameters of %s", symbol.Name(), functionName)
+				}
+				result.rest = parameters[idx+1].(Symbol).Name()
+				idx++
+			case "&key":
+				result.hasKeys = true
+			case "&allow-other-keys":
+				if !result.hasKeys {
+					return lambdaList{}, newEvaluationError(InvalidFormError, symbol.GetSpan(), "&allow-other-keys must follow &key in the parameters of %s", functionName)
+				}
+				result.allowOtherKeys = true
+			}
+			continue
+		}
+
+		switch sections[section] {
+		case "":
+			variable, ok := specification.(Symbol)
+			if !ok {
+				return lambdaList{}, newEvaluationError(InvalidFormError, specification.GetSpan(), "the parameter %s of %s is not a variable name", describe(specification), functionName)
+			}
+			result.required = append(result.required, variable)
+		case "&optional":
+			optional, failure := parseParameter(functionName, specification, false)
+			if failure != nil {
+				return lambdaList{}, failure
+			}
+			result.optional = append(result.optional, optional)
+		case "&key":
+			key, failure := parseParameter(functionName, specification, true)
+			if failure != nil {
+				return lambdaList{}, failure
+			}
+			result.keys = append(result.keys, key)
+		default:
+			return lambdaList{}, newEvaluationError(InvalidFormError, specification.GetSpan(), "the parameter %s of %s is misplaced after %s", specification.Print(), functionName, sections[section])
+		}
+	}
+
+	return result, nil
+}
+
+// checkConstants fails when a variable of the lambda list is a constant,
+// which cannot be rebound.
+func (ll lambdaList) checkConstants(functionName string, span Span, context EvaluationContext) EvaluationResult {
+	names := []string{ll.rest}
+	for _, variable := range ll.required {
+		names = append(names, variable.Name())
+	}
+	for _, p := range append(append([]parameter{}, ll.optional...), ll.keys...) {
+		names = append(names, p.variable.Name(), p.suppliedVariable)
+	}
+
+	for _, name := range names {
+		if name != "" && context.variableKind(name) == constantVariable {
+			return newEvaluationError(InvalidFormError, span, "%s cannot bind the constant %s", functionName, name)
+		}
+	}
+	return nil
+}
+
+// checkArity reports an error when a number of arguments does not suit the
+// lambda list.
+func (ll lambdaList) checkArity(functionName string, count int, span Span) EvaluationResult {
+	minimum := len(ll.required)
+	maximum := minimum + len(ll.optional)
+	unbounded := ll.rest != "" || ll.hasKeys
+
+	if count >= minimum && (unbounded || count <= maximum) {
+		return nil
+	}
+
+	switch {
+	case unbounded:
+		return newEvaluationError(ArityMismatchError, span, "%s expects at least %s but got %d", functionName, countArguments(minimum), count)
+	case minimum == maximum:
+		return newEvaluationError(ArityMismatchError, span, "%s expects %s but got %d", functionName, countArguments(minimum), count)
+	}
+	return newEvaluationError(ArityMismatchError, span, "%s expects between %d and %d arguments but got %d", functionName, minimum, maximum, count)
+}
+
+// bind binds the parameters to the arguments of a call in the frame of the
+// function, evaluating the defaults of the missing arguments there.
+func (ll lambdaList) bind(functionName string, arguments []Expression, span Span, bindings *binder) EvaluationResult {
+	if failure := ll.checkArity(functionName, len(arguments), span); failure != nil {
+		return failure
+	}
+
+	for i, variable := range ll.required {
+		bindings.bind(variable.Name(), arguments[i])
+	}
+	remaining := arguments[len(ll.required):]
+
+	for _, optional := range ll.optional {
+		var value Expression
+		if len(remaining) > 0 {
+			value = remaining[0]
+			remaining = remaining[1:]
+		}
+		if failure := optional.bind(value, bindings); failure != nil {
+			return failure
+		}
+	}
+
+	if ll.rest != "" {
+		bindings.bind(ll.rest, makeListFromSlice(remaining, Span{}))
+	}
+
+	if !ll.hasKeys {
+		return nil
+	}
+
+	if len(remaining)%2 != 0 {
+		return newEvaluationError(ArityMismatchError, span, "%s expects keyword arguments in pairs but got %s after the positional ones", functionName, countArguments(len(remaining)))
+	}
+
+	allowOtherKeys := ll.allowOtherKeys
+	for i := 0; i < len(remaining); i += 2 {
+		if !isKeywordSymbol(remaining[i]) {
+			return newEvaluationError(ArityMismatchError, span, "%s expects a keyword but got %s", functionName, describe(remaining[i]))
+		}
+		if remaining[i].(Symbol).Name() == ":allow-other-keys" && isTrue(remaining[i+1]) {
+			allowOtherKeys = true
+		}
+	}
+
+	for i := 0; i < len(remaining) && !allowOtherKeys; i += 2 {
+		known := remaining[i].(Symbol).Name() == ":allow-other-keys"
+		for _, key := range ll.keys {
+			known = known || isEq(key.keyword, remaining[i])
+		}
+		if !known {
+			accepted := []string{}
+			for _, key := range ll.keys {
+				accepted = append(accepted, key.keyword.Name())
+			}
+			return newEvaluationError(ArityMismatchError, span, "%s does not accept the keyword %s, only %s", functionName, remaining[i].Print(), strings.Join(accepted, " "))
+		}
+	}
+
+	for _, key := range ll.keys {
+		var value Expression
+		for i := 0; i < len(remaining); i += 2 {
+			if isEq(key.keyword, remaining[i]) {
+				value = remaining[i+1]
+				break
+			}
+		}
+		if failure := key.bind(value, bindings); failure != nil {
+			return failure
+		}
+	}
+
+	return nil
+}
+
+// bind binds a parameter to its argument, or to its default value when the
+// argument is nil.
+func (p parameter) bind(value Expression, bindings *binder) EvaluationResult {
+	supplied := value != nil
+
+	if !supplied {
+		defaultResult := p.defaultValue.Evaluate(bindings.frame)
+		if !defaultResult.IsSuccessful() {
+			return defaultResult
+		}
+		value = defaultResult.(SuccessfulEvaluationResult).Expression
+	}
+
+	bindings.bind(p.variable.Name(), value)
+	if p.suppliedVariable != "" {
+		bindings.bind(p.suppliedVariable, Boolean{Value: supplied})
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package lisp
+
+import (
+	"testing"
+)
+
+func TestLambdaLists(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "optional", source: "(defun f (a &optional b) (list a b)) (list (f 1) (f 1 2))", want: "((1 NIL) (1 2))"},
+		{name: "optional with a default", source: "(defun f (&optional (b 10)) b) (list (f) (f 2))", want: "(10 2)"},
+		{name: "a default using a parameter", source: "(defun f (a &optional (b (* a 2))) b) (f 3)", want: "6"},
+		{name: "a supplied variable", source: "(defun f (&optional (b 1 given)) (list b given)) (list (f) (f 1))", want: "((1 NIL) (1 T))"},
+		{name: "rest", source: "(defun f (a &rest more) (list a more)) (list (f 1) (f 1 2 3))", want: "((1 NIL) (1 (2 3)))"},
+		{name: "body is rest", source: "(defmacro m (&body forms) `(list ,@forms)) (m 1 2)", want: "(1 2)"},
+		{name: "key", source: "(defun f (&key a (b 2)) (list a b)) (list (f) (f :b 3 :a 1))", want: "((NIL 2) (1 3))"},
+		{name: "a key with another keyword", source: "(defun f (&key ((:size s) 0)) s) (f :size 4)", want: "4"},
+		{name: "a key supplied variable", source: "(defun f (&key (a 0 given)) given) (list (f) (f :a 0))", want: "(NIL T)"},
+		{name: "the first of repeated keys", source: "(defun f (&key a) a) (f :a 1 :a 2)", want: "1"},
+		{name: "allow-other-keys", source: "(defun f (&key a &allow-other-keys) a) (f :b 2 :a 1)", want: "1"},
+		{name: "allow-other-keys in the call", source: "(defun f (&key a) a) (f :b 2 :allow-other-keys T)", want: "NIL"},
+		{name: "all kinds", source: "(defun f (a &optional b &rest r &key c) (list a b r c)) (f 1 2 :c 3)", want: "(1 2 (:c 3) 3)"},
+		{name: "lambda", source: "(funcall (lambda (&optional (x 5) &rest ys) (cons x ys)))", want: "(5)"},
+
+		{name: "too few arguments", source: "(defun f (a b &optional c) a) (f 1)", want: "f expects between 2 and 3 arguments but got 1", wantError: "arity-mismatch"},
+		{name: "too many arguments", source: "(defun f (a &optional b) a) (f 1 2 3)", want: "f expects between 1 and 2 arguments but got 3", wantError: "arity-mismatch"},
+		{name: "too few with rest", source: "(defun f (a &rest r) a) (f)", want: "f expects at least 1 argument but got 0", wantError: "arity-mismatch"},
+		{name: "a single required argument", source: "(defun f (a) a) (f)", want: "f expects 1 argument but got 0", wantError: "arity-mismatch"},
+		{name: "an unknown keyword", source: "(defun f (&key a) a) (f :b 1)", want: "f does not accept the keyword :b, only :a", wantError: "arity-mismatch"},
+		{name: "an odd number of keyword arguments", source: "(defun f (&key a) a) (f :a)", want: "f expects keyword arguments in pairs but got 1 argument after the positional ones", wantError: "arity-mismatch"},
+		{name: "a keyword that is not a keyword", source: "(defun f (&key a) a) (f 1 2)", want: "f expects a keyword but got 1", wantError: "arity-mismatch"},
+		{name: "an unsupported lambda list keyword", source: "(defun f (&aux a) a)", want: "&aux is not a lambda list keyword supported in the parameters of f", wantError: "invalid-form"},
+		{name: "misplaced optional", source: "(defun f (&key a &optional b) a)", want: "&optional is misplaced in the parameters of f", wantError: "invalid-form"},
+		{name: "rest without a variable", source: "(defun f (&rest) 1)", want: "&rest must be followed by a variable name", wantError: "invalid-form"},
+		{name: "allow-other-keys without key", source: "(defun f (&allow-other-keys) 1)", want: "&allow-other-keys must follow &key", wantError: "invalid-form"},
+		{name: "a parameter after rest", source: "(defun f (&rest r x) 1)", want: "the parameter x of f is misplaced after &rest", wantError: "invalid-form"},
+		{name: "a number as a parameter", source: "(defun f (1) 1)", want: "the parameter 1 (int) of f is not a variable name", wantError: "invalid-form"},
+		{name: "a bad optional parameter", source: "(defun f (&optional (a 1 2 3)) a)", want: "must be of the form (variable [default [supplied-variable]])", wantError: "invalid-form"},
+		{name: "a bad key parameter", source: "(defun f (&key ((a) 1)) a)", want: "must be of the form (:keyword variable)", wantError: "invalid-form"},
+		{name: "a bad supplied variable", source: "(defun f (&optional (a 1 2)) a)", want: "the supplied variable 2 (int) of f is not a variable name", wantError: "invalid-form"},
+		{name: "parameters that are not a list", source: "(lambda 1 1)", want: "the parameters of lambda must be a list of variable names", wantError: "invalid-form"},
+	})
+}
@@ -2,15 +2,13 @@ package lisp
 
 import (
 	"fmt"
-	"regexp"
-	"strconv"
-	"strings"
 )
 
 type Expression interface {
 	Evaluate(context EvaluationContext) EvaluationResult
 	GetType() string
 	Print() string
+	GetSpan() Span
 }
 
 
@@ -21,12 +19,17 @@ type BaseTypeExpression interface {
 type Block struct {
 	Expression
 	SubExpressions []Expression
+	Span Span
 }
 
 func (b Block) GetType() string {
 	return "block"
 }
 
+func (b Block) GetSpan() Span {
+	return b.Span
+}
+
 func (b Block) Print() string {
 	result := "block"
 	return result
@@ -35,12 +38,17 @@ func (b Block) Print() string {
 type Int struct {
 	BaseTypeExpression
 	Value int
+	Span Span
 }
 
 func (i Int) GetType() string {
 	return "int"
 }
 
+func (i Int) GetSpan() Span {
+	return i.Span
+}
+
 func (i Int) Print() string {
 	return fmt.Sprintf("%d", i.Value)
 }
@@ -48,12 +56,17 @@ func (i Int) Print() string {
 type String struct {
 	BaseTypeExpression
 	Value string
+	Span Span
 }
 
 func (s String) GetType() string {
 	return "string"
 }
 
+func (s String) GetSpan() Span {
+	return s.Span
+}
+
 func (s String) Print() string {
 	return s.Value
 }
@@ -61,12 +74,17 @@ func (s String) Print() string {
 type Boolean struct {
 	BaseTypeExpression
 	Value bool
+	Span Span
 }
 
 func (b Boolean) GetType() string {
 	return "boolean"
 }
 
+func (b Boolean) GetSpan() Span {
+	return b.Span
+}
+
 func (b Boolean) Print() string {
 	if b.Value {
 		return "T"
@@ -80,76 +98,51 @@ type List struct {
 	left Expression
 	right Expression
 	//Value []Expression
+	Span Span
 }
 
-func (l List) GetType() string {
+func (l *List) GetType() string {
 	return "list"
 }
 
-func (l List) Print() string {
-	result := "("
-	elementsToString := []string{}
-	elementsToString = append(elementsToString, l.left.Print())
-	if ! (l.right.GetType() == "boolean" && ! l.right.(Boolean).Value) {
-		rightValue := l.right.Print()
-		if l.right.GetType() != "list" {
-			rightValue = ". " + rightValue
-		} else {
-			rightValue = rightValue[1:len(rightValue)-1]
-		}
-		elementsToString = append(elementsToString, rightValue)
-	}
-	result += strings.Join(elementsToString, " ")
-	result += ")"
-	return result
+func (l *List) GetSpan() Span {
+	return l.Span
 }
 
-type FunctionCall struct {
-	Expression
-	functionName string
-	arguments []Expression
-}
-
-func (fc FunctionCall) GetType() string {
-	return "functionCall"
-}
-
-func (fc FunctionCall) Print() string {
-	result := fc.functionName + "("
-	argumentsAsStrings := []string{}
-	for _, argument := range fc.arguments {
-		argumentsAsStrings = append(argumentsAsStrings, argument.Print())
-	}
-	result += strings.Join(argumentsAsStrings, ",")
-	result += ")"
-	return result
-}
-
-type Variable struct {
-	Expression
-	Name string
-}
-
-func (v Variable) GetType() string {
-	return "variable"
-}
-
-func (v Variable) Print() string {
-	return fmt.Sprintf("@%s", v.Name)
+func (l *List) Print() string {
+	return printExpression(l)
 }
 
 type FunctionDeclaration struct {
 	Expression
 	functionName          string
 	functionDocumentation string
-	arguments             []Variable
+	parameters            lambdaList
 	body                  Block
+	// context is the environment the function was defined in, which its
+	// body can refer to when it is called.
+	context               EvaluationContext
+	isMacro               bool
+	// generic is set for the functions defined with defgeneric, which run
+	// their methods instead of a body.
+	generic               *genericFunction
+	// identity is shared by the copies of the function made by one
+	// defun, lambda or defgeneric, which are eq.
+	identity              *int
+	Span                  Span
 }
 
 func (fd FunctionDeclaration) GetType() string {
+	if fd.isMacro {
+		return "macro"
+	}
 	return "functionDeclaration"
 }
 
+func (fd FunctionDeclaration) GetSpan() Span {
+	return fd.Span
+}
+
 func (fd FunctionDeclaration)  Print() string {
 	return fmt.Sprintf("#%s", fd.functionName)
 }
@@ -166,6 +159,7 @@ type SuccessfulParseResult struct {
 type UnsuccessfulParseResult struct {
 	ParseResult
 	Message string
+	Span    Span
 }
 
 func (r SuccessfulParseResult) IsSucccessful() bool {
@@ -176,406 +170,151 @@ func (r UnsuccessfulParseResult) IsSucccessful() bool {
 	return false
 }
 
-func ParseString(expression string) ParseResult {
-	var is_surrounded_by_quotes = false
-	if expression[:1] == "\"" && expression[len(expression)-1:] == "\"" {
-		is_surrounded_by_quotes = true
-	}
-
-	if is_surrounded_by_quotes {
-		payload := expression[1:len(expression)-1]
-
-		if is_surrounded_by_quotes {
-			return SuccessfulParseResult{
-				Expression: String {
-					Value: payload,
-				},
-			}
-		}
-	}
-
-	errorMsg := fmt.Sprintf("Cannot parse String from \"%s\"", expression)
-	return UnsuccessfulParseResult{
-		Message: errorMsg,
-	}
-}
-
-func ParseVariable(expression string) ParseResult {
-
-	match, _ := regexp.MatchString("[_a-zA-Z][_a-zA-Z1-9]*", expression)
-	if match {
-		return SuccessfulParseResult{
-			Expression: Variable {
-				Name: expression,
-			},
-		}
-	}
-
-	errorMsg := fmt.Sprintf("Cannot parse Variable from \"%s\"", expression)
-	return UnsuccessfulParseResult{
-		Message: errorMsg,
-	}
-}
-
-func ParseInt(expression string) ParseResult {
-	int_value, err := strconv.Atoi(expression)
-
-	if err == nil {
-		return SuccessfulParseResult{
-			Expression: Int{
-				Value: int_value,
-			},
-		}
-	}
-
-	errorMsg := fmt.Sprintf("Cannot parse Int from \"%s\"", expression)
-	return UnsuccessfulParseResult{
-		Message: errorMsg,
-	}
-}
-
-func ParseBoolean(expression string) ParseResult {
-
-	if expression == "T" {
-		return SuccessfulParseResult{
-			Expression: Boolean{
-				Value: true,
-			},
-		}
-	}
-
-	if expression == "NIL" {
-		return SuccessfulParseResult{
-			Expression: Boolean{
-				Value: false,
-			},
-		}
-	}
-
-	errorMsg := fmt.Sprintf("Cannot parse Int from \"%s\"", expression)
-	return UnsuccessfulParseResult{
-		Message: errorMsg,
-	}
-}
-
-func ParseSeveralExpressionsString(expression string) []string {
-	arguments := []string{}
-
-	payload := expression //lisp[1:len(lisp)-1]
-
-	// combine parts of a same string
-	currentWord := ""
-
-	_ = arguments
-	_ = currentWord
-
-	isProcessingASplittedWord := false
-	currentParenthesisLevel := 0
-	currentSubExpressionStart := 0
-
-	for i := 0; i < len(payload); i++ {
-		if payload[i:i+1] == "\"" && i > 0 && payload[i-1:i] == "\\" {
-			isProcessingASplittedWord = !isProcessingASplittedWord
-		}
-
-		if payload[i:i+1] == "(" {
-			if currentParenthesisLevel == 0 {
-				if i > 0 && payload[i-1:i] == "'" {
-					currentSubExpressionStart = i-1
-				} else {
-					currentSubExpressionStart = i
-				}
-			}
-			currentParenthesisLevel += 1
-		}
-
-		if payload[i:i+1] == ")" {
-			currentParenthesisLevel -= 1
-			if currentParenthesisLevel == 0 {
-				subExpression := payload[currentSubExpressionStart:i+1]
-				arguments = append(arguments, subExpression)
-				currentWord = ""
-				continue
-			}
-		}
-
-		if currentParenthesisLevel == 0 {
-			if !isProcessingASplittedWord && (payload[i:i+1] == " " || payload[i:i+1] == "\n") {
-				if len(currentWord) > 0 {
-					arguments = append(arguments, currentWord)
-					currentWord = ""
-				}
-				continue
-			} else {
-				currentWord += payload[i:i+1]
-			}
-		}
-	}
-
-	if currentWord != "" && currentWord != "\n" {
-		arguments = append(arguments, currentWord)
-	}
-
-	return arguments
-}
-
-func countSubExpressions(expression string) int {
-	count := 0
-	currentParenthesisLevel := 0
-	isProcessingASplittedWord := false
-
-	for i := 0; i < len(expression); i++ {
-		if expression[i:i+1] == "\"" && i > 0 && expression[i-1:i] == "\\" {
-			isProcessingASplittedWord = !isProcessingASplittedWord
-		}
-
-		if expression[i:i+1] == "(" {
-			currentParenthesisLevel += 1
-		}
-
-		if expression[i:i+1] == ")" {
-			currentParenthesisLevel -= 1
-			if currentParenthesisLevel == 0 {
-				count += 1
-			}
-		}
-	}
-
-	return count
-}
-
-func ParseBlock(expression string) ParseResult {
-
-	isProcessingASplittedWord := false
-	currentParenthesisLevel := 0
-	currentSubExpressionStart := 0
-
-	expressions := []Expression{}
-	arguments := []string{}
-
-	_ = expressions
-
-	currentWord := ""
-
-	for i := 0; i < len(expression); i++ {
-		if expression[i:i+1] == "\"" && i > 0 && expression[i-1:i] == "\\" {
-			isProcessingASplittedWord = !isProcessingASplittedWord
-		}
-
-		if expression[i:i+1] == "(" {
-			if currentParenthesisLevel == 0 {
-				if i > 0 && expression[i-1:i] == "'" {
-					currentSubExpressionStart = i-1
-				} else {
-					currentSubExpressionStart = i
-				}
-			}
-			currentParenthesisLevel += 1
-		}
+func Parse(expression string) ParseResult {
+	expressions, err := ReadAll(expression)
 
-		if expression[i:i+1] == ")" {
-			currentParenthesisLevel -= 1
-			if currentParenthesisLevel == 0 {
-				subExpression := expression[currentSubExpressionStart:i+1]
-				arguments = append(arguments, subExpression)
-				currentWord = ""
-				continue
+	if err != nil {
+		if syntaxError, ok := err.(SyntaxError); ok {
+			return UnsuccessfulParseResult{
+				Message: syntaxError.Error(),
+				Span:    syntaxError.Span,
 			}
 		}
-
-		if currentParenthesisLevel == 0 {
-			if !isProcessingASplittedWord && (expression[i:i+1] == " " || expression[i:i+1] == "\n") {
-				if len(currentWord) > 0 {
-					arguments = append(arguments, currentWord)
-					currentWord = ""
-				}
-				continue
-			} else {
-				currentWord += expression[i:i+1]
-			}
+		return UnsuccessfulParseResult{
+			Message: err.Error(),
 		}
 	}
 
-	if currentParenthesisLevel > 0 || isProcessingASplittedWord {
-		errorMessage := "Cannot extract any expression from " + expression
+	if len(expressions) == 0 {
+		errorMsg := fmt.Sprintf("Cannot parse \"%s\": no expression found", expression)
 		return UnsuccessfulParseResult{
-			Message: errorMessage,
+			Message: errorMsg,
 		}
 	}
 
-	for _, argument := range arguments {
-		parseResult := Parse(argument)
-		if parseResult.IsSucccessful() {
-			expressions = append(expressions, parseResult.(SuccessfulParseResult).Expression)
-		} else {
-			return parseResult
+	if len(expressions) == 1 {
+		return SuccessfulParseResult{
+			Expression: expressions[0],
 		}
 	}
 
 	return SuccessfulParseResult{
 		Expression: Block{
 			SubExpressions: expressions,
+			Span: Span{
+				Start: expressions[0].GetSpan().Start,
+				End:   expressions[len(expressions)-1].GetSpan().End,
+			},
 		},
 	}
 }
 
-func ParseFunctionCall(expression string) ParseResult {
-	if len(expression) < 2 {
-		errorMsg := fmt.Sprintf("Cannot parse function call from \"%s\"", expression)
-		return UnsuccessfulParseResult{
-			Message: errorMsg,
-		}
-	}
-
-	arguments := ParseSeveralExpressionsString(expression[1: len(expression)-1])
-
-	if len(arguments) == 0 {
-		errorMsg := fmt.Sprintf("Cannot parse Function call from \"%s\"", expression)
-		return UnsuccessfulParseResult{
-			Message: errorMsg,
-		}
-	}
-
-	functionName := arguments[0]
-
-	functionArgumentsExpressions := []Expression{}
-
-	for _, functionArgument := range arguments[1:] {
-		functionArgumentParseResult := Parse(functionArgument)
-
-		if functionArgumentParseResult.IsSucccessful() {
-			r := functionArgumentParseResult.(SuccessfulParseResult)
-			functionArgumentsExpressions = append(functionArgumentsExpressions, r.Expression)
-		} else {
-			errorMsg := fmt.Sprintf("Cannot parse \"%s\"", functionArgument)
-			return UnsuccessfulParseResult{
-				Message: errorMsg,
-			}
-		}
-	}
-
-	return SuccessfulParseResult{
-		Expression: FunctionCall {
-			functionName: functionName,
-			arguments: functionArgumentsExpressions,
-		},
+// EvaluationContext is a frame of variable bindings. Frames are chained
+// through Parent up to the global frame, which is the only one holding
+// function definitions and the declarations of global variables.
+type EvaluationContext struct {
+	Parent *EvaluationContext
+	variables map[string]Expression
+	functions map[string]FunctionDeclaration
+	declarations map[string]variableKind
+	packages *packageRegistry
+	loader *loader
+	readtable *readtable
+	depth *callDepth
+	timeout *evaluationTimeout
+	symbols *symbolState
+	conditions *conditionRegistry
+	handlers *handlerStack
+	structures map[string]*structureType
+}
+
+func NewGlobalContext() EvaluationContext {
+	context := EvaluationContext{
+		variables: make(map[string]Expression),
+		functions: make(map[string]FunctionDeclaration),
+		declarations: make(map[string]variableKind),
+		packages: newPackageRegistry(),
+		loader: newLoader(),
+		readtable: newReadtable(),
+		depth: &callDepth{maximum: DefaultMaxCallDepth},
+		timeout: &evaluationTimeout{},
+		symbols: newSymbolState(),
+		conditions: newConditionRegistry(),
+		handlers: &handlerStack{},
+		structures: make(map[string]*structureType),
+	}
+
+	// the features tested by #+ and #- are the ones of the dynamic
+	// variable *features*
+	context.variables[featuresVariable] = standardFeatures()
+	context.declarations[featuresVariable] = dynamicVariable
+	context.readtable.features = func() Expression {
+		return context.variables[featuresVariable]
+	}
+	context.readtable.readName = context.packages.readName
+	context.readtable.dispatch['S'] = structureReaderMacro(context)
+	context.readtable.dispatch['s'] = structureReaderMacro(context)
+
+	return context
+}
+
+// NewChildContext returns an empty frame whose lookups fall back on the
+// given context.
+func NewChildContext(parent EvaluationContext) EvaluationContext {
+	return EvaluationContext{
+		Parent: &parent,
+		variables: make(map[string]Expression),
 	}
 }
 
-func ParseList(expression string) ParseResult {
-	if len(expression) < 3 {
-		errorMsg := fmt.Sprintf("Cannot parse List from \"%s\"", expression)
-		return UnsuccessfulParseResult{
-			Message: errorMsg,
-		}
-	}
-
-	arguments := ParseSeveralExpressionsString(expression[2: len(expression)-1])
-
-	if len(arguments) == 0 {
-		return SuccessfulParseResult{
-			Expression: Boolean{
-				Value: false,
-			},
-		}
+func (context EvaluationContext) Global() EvaluationContext {
+	for context.Parent != nil {
+		context = *context.Parent
 	}
+	return context
+}
 
-	var firstElement List
-	var previousElement List
-	var currentElement List
-
-	for i := (len(arguments) -1) ; i >= 0; i-- {
-		functionArgument := arguments[i]
-		functionArgumentParseResult := Parse(functionArgument)
-		if functionArgumentParseResult.IsSucccessful() {
-			r := functionArgumentParseResult.(SuccessfulParseResult)
-			if i == (len(arguments) - 1) {
-				currentElement = List{
-					left:  r.Expression,
-					right: Boolean{Value: false},
-				}
-			} else {
-				currentElement = List{
-					left:  r.Expression,
-					right: previousElement,
-				}
-			}
-			previousElement = currentElement
+func (context EvaluationContext) LookupVariable(name string) (Expression, bool) {
+	for current := &context; current != nil; current = current.Parent {
+		if value, ok := current.variables[name]; ok {
+			return value, true
 		}
 	}
-	firstElement = currentElement
-
-	return SuccessfulParseResult{
-		Expression: firstElement,
-	}
+	return nil, false
 }
 
-func Parse(expression string) ParseResult {
-
-	var isSurroundedByParentheses = false
-	var isDefiningAList = false
-
-	if len(expression) >= 2 && expression[:1] == "(" && expression[len(expression)-1:] == ")" {
-		isSurroundedByParentheses = true
-	}
-
-	if len(expression) >= 3 && expression[:2] == "'(" && expression[len(expression)-1:] == ")" {
-		isDefiningAList = true
-	}
-
-	payload := expression
+// DefineVariable binds a variable in this frame, shadowing any binding of
+// the same name in the parent frames.
+func (context EvaluationContext) DefineVariable(name string, value Expression) {
+	context.variables[name] = value
+}
 
-	if countSubExpressions(expression) > 1 {
-		blockParseResult := ParseBlock(payload)
-		if blockParseResult.IsSucccessful() {
-			return blockParseResult
+// SetVariable assigns the closest existing binding of a variable, or
+// creates a global binding when the variable is not bound yet.
+func (context EvaluationContext) SetVariable(name string, value Expression) {
+	for current := &context; current != nil; current = current.Parent {
+		if _, ok := current.variables[name]; ok {
+			current.variables[name] = value
+			return
 		}
 	}
+	context.Global().variables[name] = value
+}
 
-	if isDefiningAList {
-		listParseResult := ParseList(payload)
-		if listParseResult.IsSucccessful() {
-			return listParseResult
-		}
-
-	} else if isSurroundedByParentheses {
-		functionCallParseResult := ParseFunctionCall(payload)
-		if functionCallParseResult.IsSucccessful() {
-			return functionCallParseResult
-		}
-	} else {
-
-		booleanParseResult := ParseBoolean(payload)
-		if booleanParseResult.IsSucccessful() {
-			return booleanParseResult
-		}
-
-		stringParseResult := ParseString(payload)
-		if stringParseResult.IsSucccessful() {
-			return stringParseResult
-		}
-
-		intParseResult := ParseInt(payload)
-		if intParseResult.IsSucccessful() {
-			return intParseResult
-		}
-
-		variableParseResult := ParseVariable(payload)
-		if variableParseResult.IsSucccessful() {
-			return variableParseResult
+// LookupFunction finds a function by name, which may be qualified with a
+// package, as explained in packages.go.
+func (context EvaluationContext) LookupFunction(name string) (FunctionDeclaration, bool) {
+	global := context.Global()
+	for _, key := range context.functionKeys(name) {
+		if functionDeclaration, ok := global.functions[key]; ok {
+			return functionDeclaration, true
 		}
 	}
-
-	errorMsg := fmt.Sprintf("Cannot parse \"%s\"", expression)
-	return UnsuccessfulParseResult{
-		Message: errorMsg,
-	}
+	return FunctionDeclaration{}, false
 }
 
-type EvaluationContext struct {
-	Parent *EvaluationContext
-	variables map[string]Expression
-	functions map[string]FunctionDeclaration
+func (context EvaluationContext) DefineFunction(name string, functionDeclaration FunctionDeclaration) {
+	context.Global().functions[context.definitionKey(name)] = functionDeclaration
 }
 
 
@@ -590,7 +329,7 @@ type SuccessfulEvaluationResult struct {
 
 type UnsuccessfulEvaluationResult struct {
 	EvaluationResult
-	message string
+	Error *EvaluationError
 }
 
 func (er SuccessfulEvaluationResult) IsSuccessful() bool {
@@ -611,11 +350,12 @@ func (block Block) Evaluate(context EvaluationContext) EvaluationResult {
 		if evaluationResult.IsSuccessful() {
 			results = append(results, evaluationResult.(SuccessfulEvaluationResult).Expression)
 		} else {
-			return UnsuccessfulEvaluationResult{}
+			return evaluationResult
 		}
 	}
 
 	result := SuccessfulEvaluationResult {
+		Expression: Boolean{Value: false},
 	}
 	if len(results) > 0 {
 		result = SuccessfulEvaluationResult {
@@ -644,58 +384,114 @@ func (re String) Evaluate(context EvaluationContext) EvaluationResult {
 	}
 }
 
-func (re List) Evaluate(context EvaluationContext) EvaluationResult {
-	return SuccessfulEvaluationResult {
-		Expression: re,
-	}
+// Evaluate evaluates a list as a form: a special form, a macro call or a
+// function call depending on its head.
+func (re *List) Evaluate(context EvaluationContext) EvaluationResult {
+	return resolveTailCall(re.evaluateForm(context))
 }
 
-func (v Variable) Evaluate(context EvaluationContext) EvaluationResult {
+// evaluateForm evaluates a list as a form, but returns a tailCall instead
+// of calling a user defined function, so that a call in tail position does
+// not grow the Go stack.
+func (re *List) evaluateForm(context EvaluationContext) EvaluationResult {
+
+	arguments, ok := listToSlice(re.right)
+	if ! ok {
+		return newEvaluationError(InvalidFormError, re.Span, "cannot evaluate the dotted list %s", re.Print())
+	}
+
+	if head, ok := re.left.(Symbol); ok {
+		if specialForm, ok := specialForms[head.Name()]; ok {
+			return specialForm(arguments, re.Span, context)
+		}
 
-	if variableValue, ok := context.variables[v.Name]; ok {
-		return SuccessfulEvaluationResult {
-			Expression: variableValue,
+		if macro, ok := context.LookupFunction(head.Name()); ok && macro.isMacro {
+			return expandAndEvaluate(macro, re, context)
 		}
 	}
 
-	return UnsuccessfulEvaluationResult {
+	var function Expression
+
+	if head, ok := re.left.(Symbol); ok {
+		function, ok = lookupFunctionValue(head.Name(), context)
+		if ! ok {
+			return newEvaluationError(UnknownFunctionError, re.Span, "the function %s is undefined", head.Name())
+		}
+	} else if isFormNamed(re.left, "lambda") {
+		evaluationResult := re.left.Evaluate(context)
+		if ! evaluationResult.IsSuccessful() {
+			return evaluationResult
+		}
+		function = evaluationResult.(SuccessfulEvaluationResult).Expression
+	} else {
+		return newEvaluationError(InvalidFormError, re.Span, "cannot evaluate %s: %s is not a function name", re.Print(), describe(re.left))
 	}
-}
 
-func compare(evaluatedArguments []EvaluationResult, side int) EvaluationResult {
+	evaluatedArguments := []Expression{}
 
-	if len(evaluatedArguments) != 2 {
-		return UnsuccessfulEvaluationResult{}
+	for _, argument := range arguments {
+		evaluationResult := argument.Evaluate(context)
+		if ! evaluationResult.IsSuccessful() {
+			return evaluationResult
+		}
+		evaluatedArguments = append(evaluatedArguments, evaluationResult.(SuccessfulEvaluationResult).Expression)
 	}
 
-	if ! (evaluatedArguments[0].IsSuccessful() && evaluatedArguments[1].IsSuccessful()) {
-		return UnsuccessfulEvaluationResult{}
+	if functionDeclaration, ok := function.(FunctionDeclaration); ok && ! functionDeclaration.isMacro {
+		return tailCall{
+			function: functionDeclaration,
+			arguments: evaluatedArguments,
+			span: re.Span,
+		}
 	}
 
-	arg1 := evaluatedArguments[0].(SuccessfulEvaluationResult).Expression
-	arg2 := evaluatedArguments[1].(SuccessfulEvaluationResult).Expression
+	return applyFunction(function, evaluatedArguments, re.Span)
+}
+
+func checkArity(functionName string, arguments []Expression, expectedCount int) EvaluationResult {
+	if len(arguments) != expectedCount {
+		return newEvaluationError(ArityMismatchError, Span{}, "%s expects %s but got %d", functionName, countArguments(expectedCount), len(arguments))
+	}
+	return nil
+}
 
-	if ! (arg1.GetType() == "int" && arg2.GetType() == "int") {
-		return UnsuccessfulEvaluationResult{}
+func checkArityRange(functionName string, arguments []Expression, minimumCount int, maximumCount int) EvaluationResult {
+	if len(arguments) < minimumCount || len(arguments) > maximumCount {
+		return newEvaluationError(ArityMismatchError, Span{}, "%s expects between %d and %d arguments but got %d", functionName, minimumCount, maximumCount, len(arguments))
 	}
+	return nil
+}
 
-	arg1AsInt := arg1.(Int)
-	arg2AsInt := arg2.(Int)
+func isNil(expression Expression) bool {
+	return expression.GetType() == "boolean" && ! expression.(Boolean).Value
+}
 
-	result := false
+// compare implements the comparison functions, which return T when test
+// holds for every pair of consecutive arguments, or for every pair of
+// arguments with /=.
+func compare(functionName string, arguments []Expression, test func(comparison int) bool) EvaluationResult {
 
-	if side == -1 {
-		result = arg1AsInt.Value > arg2AsInt.Value
-	} else if side == 0 {
-		result = arg1AsInt.Value == arg2AsInt.Value
-	} else if side == 1 {
-		result = arg1AsInt.Value < arg2AsInt.Value
-	} else if side == 2 {
-		result = arg1AsInt.Value != arg2AsInt.Value
-	} else {
-		return UnsuccessfulEvaluationResult{}
+	if len(arguments) == 0 {
+		return newEvaluationError(ArityMismatchError, Span{}, "%s expects at least 1 argument", functionName)
+	}
+
+	numbers, failure := expectNumbers(functionName, arguments)
+	if failure != nil {
+		return failure
 	}
 
+	result := true
+
+	for i := 0; i < len(numbers); i++ {
+		for j := i + 1; j < len(numbers); j++ {
+			if ! test(compareNumbers(numbers[i], numbers[j])) {
+				result = false
+			}
+			if functionName != "/=" {
+				break
+			}
+		}
+	}
 
 	return SuccessfulEvaluationResult{
 		Expression: Boolean{
@@ -704,431 +500,435 @@ func compare(evaluatedArguments []EvaluationResult, side int) EvaluationResult {
 	}
 }
 
-func plus(evaluatedArguments []EvaluationResult) EvaluationResult {
-	if len(evaluatedArguments) != 2 {
-		return UnsuccessfulEvaluationResult{}
+func plus(arguments []Expression) EvaluationResult {
+	numbers, failure := expectNumbers("+", arguments)
+	if failure != nil {
+		return failure
 	}
 
-	if ! (evaluatedArguments[0].IsSuccessful() && evaluatedArguments[1].IsSuccessful()) {
-		return UnsuccessfulEvaluationResult{}
-	}
-
-	arg1 := evaluatedArguments[0].(SuccessfulEvaluationResult).Expression
-	arg2 := evaluatedArguments[1].(SuccessfulEvaluationResult).Expression
-
-	if ! (arg1.GetType() == "int" && arg2.GetType() == "int") {
-		return UnsuccessfulEvaluationResult{}
+	var result Expression = Int{Value: 0}
+	for _, number := range numbers {
+		result = addNumbers(result, number)
 	}
 
-	arg1AsInt := arg1.(Int)
-	arg2AsInt := arg2.(Int)
-
 	return SuccessfulEvaluationResult{
-		Expression: Int{
-			Value: arg1AsInt.Value + arg2AsInt.Value,
-		},
+		Expression: result,
 	}
 }
 
-func minus(evaluatedArguments []EvaluationResult) EvaluationResult {
-	if len(evaluatedArguments) != 2 {
-		return UnsuccessfulEvaluationResult{}
+// minus subtracts the following arguments from the first one, or negates
+// its only argument.
+func minus(arguments []Expression) EvaluationResult {
+	if len(arguments) == 0 {
+		return newEvaluationError(ArityMismatchError, Span{}, "- expects at least 1 argument")
 	}
 
-	if ! (evaluatedArguments[0].IsSuccessful() && evaluatedArguments[1].IsSuccessful()) {
-		return UnsuccessfulEvaluationResult{}
+	numbers, failure := expectNumbers("-", arguments)
+	if failure != nil {
+		return failure
 	}
 
-	arg1 := evaluatedArguments[0].(SuccessfulEvaluationResult).Expression
-	arg2 := evaluatedArguments[1].(SuccessfulEvaluationResult).Expression
-
-	if ! (arg1.GetType() == "int" && arg2.GetType() == "int") {
-		return UnsuccessfulEvaluationResult{}
+	if len(numbers) == 1 {
+		return SuccessfulEvaluationResult{
+			Expression: subtractNumbers(Int{Value: 0}, numbers[0]),
+		}
 	}
 
-	arg1AsInt := arg1.(Int)
-	arg2AsInt := arg2.(Int)
+	result := numbers[0]
+	for _, number := range numbers[1:] {
+		result = subtractNumbers(result, number)
+	}
 
 	return SuccessfulEvaluationResult{
-		Expression: Int{
-			Value: arg1AsInt.Value - arg2AsInt.Value,
-		},
+		Expression: result,
 	}
 }
 
-func mult(evaluatedArguments []EvaluationResult) EvaluationResult {
-	if len(evaluatedArguments) != 2 {
-		return UnsuccessfulEvaluationResult{}
+func mult(arguments []Expression) EvaluationResult {
+	numbers, failure := expectNumbers("*", arguments)
+	if failure != nil {
+		return failure
 	}
 
-	if ! (evaluatedArguments[0].IsSuccessful() && evaluatedArguments[1].IsSuccessful()) {
-		return UnsuccessfulEvaluationResult{}
+	var result Expression = Int{Value: 1}
+	for _, number := range numbers {
+		result = multiplyNumbers(result, number)
 	}
 
-	arg1 := evaluatedArguments[0].(SuccessfulEvaluationResult).Expression
-	arg2 := evaluatedArguments[1].(SuccessfulEvaluationResult).Expression
-
-	if ! (arg1.GetType() == "int" && arg2.GetType() == "int") {
-		return UnsuccessfulEvaluationResult{}
-	}
-
-	arg1AsInt := arg1.(Int)
-	arg2AsInt := arg2.(Int)
-
 	return SuccessfulEvaluationResult{
-		Expression: Int{
-			Value: arg1AsInt.Value * arg2AsInt.Value,
-		},
+		Expression: result,
 	}
 }
 
-func divide(evaluatedArguments []EvaluationResult) EvaluationResult {
-	if len(evaluatedArguments) != 2 {
-		return UnsuccessfulEvaluationResult{}
+// divide divides the first argument by the following ones, or returns the
+// inverse of its only argument.
+func divide(arguments []Expression) EvaluationResult {
+	if len(arguments) == 0 {
+		return newEvaluationError(ArityMismatchError, Span{}, "/ expects at least 1 argument")
 	}
 
-	if ! (evaluatedArguments[0].IsSuccessful() && evaluatedArguments[1].IsSuccessful()) {
-		return UnsuccessfulEvaluationResult{}
+	numbers, failure := expectNumbers("/", arguments)
+	if failure != nil {
+		return failure
 	}
 
-	arg1 := evaluatedArguments[0].(SuccessfulEvaluationResult).Expression
-	arg2 := evaluatedArguments[1].(SuccessfulEvaluationResult).Expression
-
-	if ! (arg1.GetType() == "int" && arg2.GetType() == "int") {
-		return UnsuccessfulEvaluationResult{}
+	if len(numbers) == 1 {
+		numbers = []Expression{Int{Value: 1}, numbers[0]}
 	}
 
-	arg1AsInt := arg1.(Int)
-	arg2AsInt := arg2.(Int)
+	result := numbers[0]
+	for _, number := range numbers[1:] {
+		result, failure = divideNumbers(result, number)
+		if failure != nil {
+			return failure
+		}
+	}
 
 	return SuccessfulEvaluationResult{
-		Expression: Int{
-			Value: arg1AsInt.Value / arg2AsInt.Value,
-		},
+		Expression: result,
 	}
 }
 
-func ifFunction(re FunctionCall, context EvaluationContext) EvaluationResult {
+func ifFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
 
-	if len(re.arguments) != 3 {
-		return UnsuccessfulEvaluationResult{}
+	if len(arguments) != 3 {
+		return newEvaluationError(ArityMismatchError, span, "if expects a condition, a then form and an else form but got %s", countArguments(len(arguments)))
 	}
 
-	arg1EvaluationResult := re.arguments[0].Evaluate(context)
+	arg1EvaluationResult := arguments[0].Evaluate(context)
 
 	if ! (arg1EvaluationResult.IsSuccessful()) {
-		return UnsuccessfulEvaluationResult{}
+		return arg1EvaluationResult
 	}
 
 	arg1 := arg1EvaluationResult.(SuccessfulEvaluationResult).Expression
 
-	//if ! (arg1.GetType() == "boolean") {
-	//	return UnsuccessfulEvaluationResult{}
-	//}
-
-	//arg1AsBoolean := arg1.(Boolean)
-
 	var expressionToExecute Expression
 
 	if ! (arg1.GetType() == "boolean" && ! arg1.(Boolean).Value) {
-		expressionToExecute = re.arguments[1]
+		expressionToExecute = arguments[1]
 	} else {
-		expressionToExecute = re.arguments[2]
-	}
-
-	evaluationResult := expressionToExecute.Evaluate(context)
-
-	if ! evaluationResult.IsSuccessful() {
-		return UnsuccessfulEvaluationResult{}
+		expressionToExecute = arguments[2]
 	}
 
-	resultExpression := evaluationResult.(SuccessfulEvaluationResult).Expression
-
-	return SuccessfulEvaluationResult{
-		Expression: resultExpression,
-	}
+	return evaluateTail(expressionToExecute, context)
 }
 
-func makeList(evaluatedArguments []EvaluationResult) EvaluationResult {
+func makeList(arguments []Expression) EvaluationResult {
 
-	if len(evaluatedArguments) != 2 {
-		return UnsuccessfulEvaluationResult{}
+	if failure := checkArity("cons", arguments, 2); failure != nil {
+		return failure
 	}
 
-	if ! (evaluatedArguments[0].IsSuccessful() && evaluatedArguments[1].IsSuccessful()) {
-		return UnsuccessfulEvaluationResult{}
-	}
-
-	arg1 := evaluatedArguments[0].(SuccessfulEvaluationResult).Expression
-	arg2 := evaluatedArguments[1].(SuccessfulEvaluationResult).Expression
-
 	return SuccessfulEvaluationResult{
-		Expression: List{
-			left: arg1,
-			right: arg2,
+		Expression: &List{
+			left: arguments[0],
+			right: arguments[1],
 		},
 	}
 }
 
-func defineFunction(expressions []Expression, context EvaluationContext) EvaluationResult {
-
-	functionName := "lambda"
-	arguments := []Variable{}
-	functionDocumentation := ""
-
-	bodyExpressions := []Expression{}
-
-	for idx, expression := range expressions {
-		variableType := expression.GetType()
-
-		if idx == 0 && variableType == "variable" {
-			functionName = expression.(Variable).Name
-			continue
-		}
-
-		if idx == 1 && variableType == "functionCall" {
-			expressionAsFunctionCall := expression.(FunctionCall)
-			arguments = append(arguments, Variable{Name: expressionAsFunctionCall.functionName})
-
-			for _, functionArgument := range expressionAsFunctionCall.arguments {
-				if functionArgument.GetType() == "variable" {
-					arguments = append(arguments, functionArgument.(Variable))
-				} else {
-					return UnsuccessfulEvaluationResult{}
-				}
-			}
-			continue
-		}
-
-		if idx == 2 && variableType == "string" {
-			functionDocumentation = expression.(String).Value
-			continue
-		}
-
-		if idx >= 2 {
-			bodyExpressions = append(bodyExpressions, expression)
-			continue
-		}
+func makeAssignment(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) != 2 {
+		return newEvaluationError(ArityMismatchError, span, "setq expects a variable and a value but got %s", countArguments(len(arguments)))
 	}
 
-	functionDeclaration := FunctionDeclaration{
-		functionName:          functionName,
-		functionDocumentation: functionDocumentation,
-		arguments: arguments,
-		body: Block{SubExpressions: bodyExpressions},
+	if arguments[0].GetType() != "symbol" {
+		return newEvaluationError(InvalidFormError, arguments[0].GetSpan(), "setq cannot assign %s, which is not a variable", describe(arguments[0]))
 	}
 
-	context.functions[functionName] = functionDeclaration
-
-	return SuccessfulEvaluationResult{
-		Expression: functionDeclaration,
+	if failure := checkAssignable("setq", arguments[0].(Symbol), context); failure != nil {
+		return failure
 	}
-}
 
-func makeAssignment(re FunctionCall, context EvaluationContext) EvaluationResult {
-	if len(re.arguments) != 2 {
-		return UnsuccessfulEvaluationResult{}
-	}
+	variableName := arguments[0].(Symbol).Name()
+	evaluationResult := arguments[1].Evaluate(context)
 
-	if re.arguments[0].GetType() != "variable" {
-		return UnsuccessfulEvaluationResult{}
+	if ! evaluationResult.IsSuccessful() {
+		return evaluationResult
 	}
 
-	variableName := re.arguments[0].(Variable).Name
-	variableValue := re.arguments[1]
-
-	context.variables[variableName] = variableValue
+	variableValue := evaluationResult.(SuccessfulEvaluationResult).Expression
+	context.SetVariable(variableName, variableValue)
 
 	return SuccessfulEvaluationResult{
 		Expression: variableValue,
 	}
 }
 
-func headList(evaluatedArguments []EvaluationResult) EvaluationResult {
-	if len(evaluatedArguments) != 1 {
-		return UnsuccessfulEvaluationResult{}
+func headList(arguments []Expression) EvaluationResult {
+	if failure := checkArity("car", arguments, 1); failure != nil {
+		return failure
 	}
 
-	if ! evaluatedArguments[0].IsSuccessful() {
-		return UnsuccessfulEvaluationResult{}
-	}
-
-	arg1 := evaluatedArguments[0].(SuccessfulEvaluationResult).Expression
+	arg1 := arguments[0]
 
-	if arg1.GetType() == "boolean" {
-		if !arg1.(Boolean).Value {
-			return SuccessfulEvaluationResult{
-				Expression: arg1,
-			}
-		} else {
-			return UnsuccessfulEvaluationResult{}
+	if arg1.GetType() == "boolean" && !arg1.(Boolean).Value {
+		return SuccessfulEvaluationResult{
+			Expression: arg1,
 		}
 	}
 
 	if arg1.GetType() != "list" {
-		return UnsuccessfulEvaluationResult{}
+		return newEvaluationError(TypeMismatchError, Span{}, "car expects a list but got %s", describe(arg1))
 	}
 
-	result := arg1.(List).left
+	result := arg1.(*List).left
 
 	return SuccessfulEvaluationResult{
 		Expression: result,
 	}
 }
 
-func restList(evaluatedArguments []EvaluationResult) EvaluationResult {
-	if len(evaluatedArguments) != 1 {
-		return UnsuccessfulEvaluationResult{}
-	}
-
-	if ! evaluatedArguments[0].IsSuccessful() {
-		return UnsuccessfulEvaluationResult{}
+func restList(arguments []Expression) EvaluationResult {
+	if failure := checkArity("cdr", arguments, 1); failure != nil {
+		return failure
 	}
 
-	arg1 := evaluatedArguments[0].(SuccessfulEvaluationResult).Expression
+	arg1 := arguments[0]
 
-	if arg1.GetType() == "boolean" {
-		if !arg1.(Boolean).Value {
-			return SuccessfulEvaluationResult{
-				Expression: arg1,
-			}
-		} else {
-			return UnsuccessfulEvaluationResult{}
+	if arg1.GetType() == "boolean" && !arg1.(Boolean).Value {
+		return SuccessfulEvaluationResult{
+			Expression: arg1,
 		}
 	}
 
 	if arg1.GetType() != "list" {
-		return UnsuccessfulEvaluationResult{}
+		return newEvaluationError(TypeMismatchError, Span{}, "cdr expects a list but got %s", describe(arg1))
 	}
 
-	result := arg1.(List).right
+	result := arg1.(*List).right
 
 	return SuccessfulEvaluationResult{
 		Expression: result,
 	}
 }
 
-
-func (re FunctionCall) Evaluate(context EvaluationContext) EvaluationResult {
-
-	newContextvariables := make(map[string]Expression)
-	newContextFunctions := make(map[string]FunctionDeclaration)
-
-	// Copy variables from pred
-	for key, value := range context.variables {
-		newContextvariables[key] = value
-	}
-
-	// Copy from the original map to the target map
-	for key, value := range context.functions {
-		newContextFunctions[key] = value
-	}
-
-	functionContext := EvaluationContext{
-		Parent: &context,
-		variables: newContextvariables,
-		functions: newContextFunctions,
-	}
-
-	evaluatedArguments := []EvaluationResult{}
-
-	for i := 0; i < len(re.arguments); i++ {
-		//variableName := re.arguments[i].(Variable).Name
-		//_= variableName
-		//variableValue := re.arguments[i].Evaluate(functionContext)
-		//if variableValue.IsSuccessful() {
-		//	functionContext.variables[variableName] = variableValue.(SuccessfulEvaluationResult).Expression
-		//} else {
-		//	return UnsuccessfulEvaluationResult{}
-		//}
-	}
-
-	if re.functionName == "defun" {
-		return defineFunction(re.arguments, context)
-	}
-
-	if re.functionName == "setq" {
-		return makeAssignment(re, functionContext)
-	}
-
-	if re.functionName == "if" {
-		return ifFunction(re, functionContext)
-	}
-
-	for _, functionCallArgument := range re.arguments {
-		evaluatedArguments = append(evaluatedArguments, functionCallArgument.Evaluate(context))
-	}
-
-	if re.functionName == "car" {
-		return headList(evaluatedArguments)
-	}
-
-	if re.functionName == "cdr" {
-		return restList(evaluatedArguments)
-	}
-
-	if re.functionName == ">" {
-		return compare(evaluatedArguments, -1)
-	}
-
-	if re.functionName == "<" {
-		return compare(evaluatedArguments, 0)
-	}
-
-	if re.functionName == "=" {
-		return compare(evaluatedArguments, 1)
-	}
-
-	if re.functionName == "/=" {
-		return compare(evaluatedArguments, 2)
-	}
-
-	if re.functionName == "+" {
-		return plus(evaluatedArguments)
-	}
-
-	if re.functionName == "-" {
-		return minus(evaluatedArguments)
-	}
-
-	if re.functionName == "/" {
-		return divide(evaluatedArguments)
-	}
-
-	if re.functionName == "*" {
-		return mult(evaluatedArguments)
-	}
-
-	if re.functionName == "cons" {
-		return makeList(evaluatedArguments)
-	}
-
-	if functionDeclaration, ok := context.functions[re.functionName]; ok {
-		if ! (len(re.arguments) == len(functionDeclaration.arguments)) {
-			return  UnsuccessfulEvaluationResult{}
-		}
-
-		for i := 0; i < len(re.arguments); i++ {
-			variableName := functionDeclaration.arguments[i].Name
-			variableValue := re.arguments[i].Evaluate(functionContext)
-			if variableValue.IsSuccessful() {
-				functionContext.variables[variableName] = variableValue.(SuccessfulEvaluationResult).Expression
-			} else {
-				return UnsuccessfulEvaluationResult{}
-			}
-		}
-
-		result := functionDeclaration.body.Evaluate(functionContext)
-		return result
+// builtinFunctions and specialForms are filled in init, as the functions
+// they hold refer back to them.
+var builtinFunctions map[string]func(arguments []Expression) EvaluationResult
+
+// interpreterBuiltins are builtins which use the state of the interpreter
+// calling them, such as the property lists of symbols. They receive its
+// global context.
+var interpreterBuiltins map[string]func(arguments []Expression, global EvaluationContext) EvaluationResult
+
+// specialForm is implemented in Go like a builtin, but receives its
+// arguments unevaluated.
+type specialForm func(arguments []Expression, span Span, context EvaluationContext) EvaluationResult
+
+var specialForms map[string]specialForm
+
+func init() {
+	builtinFunctions = map[string]func(arguments []Expression) EvaluationResult{
+		"car":  headList,
+		"cdr":  restList,
+		"cons": makeList,
+		"list":          list,
+		"length":        length,
+		"append":        appendLists,
+		"reverse":       reverse,
+		"nth":           nth,
+		"nthcdr":        nthcdr,
+		"last":          last,
+		"mapcar":        mapcar,
+		"mapc":          mapc,
+		"reduce":        reduce,
+		"remove-if":     removeIf("remove-if", false),
+		"remove-if-not": removeIf("remove-if-not", true),
+		"find":          find,
+		"position":      position,
+		"member":        member,
+		"every":         everyOrSome("every", true),
+		"some":          everyOrSome("some", false),
+		"sort":          sortList,
+		"assoc":         associate("assoc", false),
+		"rassoc":        associate("rassoc", true),
+		"copy-list":     copyList,
+		"rplaca":        replaceCons("rplaca", true),
+		"rplacd":        replaceCons("rplacd", false),
+		"make-hash-table":    makeHashTable,
+		"gethash":            gethash,
+		"remhash":            remhash,
+		"maphash":            maphash,
+		"hash-table-count":   hashTableCount,
+		"make-array":         makeArray,
+		"vector":             vector,
+		"aref":               aref,
+		"vector-push-extend": vectorPushExtend,
+		"+":    plus,
+		"-":    minus,
+		"*":    mult,
+		"/":    divide,
+		">": func(arguments []Expression) EvaluationResult {
+			return compare(">", arguments, func(comparison int) bool { return comparison > 0 })
+		},
+		"<": func(arguments []Expression) EvaluationResult {
+			return compare("<", arguments, func(comparison int) bool { return comparison < 0 })
+		},
+		">=": func(arguments []Expression) EvaluationResult {
+			return compare(">=", arguments, func(comparison int) bool { return comparison >= 0 })
+		},
+		"<=": func(arguments []Expression) EvaluationResult {
+			return compare("<=", arguments, func(comparison int) bool { return comparison <= 0 })
+		},
+		"=": func(arguments []Expression) EvaluationResult {
+			return compare("=", arguments, func(comparison int) bool { return comparison == 0 })
+		},
+		"/=": func(arguments []Expression) EvaluationResult {
+			return compare("/=", arguments, func(comparison int) bool { return comparison != 0 })
+		},
+		"mod":      remainderFunction("mod", roundingFloor),
+		"rem":      remainderFunction("rem", roundingTruncate),
+		"abs":      absolute,
+		"min":      extremum("min", -1),
+		"max":      extremum("max", 1),
+		"gcd":      integerFunction("gcd", 0, greatestCommonDivisor),
+		"lcm":      integerFunction("lcm", 1, leastCommonMultiple),
+		"expt":     expt,
+		"sqrt":     squareRoot,
+		"floor":    roundingFunction("floor", roundingFloor),
+		"ceiling":  roundingFunction("ceiling", roundingCeiling),
+		"truncate": roundingFunction("truncate", roundingTruncate),
+		"round":    roundingFunction("round", roundingRound),
+		"1+":       increment("1+", 1),
+		"1-":       increment("1-", -1),
+		"zerop":    zerop,
+		"evenp":    parity("evenp", 0),
+		"oddp":     parity("oddp", 1),
+		"logand":   integerFunction("logand", -1, bitwiseAnd),
+		"logior":   integerFunction("logior", 0, bitwiseOr),
+		"logxor":   integerFunction("logxor", 0, bitwiseXor),
+		"ash":      arithmeticShift,
+		"funcall": funcall,
+		"apply":   apply,
+		"eq":           eq,
+		"eql":          equality("eql", isEql),
+		"equal":        equality("equal", isEqual),
+		"null":         typePredicate("null", isNil),
+		"atom":         typePredicate("atom", isAtom),
+		"consp":        typePredicate("consp", isCons),
+		"listp":        typePredicate("listp", isList),
+		"numberp":      typePredicate("numberp", isNumber),
+		"stringp":      typePredicate("stringp", isString),
+		"symbolp":      typePredicate("symbolp", isSymbol),
+		"functionp":    typePredicate("functionp", isFunction),
+		"type-of":      typeOf,
+		"symbol-name":  symbolName,
+		"concat":          concat,
+		"substring":       substring,
+		"string-length":   stringLength,
+		"string-upcase":   stringUpcase,
+		"string-downcase": stringDowncase,
+		"split":           split,
+		"join":            join,
+		"string=":         stringEqual,
+		"string<":         stringLess,
+		"search":          search,
+		"string->number":  stringToNumber,
+		"number->string":  numberToString,
+		"error-message":   errorMessage,
+	}
+
+	interpreterBuiltins = map[string]func(arguments []Expression, global EvaluationContext) EvaluationResult{
+		"error":                        errorFunction,
+		"eval":                         evalFunction,
+		"gensym":                       gensym,
+		"intern":                       intern,
+		"get":                          getProperty,
+		"put":                          putProperty,
+		"remprop":                      removeProperty,
+		"symbol-plist":                 symbolPlist,
+		"macroexpand-1":                macroexpandFunction("macroexpand-1", false),
+		"macroexpand":                  macroexpandFunction("macroexpand", true),
+		"export":                       exportFunction,
+		"use-package":                  usePackageFunction,
+		"load":                         loadFunction,
+		"require":                      requireFunction,
+		"provide":                      provideFunction,
+		"set-dispatch-macro-character": setDispatchMacroCharacterFunction,
+	}
+
+	placeSetters = map[string]func(arguments []Expression, value Expression, global EvaluationContext) EvaluationResult{
+		"car": cxrSetter("car"),
+		"cdr": cxrSetter("cdr"),
+		"nth": nthSetter,
+		"get": getSetter,
+		"gethash": gethashSetter,
+		"aref":    arefSetter,
+	}
+
+	for _, name := range cxrNames() {
+		builtinFunctions[name] = cxr(name)
+		placeSetters[name] = cxrSetter(name)
+	}
+
+	specialForms = map[string]specialForm{
+		"quote":      quoteFunction,
+		"quasiquote": quasiquoteFunction,
+		"if":         ifFunction,
+		"setq":       makeAssignment,
+		"setf":       setfFunction,
+		"push":       pushFunction,
+		"pop":        popFunction,
+		"incf":       incrementFunction("incf", 1),
+		"decf":       incrementFunction("decf", -1),
+		"defun":      defineFunction,
+		"lambda":     lambdaFunction,
+		"function":   functionFunction,
+		"defmacro":   defineMacro,
+		"progn":      prognFunction,
+		"let": func(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+			return letFunction("let", arguments, span, context, false)
+		},
+		"let*": func(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+			return letFunction("let*", arguments, span, context, true)
+		},
+		"cond": condFunction,
+		"when": func(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+			return whenFunction("when", arguments, span, context, true)
+		},
+		"unless": func(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+			return whenFunction("unless", arguments, span, context, false)
+		},
+		"and": andFunction,
+		"or":  orFunction,
+		"case": func(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+			return caseFunction("case", arguments, span, context, false)
+		},
+		"ecase": func(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+			return caseFunction("ecase", arguments, span, context, true)
+		},
+		"dotimes": dotimesFunction,
+		"dolist":  dolistFunction,
+		"while":   whileFunction,
+		"do":      doFunction,
+		"loop":    loopFunction,
+		"return":  returnFunction,
+		"define-condition": defineCondition,
+		"handler-case":     handlerCaseFunction,
+		"handler-bind":     handlerBindFunction,
+		"ignore-errors":    ignoreErrorsFunction,
+		"unwind-protect":   unwindProtectFunction,
+		"assert":           assertFunction,
+		"defvar": func(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+			return defineVariable("defvar", arguments, span, context, false)
+		},
+		"defparameter": func(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+			return defineVariable("defparameter", arguments, span, context, true)
+		},
+		"defconstant":      defconstantFunction,
+		"defstruct":        defineStructure,
+		"defgeneric":       defgenericFunction,
+		"defmethod":        defmethodFunction,
+		"call-next-method": callNextMethodFunction,
+		"next-method-p":    nextMethodPFunction,
+		"defpackage":       defpackageFunction,
+		"in-package":       inPackageFunction,
 	}
-
-	return UnsuccessfulEvaluationResult{}
 }
 
+// Evaluate evaluates an expression in a fresh interpreter.
 func Evaluate(expression Expression) EvaluationResult {
-	context := EvaluationContext{
-		variables: make(map[string]Expression),
-		functions: make(map[string]FunctionDeclaration),
-	}
-	return expression.Evaluate(context)
+	return NewInterpreter(InterpreterOptions{}).EvalExpr(expression)
 }
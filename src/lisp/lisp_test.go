@@ -0,0 +1,73 @@
+package lisp
+
+import (
+	"strings"
+	"testing"
+)
+
+// evalTest is a program evaluated in a fresh interpreter. The test expects
+// the printed value of its last expression, or when wantError is set, an
+// error of that condition type whose message contains want.
+type evalTest struct {
+	name      string
+	source    string
+	want      string
+	wantError string
+}
+
+func runEvalTests(t *testing.T, tests []evalTest) {
+	t.Helper()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			interpreter := NewInterpreter(InterpreterOptions{SearchPath: []string{}})
+			checkResult(t, test, interpreter.Eval(test.source))
+		})
+	}
+}
+
+func checkResult(t *testing.T, test evalTest, result EvaluationResult) {
+	t.Helper()
+	switch r := result.(type) {
+	case SuccessfulEvaluationResult:
+		if test.wantError != "" {
+			t.Fatalf("%s = %s, want a %s error", test.source, r.Expression.Print(), test.wantError)
+		}
+		if got := r.Expression.Print(); got != test.want {
+			t.Errorf("%s = %s, want %s", test.source, got, test.want)
+		}
+	case UnsuccessfulEvaluationResult:
+		if test.wantError == "" {
+			t.Fatalf("%s failed: %s", test.source, r.Error.Report())
+		}
+		if r.Error.TypeName() != test.wantError || !strings.Contains(r.Error.Message, test.want) {
+			t.Errorf("%s failed with %s, want a %s error containing %q", test.source, r.Error, test.wantError, test.want)
+		}
+	default:
+		t.Fatalf("%s returned %#v", test.source, result)
+	}
+}
+
+func TestCodeIsData(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "a quoted form is a list", source: "(car '(+ 1 2))", want: "+"},
+		{name: "the head is a symbol", source: "(symbolp (car '(f x)))", want: "T"},
+		{name: "the arguments are data", source: "(cdr '(+ 1 (* 2 3)))", want: "(1 (* 2 3))"},
+		{name: "eval of a built list", source: "(eval (list '+ 1 2))", want: "3"},
+		{name: "eval of a rewritten form", source: "(setq form '(+ 1 2)) (eval (cons '* (cdr form)))", want: "2"},
+		{name: "eval as a function", source: "(mapcar #'eval '((+ 1 2) (list 3)))", want: "(3 (3))"},
+		{name: "apply of eval", source: "(apply #'eval '((* 2 3)))", want: "6"},
+		{name: "eval ignores lexical variables", source: "(setq x :global) (let ((x :local)) (eval 'x))", want: ":global"},
+		{name: "a lambda form as the head", source: "((lambda (x) (* x x)) 3)", want: "9"},
+		{name: "nested calls", source: "(+ (* 2 3) (- 10 4))", want: "12"},
+		{name: "the empty list", source: "()", want: "NIL"},
+		{name: "quoted forms print as read", source: "'(if (> x 0) \"yes\" :no)", want: "(if (> x 0) yes :no)"},
+
+		{name: "a number as the head", source: "(1 2)", want: "1 (int) is not a function name", wantError: "invalid-form"},
+		{name: "a list as the head", source: "((car '(f)) 1)", want: "is not a function name", wantError: "invalid-form"},
+		{name: "a dotted form", source: "(eval '(+ 1 . 2))", want: "cannot evaluate the dotted list", wantError: "invalid-form"},
+		{name: "an undefined function", source: "(eval '(g 1))", want: "the function g is undefined", wantError: "undefined-function"},
+		{name: "if arity", source: "(if)", want: "if expects", wantError: "arity-mismatch"},
+		{name: "setq of a number", source: "(setq 1 2)", want: "setq cannot assign 1", wantError: "invalid-form"},
+		{name: "eval arity", source: "(eval 1 2)", want: "eval expects 1 argument but got 2", wantError: "arity-mismatch"},
+	})
+}
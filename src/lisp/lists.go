@@ -0,0 +1,698 @@
+package lisp
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// List builtins. The functions taking a list accept NIL as the empty list,
+// and report an error for a dotted list unless they only walk part of it.
+
+func expectList(functionName string, argument Expression) ([]Expression, EvaluationResult) {
+	elements, tail, ok := splitList(argument)
+	if !ok {
+		return nil, newEvaluationError(TypeMismatchError, Span{}, "%s expects a list but got a circular list", functionName)
+	}
+	if !isNil(tail) {
+		return nil, newEvaluationError(TypeMismatchError, Span{}, "%s expects a list but got %s", functionName, describe(argument))
+	}
+	return elements, nil
+}
+
+// callFunction calls a function given to a builtin and returns its value.
+func callFunction(function Expression, arguments ...Expression) (Expression, EvaluationResult) {
+	result := applyFunction(function, arguments, Span{})
+	if !result.IsSuccessful() {
+		return nil, result
+	}
+	return result.(SuccessfulEvaluationResult).Expression, nil
+}
+
+// keywordArguments splits the arguments of a builtin into its positional
+// arguments, of which there are count, and its keyword arguments, which
+// must be among allowed.
+func keywordArguments(functionName string, arguments []Expression, count int, allowed ...string) ([]Expression, map[string]Expression, EvaluationResult) {
+	if len(arguments) < count {
+		return nil, nil, newEvaluationError(ArityMismatchError, Span{}, "%s expects at least %s but got %d", functionName, countArguments(count), len(arguments))
+	}
+
+	keywords := map[string]Expression{}
+	rest := arguments[count:]
+
+	if len(rest)%2 != 0 {
+		return nil, nil, newEvaluationError(ArityMismatchError, Span{}, "%s expects keyword arguments in pairs", functionName)
+	}
+
+	for i := 0; i < len(rest); i += 2 {
+		known := false
+		for _, name := range allowed {
+			if isKeywordSymbol(rest[i]) && rest[i].(Symbol).Name() == name {
+				known = true
+			}
+		}
+		if !known {
+			return nil, nil, newEvaluationError(ArityMismatchError, Span{}, "%s does not accept the keyword argument %s, only %s", functionName, rest[i].Print(), strings.Join(allowed, " "))
+		}
+		if _, ok := keywords[rest[i].(Symbol).Name()]; !ok {
+			keywords[rest[i].(Symbol).Name()] = rest[i+1]
+		}
+	}
+
+	return arguments[:count], keywords, nil
+}
+
+// applyKey returns the value of the :key function for an element, or the
+// element itself without :key.
+func applyKey(keywords map[string]Expression, element Expression) (Expression, EvaluationResult) {
+	key, ok := keywords[":key"]
+	if !ok || isNil(key) {
+		return element, nil
+	}
+	return callFunction(key, element)
+}
+
+// matches compares an item to an element of a sequence, with the :test
+// function if any and eql otherwise.
+func matches(keywords map[string]Expression, item Expression, element Expression) (bool, EvaluationResult) {
+	value, failure := applyKey(keywords, element)
+	if failure != nil {
+		return false, failure
+	}
+
+	test, ok := keywords[":test"]
+	if !ok {
+		return isEql(item, value), nil
+	}
+
+	result, failure := callFunction(test, item, value)
+	if failure != nil {
+		return false, failure
+	}
+	return isTrue(result), nil
+}
+
+func list(arguments []Expression) EvaluationResult {
+	return SuccessfulEvaluationResult{
+		Expression: makeListFromSlice(arguments, Span{}),
+	}
+}
+
+// length returns the number of elements of a list or of a vector, or of
+// characters of a string.
+func length(arguments []Expression) EvaluationResult {
+	if failure := checkArity("length", arguments, 1); failure != nil {
+		return failure
+	}
+
+	if value, ok := arguments[0].(String); ok {
+		return SuccessfulEvaluationResult{
+			Expression: Int{Value: utf8.RuneCountInString(value.Value)},
+		}
+	}
+	if vector, ok := arguments[0].(*Vector); ok {
+		return SuccessfulEvaluationResult{
+			Expression: Int{Value: len(vector.elements)},
+		}
+	}
+
+	elements, failure := expectList("length", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: Int{Value: len(elements)},
+	}
+}
+
+// appendLists copies every list but the last, which the result shares.
+func appendLists(arguments []Expression) EvaluationResult {
+	if len(arguments) == 0 {
+		return SuccessfulEvaluationResult{
+			Expression: Boolean{Value: false},
+		}
+	}
+
+	elements := []Expression{}
+	for _, argument := range arguments[:len(arguments)-1] {
+		listElements, failure := expectList("append", argument)
+		if failure != nil {
+			return failure
+		}
+		elements = append(elements, listElements...)
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: makeDottedList(elements, arguments[len(arguments)-1], Span{}),
+	}
+}
+
+func reverse(arguments []Expression) EvaluationResult {
+	if failure := checkArity("reverse", arguments, 1); failure != nil {
+		return failure
+	}
+
+	if value, ok := arguments[0].(String); ok {
+		runes := []rune(value.Value)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return SuccessfulEvaluationResult{
+			Expression: String{Value: string(runes)},
+		}
+	}
+
+	elements, failure := expectList("reverse", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	reversed := make([]Expression, len(elements))
+	for i, element := range elements {
+		reversed[len(elements)-1-i] = element
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: makeListFromSlice(reversed, Span{}),
+	}
+}
+
+// nthcdrOf returns the list following the first n elements of a list.
+func nthcdrOf(functionName string, n int, expression Expression) (Expression, EvaluationResult) {
+	for ; n > 0; n-- {
+		if isNil(expression) {
+			return expression, nil
+		}
+		cons, ok := expression.(*List)
+		if !ok {
+			return nil, newEvaluationError(TypeMismatchError, Span{}, "%s expects a list but got %s", functionName, describe(expression))
+		}
+		expression = cons.right
+	}
+	return expression, nil
+}
+
+func nthcdr(arguments []Expression) EvaluationResult {
+	if failure := checkArity("nthcdr", arguments, 2); failure != nil {
+		return failure
+	}
+
+	n, failure := expectIndex("nthcdr", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	result, failure := nthcdrOf("nthcdr", n, arguments[1])
+	if failure != nil {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: result,
+	}
+}
+
+// nth returns the element of a list at an index, or NIL past its end.
+func nth(arguments []Expression) EvaluationResult {
+	if failure := checkArity("nth", arguments, 2); failure != nil {
+		return failure
+	}
+
+	n, failure := expectIndex("nth", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	rest, failure := nthcdrOf("nth", n, arguments[1])
+	if failure != nil {
+		return failure
+	}
+
+	return headList([]Expression{rest})
+}
+
+// last returns the list of the last n elements of a list, the last one by
+// default.
+func last(arguments []Expression) EvaluationResult {
+	if failure := checkArityRange("last", arguments, 1, 2); failure != nil {
+		return failure
+	}
+
+	n := 1
+	if len(arguments) == 2 {
+		var failure EvaluationResult
+		n, failure = expectIndex("last", arguments[1])
+		if failure != nil {
+			return failure
+		}
+	}
+
+	elements, _, ok := splitList(arguments[0])
+	if !ok {
+		return newEvaluationError(TypeMismatchError, Span{}, "last expects a list but got a circular list")
+	}
+
+	count := len(elements)
+	if count < n {
+		n = count
+	}
+
+	result, failure := nthcdrOf("last", count-n, arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: result,
+	}
+}
+
+// mapLists calls function with the first elements of the lists, then with
+// the second ones, and so on until the shortest list is exhausted.
+func mapLists(functionName string, arguments []Expression) ([]Expression, EvaluationResult) {
+	if len(arguments) < 2 {
+		return nil, newEvaluationError(ArityMismatchError, Span{}, "%s expects a function and at least 1 list but got %s", functionName, countArguments(len(arguments)))
+	}
+
+	lists := [][]Expression{}
+	shortest := -1
+	for _, argument := range arguments[1:] {
+		elements, failure := expectList(functionName, argument)
+		if failure != nil {
+			return nil, failure
+		}
+		lists = append(lists, elements)
+		if shortest < 0 || len(elements) < shortest {
+			shortest = len(elements)
+		}
+	}
+
+	results := []Expression{}
+	for i := 0; i < shortest; i++ {
+		callArguments := []Expression{}
+		for _, elements := range lists {
+			callArguments = append(callArguments, elements[i])
+		}
+
+		result, failure := callFunction(arguments[0], callArguments...)
+		if failure != nil {
+			return nil, failure
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func mapcar(arguments []Expression) EvaluationResult {
+	results, failure := mapLists("mapcar", arguments)
+	if failure != nil {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: makeListFromSlice(results, Span{}),
+	}
+}
+
+// mapc is mapcar for side effects: it returns its first list.
+func mapc(arguments []Expression) EvaluationResult {
+	if _, failure := mapLists("mapc", arguments); failure != nil {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: arguments[1],
+	}
+}
+
+// reduce implements (reduce function list [:initial-value value]), which
+// combines the elements of list from the left with function.
+func reduce(arguments []Expression) EvaluationResult {
+	positional, keywords, failure := keywordArguments("reduce", arguments, 2, ":initial-value")
+	if failure != nil {
+		return failure
+	}
+
+	elements, failure := expectList("reduce", positional[1])
+	if failure != nil {
+		return failure
+	}
+
+	if initialValue, ok := keywords[":initial-value"]; ok {
+		elements = append([]Expression{initialValue}, elements...)
+	}
+
+	if len(elements) == 0 {
+		return applyFunction(positional[0], nil, Span{})
+	}
+
+	result := elements[0]
+	for _, element := range elements[1:] {
+		result, failure = callFunction(positional[0], result, element)
+		if failure != nil {
+			return failure
+		}
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: result,
+	}
+}
+
+// removeIf implements remove-if, which removes the elements satisfying a
+// predicate from a list, and remove-if-not, which keeps them.
+func removeIf(functionName string, keep bool) func(arguments []Expression) EvaluationResult {
+	return func(arguments []Expression) EvaluationResult {
+		positional, keywords, failure := keywordArguments(functionName, arguments, 2, ":key")
+		if failure != nil {
+			return failure
+		}
+
+		elements, failure := expectList(functionName, positional[1])
+		if failure != nil {
+			return failure
+		}
+
+		results := []Expression{}
+		for _, element := range elements {
+			value, failure := applyKey(keywords, element)
+			if failure != nil {
+				return failure
+			}
+			satisfied, failure := callFunction(positional[0], value)
+			if failure != nil {
+				return failure
+			}
+			if isTrue(satisfied) == keep {
+				results = append(results, element)
+			}
+		}
+
+		return SuccessfulEvaluationResult{
+			Expression: makeListFromSlice(results, Span{}),
+		}
+	}
+}
+
+// findIndex returns the index of the first element of a list matching an
+// item, or -1.
+func findIndex(functionName string, arguments []Expression) ([]Expression, int, EvaluationResult) {
+	positional, keywords, failure := keywordArguments(functionName, arguments, 2, ":test", ":key")
+	if failure != nil {
+		return nil, 0, failure
+	}
+
+	elements, failure := expectList(functionName, positional[1])
+	if failure != nil {
+		return nil, 0, failure
+	}
+
+	for i, element := range elements {
+		matched, failure := matches(keywords, positional[0], element)
+		if failure != nil {
+			return nil, 0, failure
+		}
+		if matched {
+			return elements, i, nil
+		}
+	}
+
+	return elements, -1, nil
+}
+
+func find(arguments []Expression) EvaluationResult {
+	elements, index, failure := findIndex("find", arguments)
+	if failure != nil {
+		return failure
+	}
+
+	if index < 0 {
+		return SuccessfulEvaluationResult{
+			Expression: Boolean{Value: false},
+		}
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: elements[index],
+	}
+}
+
+func position(arguments []Expression) EvaluationResult {
+	_, index, failure := findIndex("position", arguments)
+	if failure != nil {
+		return failure
+	}
+
+	if index < 0 {
+		return SuccessfulEvaluationResult{
+			Expression: Boolean{Value: false},
+		}
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: Int{Value: index},
+	}
+}
+
+// member returns the tail of a list starting with the first element
+// matching an item, or NIL.
+func member(arguments []Expression) EvaluationResult {
+	_, index, failure := findIndex("member", arguments)
+	if failure != nil {
+		return failure
+	}
+
+	if index < 0 {
+		return SuccessfulEvaluationResult{
+			Expression: Boolean{Value: false},
+		}
+	}
+
+	tail, failure := nthcdrOf("member", index, arguments[1])
+	if failure != nil {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: tail,
+	}
+}
+
+// every returns NIL as soon as the predicate is false for the elements of
+// the lists at an index, and T otherwise. some returns the first true
+// value of the predicate, or NIL.
+func everyOrSome(functionName string, isEvery bool) func(arguments []Expression) EvaluationResult {
+	return func(arguments []Expression) EvaluationResult {
+		if len(arguments) < 2 {
+			return newEvaluationError(ArityMismatchError, Span{}, "%s expects a predicate and at least 1 list but got %s", functionName, countArguments(len(arguments)))
+		}
+
+		lists := [][]Expression{}
+		shortest := -1
+		for _, argument := range arguments[1:] {
+			elements, failure := expectList(functionName, argument)
+			if failure != nil {
+				return failure
+			}
+			lists = append(lists, elements)
+			if shortest < 0 || len(elements) < shortest {
+				shortest = len(elements)
+			}
+		}
+
+		for i := 0; i < shortest; i++ {
+			callArguments := []Expression{}
+			for _, elements := range lists {
+				callArguments = append(callArguments, elements[i])
+			}
+
+			result, failure := callFunction(arguments[0], callArguments...)
+			if failure != nil {
+				return failure
+			}
+			if isTrue(result) != isEvery {
+				if isEvery {
+					return SuccessfulEvaluationResult{Expression: Boolean{Value: false}}
+				}
+				return SuccessfulEvaluationResult{Expression: result}
+			}
+		}
+
+		return SuccessfulEvaluationResult{
+			Expression: Boolean{Value: isEvery},
+		}
+	}
+}
+
+// sortList implements (sort list predicate [:key function]), which returns
+// a new list ordered by predicate, keeping the order of equal elements.
+func sortList(arguments []Expression) EvaluationResult {
+	positional, keywords, failure := keywordArguments("sort", arguments, 2, ":key")
+	if failure != nil {
+		return failure
+	}
+
+	elements, failure := expectList("sort", positional[0])
+	if failure != nil {
+		return failure
+	}
+
+	keys := make([]Expression, len(elements))
+	for i, element := range elements {
+		keys[i], failure = applyKey(keywords, element)
+		if failure != nil {
+			return failure
+		}
+	}
+
+	indices := make([]int, len(elements))
+	for i := range indices {
+		indices[i] = i
+	}
+
+	var sortFailure EvaluationResult
+	sort.SliceStable(indices, func(i int, j int) bool {
+		if sortFailure != nil {
+			return false
+		}
+		result, failure := callFunction(positional[1], keys[indices[i]], keys[indices[j]])
+		if failure != nil {
+			sortFailure = failure
+			return false
+		}
+		return isTrue(result)
+	})
+	if sortFailure != nil {
+		return sortFailure
+	}
+
+	sorted := make([]Expression, len(elements))
+	for i, index := range indices {
+		sorted[i] = elements[index]
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: makeListFromSlice(sorted, Span{}),
+	}
+}
+
+// associate implements assoc, which finds the first pair of an association
+// list whose car matches an item, and rassoc, which matches the cdr.
+func associate(functionName string, matchRight bool) func(arguments []Expression) EvaluationResult {
+	return func(arguments []Expression) EvaluationResult {
+		positional, keywords, failure := keywordArguments(functionName, arguments, 2, ":test", ":key")
+		if failure != nil {
+			return failure
+		}
+
+		pairs, failure := expectList(functionName, positional[1])
+		if failure != nil {
+			return failure
+		}
+
+		for _, pair := range pairs {
+			if isNil(pair) {
+				continue
+			}
+			cons, ok := pair.(*List)
+			if !ok {
+				return newEvaluationError(TypeMismatchError, Span{}, "%s expects an association list but got the element %s", functionName, describe(pair))
+			}
+
+			element := cons.left
+			if matchRight {
+				element = cons.right
+			}
+
+			matched, failure := matches(keywords, positional[0], element)
+			if failure != nil {
+				return failure
+			}
+			if matched {
+				return SuccessfulEvaluationResult{
+					Expression: pair,
+				}
+			}
+		}
+
+		return SuccessfulEvaluationResult{
+			Expression: Boolean{Value: false},
+		}
+	}
+}
+
+// cxr implements the car and cdr compositions such as cadr, whose name
+// lists the operations to apply from right to left.
+func cxr(functionName string) func(arguments []Expression) EvaluationResult {
+	return func(arguments []Expression) EvaluationResult {
+		if failure := checkArity(functionName, arguments, 1); failure != nil {
+			return failure
+		}
+
+		result := arguments[0]
+		operations := functionName[1 : len(functionName)-1]
+
+		for i := len(operations) - 1; i >= 0; i-- {
+			if isNil(result) {
+				break
+			}
+			cons, ok := result.(*List)
+			if !ok {
+				return newEvaluationError(TypeMismatchError, Span{}, "%s expects a list but got %s", functionName, describe(arguments[0]))
+			}
+			if operations[i] == 'a' {
+				result = cons.left
+			} else {
+				result = cons.right
+			}
+		}
+
+		return SuccessfulEvaluationResult{
+			Expression: result,
+		}
+	}
+}
+
+// cxrNames returns the names of the compositions of two to four car and
+// cdr, from caar to cddddr.
+func cxrNames() []string {
+	names := []string{""}
+	result := []string{}
+
+	for depth := 1; depth <= 4; depth++ {
+		longer := []string{}
+		for _, name := range names {
+			longer = append(longer, name+"a", name+"d")
+		}
+		names = longer
+		if depth >= 2 {
+			for _, name := range names {
+				result = append(result, "c"+name+"r")
+			}
+		}
+	}
+	return result
+}
+
+// copyList copies the conses of a list, keeping its elements and its
+// final cdr.
+func copyList(arguments []Expression) EvaluationResult {
+	if failure := checkArity("copy-list", arguments, 1); failure != nil {
+		return failure
+	}
+
+	if !isNil(arguments[0]) && arguments[0].GetType() != "list" {
+		return newEvaluationError(TypeMismatchError, Span{}, "copy-list expects a list but got %s", describe(arguments[0]))
+	}
+
+	elements, rest, ok := splitList(arguments[0])
+	if !ok {
+		return newEvaluationError(TypeMismatchError, Span{}, "copy-list expects a list but got a circular list")
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: makeDottedList(elements, rest, Span{}),
+	}
+}
@@ -0,0 +1,54 @@
+package lisp
+
+import (
+	"testing"
+)
+
+func TestLists(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "length", source: "(list (length NIL) (length '(1 2 3)) (length \"abc\") (length (vector 1 2)))", want: "(0 3 3 2)"},
+		{name: "append", source: "(append '(1) NIL '(2 3) '(4))", want: "(1 2 3 4)"},
+		{name: "append shares the last list", source: "(setq tail (list 3)) (eq (cdr (cdr (append '(1 2) tail))) tail)", want: "T"},
+		{name: "append a dotted end", source: "(append '(1) 2)", want: "(1 . 2)"},
+		{name: "reverse", source: "(reverse '(1 2 3))", want: "(3 2 1)"},
+		{name: "nth", source: "(list (nth 0 '(a b)) (nth 1 '(a b)) (nth 5 '(a b)))", want: "(a b NIL)"},
+		{name: "nthcdr", source: "(nthcdr 2 '(a b c d))", want: "(c d)"},
+		{name: "last", source: "(list (last '(1 2 3)) (last '(1 2 3) 2) (last NIL))", want: "((3) (2 3) NIL)"},
+		{name: "cadr and friends", source: "(list (cadr '(1 2 3)) (cddr '(1 2 3)) (caar '((1) 2)) (cadddr '(1 2 3 4)))", want: "(2 (3) 1 4)"},
+		{name: "mapcar", source: "(mapcar #'1+ '(1 2 3))", want: "(2 3 4)"},
+		{name: "mapcar over several lists", source: "(mapcar #'+ '(1 2 3) '(10 20))", want: "(11 22)"},
+		{name: "mapc", source: "(setq sum 0) (list (mapc (lambda (x) (setq sum (+ sum x))) '(1 2 3)) sum)", want: "((1 2 3) 6)"},
+		{name: "reduce", source: "(list (reduce #'+ '(1 2 3 4)) (reduce #'+ NIL) (reduce #'list '(1 2 3) :initial-value 0))", want: "(10 0 (((0 1) 2) 3))"},
+		{name: "remove-if", source: "(list (remove-if #'evenp '(1 2 3 4)) (remove-if-not #'evenp '(1 2 3 4)))", want: "((1 3) (2 4))"},
+		{name: "remove-if with a key", source: "(remove-if #'evenp '((1) (2)) :key #'car)", want: "((1))"},
+		{name: "find and position", source: "(list (find 2 '(1 2 3)) (position 'c '(a b c)) (find 9 '(1 2)) (position 9 '(1 2)))", want: "(2 2 NIL NIL)"},
+		{name: "find with a test and a key", source: "(find \"b\" '((\"a\" 1) (\"b\" 2)) :test #'string= :key #'car)", want: "(b 2)"},
+		{name: "member", source: "(list (member 2 '(1 2 3)) (member 9 '(1 2 3)))", want: "((2 3) NIL)"},
+		{name: "member with equal", source: "(member '(1) '((0) (1) (2)) :test #'equal)", want: "((1) (2))"},
+		{name: "every and some", source: "(list (every #'evenp '(2 4)) (every #'evenp '(2 3)) (some #'evenp '(1 2)) (some #'evenp '(1 3)))", want: "(T NIL T NIL)"},
+		{name: "some returns the value", source: "(some (lambda (x) (and (> x 1) (* x 10))) '(1 2 3))", want: "20"},
+		{name: "sort", source: "(sort '(3 1 2) #'<)", want: "(1 2 3)"},
+		{name: "sort is stable", source: "(sort '((1 a) (0 b) (1 c) (0 d)) #'< :key #'car)", want: "((0 b) (0 d) (1 a) (1 c))"},
+		{name: "sort does not change its argument", source: "(setq xs (list 3 1 2)) (sort xs #'<) xs", want: "(3 1 2)"},
+		{name: "assoc", source: "(list (assoc 'b '((a . 1) (b . 2))) (assoc 'z '((a . 1))))", want: "((b . 2) NIL)"},
+		{name: "assoc skips NIL", source: "(assoc 'b '(NIL (b . 2)))", want: "(b . 2)"},
+		{name: "rassoc", source: "(rassoc 2 '((a . 1) (b . 2)))", want: "(b . 2)"},
+		{name: "copy-list", source: "(setq xs (list 1 2)) (setq ys (copy-list xs)) (list (equal xs ys) (eq xs ys))", want: "(T NIL)"},
+
+		{name: "length of a number", source: "(length 5)", want: "length expects a list but got 5", wantError: "type-error"},
+		{name: "length of a dotted list", source: "(length '(1 . 2))", want: "length expects a list but got (1 . 2)", wantError: "type-error"},
+		{name: "reverse of a dotted list", source: "(reverse '(1 2 . 3))", wantError: "type-error"},
+		{name: "append of a number", source: "(append 1 '(2))", want: "append expects a list but got 1", wantError: "type-error"},
+		{name: "nth with a negative index", source: "(nth -1 '(a))", want: "nth expects a non negative int", wantError: "type-error"},
+		{name: "mapcar without lists", source: "(mapcar #'1+)", want: "mapcar expects a function and at least 1 list", wantError: "arity-mismatch"},
+		{name: "mapcar of a non function", source: "(mapcar 1 '(1))", want: "is not a function", wantError: "type-error"},
+		{name: "an error in the mapped function", source: "(mapcar #'car '(1))", want: "car expects a list but got 1", wantError: "type-error"},
+		{name: "every without lists", source: "(every #'evenp)", want: "every expects a predicate and at least 1 list", wantError: "arity-mismatch"},
+		{name: "reduce with an unknown keyword", source: "(reduce #'+ '(1) :from-end T)", want: "reduce does not accept the keyword argument :from-end, only :initial-value", wantError: "arity-mismatch"},
+		{name: "find with an odd keyword", source: "(find 1 '(1) :test)", want: "find expects keyword arguments in pairs", wantError: "arity-mismatch"},
+		{name: "sort without a predicate", source: "(sort '(1))", want: "sort expects at least 2 arguments but got 1", wantError: "arity-mismatch"},
+		{name: "assoc of a bad alist", source: "(assoc 'a '(1))", want: "assoc expects an association list but got the element 1", wantError: "type-error"},
+		{name: "cadr of a number", source: "(cadr 1)", want: "cadr expects a list but got 1", wantError: "type-error"},
+		{name: "copy-list of a string", source: "(copy-list \"a\")", want: "copy-list expects a list", wantError: "type-error"},
+	})
+}
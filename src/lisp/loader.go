@@ -0,0 +1,246 @@
+package lisp
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Programs may be split across files: (load "file") evaluates the forms of
+// a file at top level, and (require 'module) loads module.lisp unless the
+// module was already provided, the file being expected to (provide
+// 'module). Relative file names are searched in the directory of the file
+// being loaded, then in the directories of the search path, which defaults
+// to the GOLISP_PATH environment variable, or to the current directory when
+// it is not set. The package selected by in-package is restored after a
+// file is loaded.
+
+type loader struct {
+	searchPath []string
+	disabled   bool
+	provided   map[string]bool
+	// loading lists the absolute names of the files being loaded,
+	// outermost first.
+	loading []string
+}
+
+func defaultSearchPath() []string {
+	if value := os.Getenv("GOLISP_PATH"); value != "" {
+		return filepath.SplitList(value)
+	}
+	return []string{"."}
+}
+
+func newLoader() *loader {
+	return &loader{
+		searchPath: defaultSearchPath(),
+		provided:   make(map[string]bool),
+	}
+}
+
+// resolve returns the absolute name of the file designated by a name,
+// trying name.lisp as well when the name has no extension.
+func (l *loader) resolve(name string) (string, bool) {
+	names := []string{name}
+	if filepath.Ext(name) == "" {
+		names = append(names, name+".lisp")
+	}
+
+	directories := []string{""}
+	if !filepath.IsAbs(name) {
+		directories = []string{}
+		if len(l.loading) > 0 {
+			directories = append(directories, filepath.Dir(l.loading[len(l.loading)-1]))
+		}
+		directories = append(directories, l.searchPath...)
+	}
+
+	for _, directory := range directories {
+		for _, candidate := range names {
+			path := filepath.Join(directory, candidate)
+			if info, err := os.Stat(path); err != nil || info.IsDir() {
+				continue
+			}
+			if absolute, err := filepath.Abs(path); err == nil {
+				return absolute, true
+			}
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// checkEnabled fails when the interpreter does not let Lisp code load files.
+func (l *loader) checkEnabled(formName string, span Span) EvaluationResult {
+	if l.disabled {
+		return newEvaluationError(FileError, span, "%s is disabled in this interpreter", formName)
+	}
+	return nil
+}
+
+// load evaluates the forms of a file in the global environment. The span
+// is the one of the form loading the file, added to the backtrace of
+// errors.
+func (l *loader) load(formName string, file string, span Span, context EvaluationContext) EvaluationResult {
+	for i, loading := range l.loading {
+		if loading == file {
+			chain := append(append([]string{}, l.loading[i:]...), file)
+			return newEvaluationError(FileError, span, "%s cannot load %s, which is already being loaded: %s", formName, file, strings.Join(chain, " -> "))
+		}
+	}
+
+	source, err := os.ReadFile(file)
+	if err != nil {
+		return newEvaluationError(FileError, span, "%s cannot read %s: %s", formName, file, err)
+	}
+
+	global := context.Global()
+	current := global.packages.current
+	l.loading = append(l.loading, file)
+	defer func() {
+		l.loading = l.loading[:len(l.loading)-1]
+		global.packages.current = current
+	}()
+
+	result := evaluateSource(NewFileReader(string(source), file), global)
+	if failure, ok := result.(UnsuccessfulEvaluationResult); ok {
+		failure.Error.pushFrame(formName, span)
+		return failure
+	}
+	return SuccessfulEvaluationResult{
+		Expression: Boolean{Value: true},
+	}
+}
+
+// evaluateSource reads and evaluates every expression of a reader in turn,
+// and returns the result of the last one. Reading stops at the first error.
+// The reader uses the dispatch macros defined in the context.
+func evaluateSource(reader *Reader, context EvaluationContext) EvaluationResult {
+	reader.readtable = context.Global().readtable
+
+	var result EvaluationResult = SuccessfulEvaluationResult{
+		Expression: Boolean{Value: false},
+	}
+
+	for {
+		expression, err := reader.Read()
+		if err == io.EOF {
+			return result
+		}
+		if err != nil {
+			if syntaxError, ok := err.(SyntaxError); ok {
+				return newEvaluationError(ReaderError, syntaxError.Span, "%s", syntaxError.Message)
+			}
+			return newEvaluationError(ReaderError, Span{}, "%s", err)
+		}
+
+		result = evaluateTopLevel(expression, context)
+		if !result.IsSuccessful() {
+			return result
+		}
+	}
+}
+
+// evaluateTopLevel evaluates an expression read at top level, converting
+// Go panics and stray returns into errors.
+func evaluateTopLevel(expression Expression, context EvaluationContext) (result EvaluationResult) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result = recoverEvaluationError(recovered, expression.GetSpan())
+		}
+	}()
+
+	result = expression.Evaluate(context)
+	if rr, ok := result.(returnResult); ok {
+		return newEvaluationError(InvalidFormError, rr.span, "return is only allowed inside a loop")
+	}
+	return result
+}
+
+// loadFunction implements (load filename).
+func loadFunction(arguments []Expression, global EvaluationContext) EvaluationResult {
+	if len(arguments) != 1 {
+		return newEvaluationError(ArityMismatchError, Span{}, "load expects 1 argument but got %d", len(arguments))
+	}
+
+	l := global.Global().loader
+	if failure := l.checkEnabled("load", Span{}); failure != nil {
+		return failure
+	}
+
+	name, ok := arguments[0].(String)
+	if !ok {
+		return newEvaluationError(TypeMismatchError, Span{}, "load expects a file name but got %s", describe(arguments[0]))
+	}
+
+	file, ok := l.resolve(name.Value)
+	if !ok {
+		return newEvaluationError(FileError, Span{}, "load cannot find the file %s in %s", name.Value, strings.Join(l.searchPath, string(filepath.ListSeparator)))
+	}
+	return l.load("load", file, Span{}, global)
+}
+
+// requireFunction implements (require module [filename]), which loads
+// the file of a module unless the module was already provided. The module
+// counts as provided once its file is loaded, even without provide.
+func requireFunction(arguments []Expression, global EvaluationContext) EvaluationResult {
+	if len(arguments) < 1 || len(arguments) > 2 {
+		return newEvaluationError(ArityMismatchError, Span{}, "require expects between 1 and 2 arguments but got %d", len(arguments))
+	}
+
+	l := global.Global().loader
+	if failure := l.checkEnabled("require", Span{}); failure != nil {
+		return failure
+	}
+
+	module, ok := designatorName(arguments[0])
+	if !ok {
+		return newEvaluationError(TypeMismatchError, Span{}, "require expects a module name but got %s", describe(arguments[0]))
+	}
+
+	if l.provided[module] {
+		return SuccessfulEvaluationResult{
+			Expression: Boolean{Value: false},
+		}
+	}
+
+	name := module
+	if len(arguments) == 2 {
+		filename, ok := arguments[1].(String)
+		if !ok {
+			return newEvaluationError(TypeMismatchError, Span{}, "require expects a file name but got %s", describe(arguments[1]))
+		}
+		name = filename.Value
+	}
+	file, ok := l.resolve(name)
+	if !ok {
+		return newEvaluationError(FileError, Span{}, "require cannot find the module %s in %s", module, strings.Join(l.searchPath, string(filepath.ListSeparator)))
+	}
+
+	if result := l.load("require", file, Span{}, global); !result.IsSuccessful() {
+		return result
+	}
+	l.provided[module] = true
+
+	return SuccessfulEvaluationResult{
+		Expression: Boolean{Value: true},
+	}
+}
+
+// provideFunction implements (provide module).
+func provideFunction(arguments []Expression, global EvaluationContext) EvaluationResult {
+	if len(arguments) != 1 {
+		return newEvaluationError(ArityMismatchError, Span{}, "provide expects 1 argument but got %d", len(arguments))
+	}
+
+	module, ok := designatorName(arguments[0])
+	if !ok {
+		return newEvaluationError(TypeMismatchError, Span{}, "provide expects a module name but got %s", describe(arguments[0]))
+	}
+	global.Global().loader.provided[module] = true
+
+	return SuccessfulEvaluationResult{
+		Expression: arguments[0],
+	}
+}
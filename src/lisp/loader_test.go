@@ -0,0 +1,119 @@
+package lisp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes Lisp files in a temporary directory, which it returns.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	directory := t.TempDir()
+	for name, source := range files {
+		file := filepath.Join(directory, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return directory
+}
+
+func TestLoad(t *testing.T) {
+	directory := writeFiles(t, map[string]string{
+		"util.lisp":        "(defun double (x) (* 2 x)) (provide 'util)",
+		"counter.lisp":     "(setq loads (+ loads 1))",
+		"lib/shapes.lisp":  "(load \"helpers\") (defun area (side) (square side))",
+		"lib/helpers.lisp": "(defun square (x) (* x x))",
+		"package.lisp":     "(defpackage :inner) (in-package :inner) (defun f () :inner)",
+		"value.lisp":       "1 2 :last",
+		"first.lisp":       "(load \"second\")",
+		"second.lisp":      "(load \"first\")",
+		"broken.lisp":      "(defun f ()\n  (car 1))\n(f)",
+		"unreadable.lisp":  "(defun f (",
+		"data.txt":         "(setq data :text)",
+	})
+
+	tests := []evalTest{
+		{name: "load", source: "(load \"util\") (double 4)", want: "8"},
+		{name: "load with the extension", source: "(load \"util.lisp\") (double 4)", want: "8"},
+		{name: "load of another extension", source: "(load \"data.txt\") data", want: ":text"},
+		{name: "load returns T", source: "(load \"value\")", want: "T"},
+		{name: "load an absolute file", source: "(load \"" + filepath.Join(directory, "util.lisp") + "\") (double 1)", want: "2"},
+		{name: "load relative to the loading file", source: "(load \"lib/shapes\") (area 3)", want: "9"},
+		{name: "load again", source: "(setq loads 0) (load \"counter\") (load \"counter\") loads", want: "2"},
+		{name: "require", source: "(require 'util) (double 5)", want: "10"},
+		{name: "require loads once", source: "(setq loads 0) (require 'counter) (require 'counter) loads", want: "1"},
+		{name: "require after provide", source: "(setq loads 0) (provide 'counter) (require 'counter) loads", want: "0"},
+		{name: "require with a file name", source: "(require 'geometry \"lib/shapes\") (area 2)", want: "4"},
+		{name: "load as a function", source: "(setq loads 0) (mapc #'load '(\"util\" \"counter\")) (list (double 2) loads)", want: "(4 1)"},
+		{name: "require and provide as functions", source: "(setq loads 0) (funcall #'provide 'counter) (apply #'require '(counter)) loads", want: "0"},
+		{name: "load restores the package", source: "(load \"package\") (list (inner::f) (symbol-name 'f) (eq 'f 'inner::f))", want: "(:inner f NIL)"},
+
+		{name: "a missing file", source: "(load \"missing\")", want: "load cannot find the file missing in " + directory, wantError: "file-error"},
+		{name: "a missing module", source: "(require 'missing)", want: "require cannot find the module missing", wantError: "file-error"},
+		{name: "a load cycle", source: "(load \"first\")", want: "which is already being loaded", wantError: "file-error"},
+		{name: "an error in a loaded file", source: "(load \"broken\")", want: "car expects a list but got 1", wantError: "type-error"},
+		{name: "a syntax error in a loaded file", source: "(load \"unreadable\")", want: "unterminated list", wantError: "reader-error"},
+		{name: "load of a number", source: "(load 1)", want: "load expects a file name but got 1", wantError: "type-error"},
+		{name: "load arity", source: "(load)", want: "load expects 1 argument but got 0", wantError: "arity-mismatch"},
+		{name: "require of a number", source: "(require 1)", want: "require expects a module name but got 1", wantError: "type-error"},
+		{name: "require with a bad file name", source: "(require 'util 1)", want: "require expects a file name but got 1", wantError: "type-error"},
+		{name: "require arity", source: "(require)", want: "require expects between 1 and 2 arguments but got 0", wantError: "arity-mismatch"},
+		{name: "provide of a number", source: "(provide 1)", want: "provide expects a module name", wantError: "type-error"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			interpreter := NewInterpreter(InterpreterOptions{SearchPath: []string{directory}})
+			checkResult(t, test, interpreter.Eval(test.source))
+		})
+	}
+}
+
+func TestLoadErrorLocations(t *testing.T) {
+	directory := writeFiles(t, map[string]string{
+		"broken.lisp":     "(defun f ()\n  (car 1))\n(f)",
+		"unreadable.lisp": "\n(defun f (",
+	})
+	interpreter := NewInterpreter(InterpreterOptions{SearchPath: []string{directory}})
+
+	failure := interpreter.Eval("(load \"broken\")").(UnsuccessfulEvaluationResult).Error
+	if got := failure.Span.String(); got != filepath.Join(directory, "broken.lisp")+":2:3" {
+		t.Errorf("the error is located at %s, want broken.lisp:2:3", got)
+	}
+	if report := failure.Report(); !strings.Contains(report, "in (load ...) at 1:1") {
+		t.Errorf("the report does not show the call to load:\n%s", report)
+	}
+
+	failure = interpreter.Eval("(load \"unreadable\")").(UnsuccessfulEvaluationResult).Error
+	if got := failure.Span.String(); !strings.HasSuffix(got, "unreadable.lisp:2:10") {
+		t.Errorf("the syntax error is located at %s, want unreadable.lisp:2:10, the unclosed parameter list", got)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	directory := writeFiles(t, map[string]string{
+		"util.lisp": "(defun double (x) (* 2 x))",
+	})
+
+	interpreter := NewInterpreter(InterpreterOptions{DisableLoad: true})
+	checkResult(t, evalTest{want: "load is disabled in this interpreter", wantError: "file-error"}, interpreter.Eval("(load \"util\")"))
+	checkResult(t, evalTest{want: "require is disabled in this interpreter", wantError: "file-error"}, interpreter.Eval("(require 'util)"))
+
+	checkResult(t, evalTest{want: "T"}, interpreter.LoadFile(filepath.Join(directory, "util.lisp")))
+	checkResult(t, evalTest{want: "6"}, interpreter.Eval("(double 3)"))
+	checkResult(t, evalTest{want: "cannot read", wantError: "file-error"}, interpreter.LoadFile(filepath.Join(directory, "missing.lisp")))
+}
+
+func TestSearchPathFromTheEnvironment(t *testing.T) {
+	first := writeFiles(t, map[string]string{"one.lisp": "(setq one :one)"})
+	second := writeFiles(t, map[string]string{"two.lisp": "(setq two :two)"})
+	t.Setenv("GOLISP_PATH", first+string(filepath.ListSeparator)+second)
+
+	interpreter := NewInterpreter(InterpreterOptions{})
+	checkResult(t, evalTest{want: "(:one :two)"}, interpreter.Eval("(load \"one\") (load \"two\") (list one two)"))
+}
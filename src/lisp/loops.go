@@ -0,0 +1,608 @@
+package lisp
+
+// Iteration special forms. Every loop may be left early with (return
+// value), which makes the loop return value.
+
+// returnResult is the result of (return value): like a failure, it is
+// handed up by the forms evaluating it, until the innermost loop catches
+// it. It cannot leave the function it is evaluated in.
+type returnResult struct {
+	value Expression
+	span  Span
+}
+
+func (rr returnResult) IsSuccessful() bool {
+	return false
+}
+
+func returnFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) > 1 {
+		return newEvaluationError(ArityMismatchError, span, "return expects at most 1 argument but got %d", len(arguments))
+	}
+
+	var value Expression = Boolean{Value: false}
+	if len(arguments) == 1 {
+		evaluationResult := arguments[0].Evaluate(context)
+		if !evaluationResult.IsSuccessful() {
+			return evaluationResult
+		}
+		value = evaluationResult.(SuccessfulEvaluationResult).Expression
+	}
+
+	return returnResult{value: value, span: span}
+}
+
+// catchReturn turns the result of a return into the value it returns. The
+// boolean reports whether the loop must stop, because of a return or of an
+// error.
+func catchReturn(result EvaluationResult) (EvaluationResult, bool) {
+	if rr, ok := result.(returnResult); ok {
+		return SuccessfulEvaluationResult{Expression: rr.value}, true
+	}
+	return result, !result.IsSuccessful()
+}
+
+// evaluateLoopBody evaluates the body of a loop once. Unlike the other
+// bodies, it is not in tail position.
+func evaluateLoopBody(body []Expression, context EvaluationContext) EvaluationResult {
+	return Block{SubExpressions: body}.Evaluate(context)
+}
+
+// parseLoopHeader reads the (variable form [result]) header of dotimes and
+// dolist.
+func parseLoopHeader(formName string, arguments []Expression, span Span) (Symbol, Expression, Expression, EvaluationResult) {
+	if len(arguments) == 0 {
+		return Symbol{}, nil, nil, newEvaluationError(InvalidFormError, span, "%s expects a (variable form [result]) header", formName)
+	}
+
+	elements, ok := listToSlice(arguments[0])
+	if !ok || len(elements) < 2 || len(elements) > 3 || elements[0].GetType() != "symbol" {
+		return Symbol{}, nil, nil, newEvaluationError(InvalidFormError, arguments[0].GetSpan(), "%s expects a (variable form [result]) header but got %s", formName, describe(arguments[0]))
+	}
+
+	var result Expression = Boolean{Value: false}
+	if len(elements) == 3 {
+		result = elements[2]
+	}
+
+	return elements[0].(Symbol), elements[1], result, nil
+}
+
+// dotimesFunction implements (dotimes (variable count [result]) body...),
+// which evaluates body with variable bound to 0, 1, ... count - 1.
+func dotimesFunction(arguments []Expression, span Span, context EvaluationContext) (result EvaluationResult) {
+	variable, countExpression, resultExpression, failure := parseLoopHeader("dotimes", arguments, span)
+	if failure != nil {
+		return failure
+	}
+	if failure := checkAssignable("dotimes", variable, context); failure != nil {
+		return failure
+	}
+
+	countResult := countExpression.Evaluate(context)
+	if !countResult.IsSuccessful() {
+		return countResult
+	}
+	count, ok := countResult.(SuccessfulEvaluationResult).Expression.(Int)
+	if !ok {
+		return newEvaluationError(TypeMismatchError, countExpression.GetSpan(), "dotimes expects an int count but got %s", describe(countResult.(SuccessfulEvaluationResult).Expression))
+	}
+
+	loopContext := NewChildContext(context)
+	bindings := newBinder(loopContext)
+	defer func() { result = bindings.restore(result) }()
+
+	for i := 0; i < count.Value; i++ {
+		if failure := context.checkTimeout("dotimes", span); failure != nil {
+			return failure
+		}
+		bindings.bind(variable.Name(), Int{Value: i})
+		if result, stop := catchReturn(evaluateLoopBody(arguments[1:], loopContext)); stop {
+			return result
+		}
+	}
+
+	bindings.bind(variable.Name(), Int{Value: count.Value})
+	return resultExpression.Evaluate(loopContext)
+}
+
+// dolistFunction implements (dolist (variable list [result]) body...),
+// which evaluates body with variable bound to each element of list.
+func dolistFunction(arguments []Expression, span Span, context EvaluationContext) (result EvaluationResult) {
+	variable, listExpression, resultExpression, failure := parseLoopHeader("dolist", arguments, span)
+	if failure != nil {
+		return failure
+	}
+	if failure := checkAssignable("dolist", variable, context); failure != nil {
+		return failure
+	}
+
+	listResult := listExpression.Evaluate(context)
+	if !listResult.IsSuccessful() {
+		return listResult
+	}
+	elements, ok := listToSlice(listResult.(SuccessfulEvaluationResult).Expression)
+	if !ok {
+		return newEvaluationError(TypeMismatchError, listExpression.GetSpan(), "dolist expects a list but got %s", describe(listResult.(SuccessfulEvaluationResult).Expression))
+	}
+
+	loopContext := NewChildContext(context)
+	bindings := newBinder(loopContext)
+	defer func() { result = bindings.restore(result) }()
+
+	for _, element := range elements {
+		if failure := context.checkTimeout("dolist", span); failure != nil {
+			return failure
+		}
+		bindings.bind(variable.Name(), element)
+		if result, stop := catchReturn(evaluateLoopBody(arguments[1:], loopContext)); stop {
+			return result
+		}
+	}
+
+	bindings.bind(variable.Name(), Boolean{Value: false})
+	return resultExpression.Evaluate(loopContext)
+}
+
+// whileFunction implements (while test body...), which evaluates body as
+// long as test is true and returns NIL.
+func whileFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) == 0 {
+		return newEvaluationError(InvalidFormError, span, "while expects a test")
+	}
+
+	for {
+		if failure := context.checkTimeout("while", span); failure != nil {
+			return failure
+		}
+		testResult := arguments[0].Evaluate(context)
+		if result, stop := catchReturn(testResult); stop {
+			return result
+		}
+		if isNil(testResult.(SuccessfulEvaluationResult).Expression) {
+			return SuccessfulEvaluationResult{
+				Expression: Boolean{Value: false},
+			}
+		}
+
+		if result, stop := catchReturn(evaluateLoopBody(arguments[1:], context)); stop {
+			return result
+		}
+	}
+}
+
+// doFunction implements (do ((variable init [step])...) (end-test
+// result...) body...). The variables are bound in parallel, then updated in
+// parallel with their step forms after each evaluation of body, until
+// end-test is true.
+func doFunction(arguments []Expression, span Span, context EvaluationContext) (result EvaluationResult) {
+	if len(arguments) < 2 {
+		return newEvaluationError(InvalidFormError, span, "do expects a list of variables and an (end-test result...) clause")
+	}
+
+	specifications, ok := listToSlice(arguments[0])
+	if !ok {
+		return newEvaluationError(InvalidFormError, arguments[0].GetSpan(), "do expects a list of variables but got %s", describe(arguments[0]))
+	}
+
+	endClause, ok := listToSlice(arguments[1])
+	if !ok || len(endClause) == 0 {
+		return newEvaluationError(InvalidFormError, arguments[1].GetSpan(), "do expects an (end-test result...) clause but got %s", describe(arguments[1]))
+	}
+
+	variables := []Symbol{}
+	steps := []Expression{}
+	loopContext := NewChildContext(context)
+	initialValues := []Expression{}
+
+	for _, specification := range specifications {
+		elements, ok := listToSlice(specification)
+		if !ok || len(elements) == 0 || len(elements) > 3 || elements[0].GetType() != "symbol" {
+			return newEvaluationError(InvalidFormError, specification.GetSpan(), "do expects variables of the form (variable init [step]) but got %s", describe(specification))
+		}
+		if failure := checkAssignable("do", elements[0].(Symbol), context); failure != nil {
+			return failure
+		}
+
+		var value Expression = Boolean{Value: false}
+		if len(elements) >= 2 {
+			evaluationResult := elements[1].Evaluate(context)
+			if !evaluationResult.IsSuccessful() {
+				return evaluationResult
+			}
+			value = evaluationResult.(SuccessfulEvaluationResult).Expression
+		}
+
+		var step Expression
+		if len(elements) == 3 {
+			step = elements[2]
+		}
+
+		variables = append(variables, elements[0].(Symbol))
+		steps = append(steps, step)
+		initialValues = append(initialValues, value)
+	}
+
+	bindings := newBinder(loopContext)
+	defer func() { result = bindings.restore(result) }()
+
+	for i, variable := range variables {
+		bindings.bind(variable.Name(), initialValues[i])
+	}
+
+	for {
+		if failure := context.checkTimeout("do", span); failure != nil {
+			return failure
+		}
+		testResult := endClause[0].Evaluate(loopContext)
+		if result, stop := catchReturn(testResult); stop {
+			return result
+		}
+		if isTrue(testResult.(SuccessfulEvaluationResult).Expression) {
+			result, _ := catchReturn(evaluateLoopBody(endClause[1:], loopContext))
+			return result
+		}
+
+		if result, stop := catchReturn(evaluateLoopBody(arguments[2:], loopContext)); stop {
+			return result
+		}
+
+		nextValues := make([]Expression, len(variables))
+		for i, step := range steps {
+			if step == nil {
+				continue
+			}
+			stepResult := step.Evaluate(loopContext)
+			if result, stop := catchReturn(stepResult); stop {
+				return result
+			}
+			nextValues[i] = stepResult.(SuccessfulEvaluationResult).Expression
+		}
+
+		for i, variable := range variables {
+			if nextValues[i] != nil {
+				bindings.bind(variable.Name(), nextValues[i])
+			}
+		}
+	}
+}
+
+// loopFor is a for clause of loop: either (for variable in list) or (for
+// variable from start [to end | below end] [by step]).
+type loopFor struct {
+	variable Symbol
+	list     Expression
+	from     Expression
+	to       Expression
+	by       Expression
+	below    bool
+}
+
+// loopAction is a clause of loop evaluated on each iteration, unless one of
+// the tests of the when clauses preceding it is false.
+type loopAction struct {
+	keyword    string
+	forms      []Expression
+	conditions []Expression
+}
+
+// loopIterator steps through the values of a for clause.
+type loopIterator struct {
+	variable Symbol
+	elements []Expression
+	isRange  bool
+	current  Expression
+	end      Expression
+	step     Expression
+	below    bool
+	started  bool
+}
+
+func (iterator *loopIterator) next() (Expression, bool) {
+	if !iterator.isRange {
+		if len(iterator.elements) == 0 {
+			return nil, false
+		}
+		value := iterator.elements[0]
+		iterator.elements = iterator.elements[1:]
+		return value, true
+	}
+
+	if iterator.started {
+		iterator.current = addNumbers(iterator.current, iterator.step)
+	}
+	iterator.started = true
+
+	if iterator.end != nil {
+		comparison := compareNumbers(iterator.current, iterator.end)
+		if comparison > 0 || (iterator.below && comparison == 0) {
+			return nil, false
+		}
+	}
+	return iterator.current, true
+}
+
+// loopKeyword returns the name of a symbol used as a loop keyword, without
+// its package, or "" for any other expression.
+func loopKeyword(expression Expression) string {
+	if symbol, ok := expression.(Symbol); ok {
+		return unqualifiedName(symbol.Name())
+	}
+	return ""
+}
+
+// loopForms returns the compound forms starting at idx, up to the next loop
+// keyword, and the index following them.
+func loopForms(arguments []Expression, idx int) ([]Expression, int) {
+	forms := []Expression{}
+	for idx < len(arguments) && arguments[idx].GetType() == "list" {
+		forms = append(forms, arguments[idx])
+		idx++
+	}
+	return forms, idx
+}
+
+func parseLoopFor(arguments []Expression, idx int, span Span) (loopFor, int, EvaluationResult) {
+	if idx+3 >= len(arguments) || arguments[idx+1].GetType() != "symbol" {
+		return loopFor{}, 0, newEvaluationError(InvalidFormError, span, "loop expects for to be followed by a variable, in or from, and a form")
+	}
+
+	clause := loopFor{variable: arguments[idx+1].(Symbol)}
+
+	switch loopKeyword(arguments[idx+2]) {
+	case "in":
+		clause.list = arguments[idx+3]
+		return clause, idx + 4, nil
+	case "from":
+		clause.from = arguments[idx+3]
+	default:
+		return loopFor{}, 0, newEvaluationError(InvalidFormError, arguments[idx+2].GetSpan(), "loop expects in or from after for %s but got %s", clause.variable.Name(), arguments[idx+2].Print())
+	}
+
+	idx += 4
+	for idx+1 < len(arguments) {
+		switch loopKeyword(arguments[idx]) {
+		case "to":
+			clause.to = arguments[idx+1]
+		case "below":
+			clause.to = arguments[idx+1]
+			clause.below = true
+		case "by":
+			clause.by = arguments[idx+1]
+		default:
+			return clause, idx, nil
+		}
+		idx += 2
+	}
+	return clause, idx, nil
+}
+
+func parseLoopAction(arguments []Expression, idx int, span Span) (loopAction, int, EvaluationResult) {
+	keyword := loopKeyword(arguments[idx])
+
+	switch keyword {
+	case "when":
+		if idx+2 >= len(arguments) {
+			return loopAction{}, 0, newEvaluationError(InvalidFormError, arguments[idx].GetSpan(), "loop expects when to be followed by a test and a clause")
+		}
+		action, next, failure := parseLoopAction(arguments, idx+2, span)
+		if failure != nil {
+			return loopAction{}, 0, failure
+		}
+		action.conditions = append([]Expression{arguments[idx+1]}, action.conditions...)
+		return action, next, nil
+	case "collect", "sum", "return":
+		if idx+1 >= len(arguments) {
+			return loopAction{}, 0, newEvaluationError(InvalidFormError, arguments[idx].GetSpan(), "loop expects %s to be followed by a form", keyword)
+		}
+		return loopAction{keyword: keyword, forms: arguments[idx+1 : idx+2]}, idx + 2, nil
+	case "do":
+		forms, next := loopForms(arguments, idx+1)
+		if len(forms) == 0 {
+			return loopAction{}, 0, newEvaluationError(InvalidFormError, arguments[idx].GetSpan(), "loop expects do to be followed by compound forms")
+		}
+		return loopAction{keyword: keyword, forms: forms}, next, nil
+	}
+
+	return loopAction{}, 0, newEvaluationError(InvalidFormError, arguments[idx].GetSpan(), "loop does not support the clause %s", arguments[idx].Print())
+}
+
+func parseLoop(arguments []Expression, span Span) ([]loopFor, []loopAction, []Expression, EvaluationResult) {
+	fors := []loopFor{}
+	actions := []loopAction{}
+	finally := []Expression{}
+
+	for idx := 0; idx < len(arguments); {
+		switch loopKeyword(arguments[idx]) {
+		case "for":
+			clause, next, failure := parseLoopFor(arguments, idx, span)
+			if failure != nil {
+				return nil, nil, nil, failure
+			}
+			fors = append(fors, clause)
+			idx = next
+		case "finally":
+			forms, next := loopForms(arguments, idx+1)
+			finally = append(finally, forms...)
+			idx = next
+		default:
+			action, next, failure := parseLoopAction(arguments, idx, span)
+			if failure != nil {
+				return nil, nil, nil, failure
+			}
+			actions = append(actions, action)
+			idx = next
+		}
+	}
+
+	return fors, actions, finally, nil
+}
+
+// evaluateLoopValue evaluates a form of a loop clause.
+func evaluateLoopValue(expression Expression, context EvaluationContext) (Expression, EvaluationResult) {
+	evaluationResult := expression.Evaluate(context)
+	if !evaluationResult.IsSuccessful() {
+		return nil, evaluationResult
+	}
+	return evaluationResult.(SuccessfulEvaluationResult).Expression, nil
+}
+
+func makeLoopIterator(clause loopFor, context EvaluationContext) (*loopIterator, EvaluationResult) {
+	iterator := &loopIterator{variable: clause.variable}
+
+	if clause.list != nil {
+		list, failure := evaluateLoopValue(clause.list, context)
+		if failure != nil {
+			return nil, failure
+		}
+		elements, ok := listToSlice(list)
+		if !ok {
+			return nil, newEvaluationError(TypeMismatchError, clause.list.GetSpan(), "loop expects for %s in to be followed by a list but got %s", clause.variable.Name(), describe(list))
+		}
+		iterator.elements = elements
+		return iterator, nil
+	}
+
+	iterator.isRange = true
+	iterator.step = Int{Value: 1}
+
+	bounds := []*Expression{&iterator.current, &iterator.end, &iterator.step}
+	for i, form := range []Expression{clause.from, clause.to, clause.by} {
+		if form == nil {
+			continue
+		}
+		value, failure := evaluateLoopValue(form, context)
+		if failure != nil {
+			return nil, failure
+		}
+		if !isNumber(value) {
+			return nil, newEvaluationError(TypeMismatchError, form.GetSpan(), "loop expects the bounds of for %s to be numbers but got %s", clause.variable.Name(), describe(value))
+		}
+		if i == 2 && compareNumbers(value, Int{Value: 0}) <= 0 {
+			return nil, newEvaluationError(TypeMismatchError, form.GetSpan(), "loop expects the step of for %s to be a positive number but got %s", clause.variable.Name(), describe(value))
+		}
+		*bounds[i] = value
+	}
+
+	return iterator, nil
+}
+
+// loopFunction implements a subset of the loop macro of Common Lisp. Without
+// clauses, (loop forms...) evaluates forms until a return. Otherwise, the
+// loop is made of the clauses:
+//
+//	for variable in list
+//	for variable from start [to end | below end] [by step]
+//	collect form, sum form, do forms..., return form
+//	when test clause
+//	finally forms...
+//
+// The loop stops as soon as a for clause runs out of values, evaluates the
+// finally forms, and returns the collected list, the sum or NIL.
+func loopFunction(arguments []Expression, span Span, context EvaluationContext) (result EvaluationResult) {
+	if len(arguments) == 0 || arguments[0].GetType() == "list" {
+		for {
+			if failure := context.checkTimeout("loop", span); failure != nil {
+				return failure
+			}
+			if result, stop := catchReturn(evaluateLoopBody(arguments, context)); stop {
+				return result
+			}
+		}
+	}
+
+	fors, actions, finally, failure := parseLoop(arguments, span)
+	if failure != nil {
+		return failure
+	}
+
+	loopContext := NewChildContext(context)
+	bindings := newBinder(loopContext)
+	defer func() { result = bindings.restore(result) }()
+	iterators := []*loopIterator{}
+
+	for _, clause := range fors {
+		if failure := checkAssignable("loop", clause.variable, context); failure != nil {
+			return failure
+		}
+		iterator, failure := makeLoopIterator(clause, loopContext)
+		if failure != nil {
+			return failure
+		}
+		bindings.bind(clause.variable.Name(), Boolean{Value: false})
+		iterators = append(iterators, iterator)
+	}
+
+	accumulation := ""
+	collected := []Expression{}
+	var sum Expression = Int{Value: 0}
+
+iterations:
+	for {
+		if failure := context.checkTimeout("loop", span); failure != nil {
+			return failure
+		}
+		for _, iterator := range iterators {
+			value, ok := iterator.next()
+			if !ok {
+				break iterations
+			}
+			bindings.bind(iterator.variable.Name(), value)
+		}
+
+	actions:
+		for _, action := range actions {
+			for _, condition := range action.conditions {
+				value, failure := evaluateLoopValue(condition, loopContext)
+				if failure != nil {
+					result, _ := catchReturn(failure)
+					return result
+				}
+				if isNil(value) {
+					continue actions
+				}
+			}
+
+			if action.keyword == "do" {
+				if result, stop := catchReturn(evaluateLoopBody(action.forms, loopContext)); stop {
+					return result
+				}
+				continue
+			}
+
+			value, failure := evaluateLoopValue(action.forms[0], loopContext)
+			if failure != nil {
+				result, _ := catchReturn(failure)
+				return result
+			}
+
+			switch action.keyword {
+			case "collect":
+				accumulation = "collect"
+				collected = append(collected, value)
+			case "sum":
+				if !isNumber(value) {
+					return newEvaluationError(TypeMismatchError, action.forms[0].GetSpan(), "loop expects sum to be followed by a number but got %s", describe(value))
+				}
+				accumulation = "sum"
+				sum = addNumbers(sum, value)
+			case "return":
+				return SuccessfulEvaluationResult{Expression: value}
+			}
+		}
+	}
+
+	if result, stop := catchReturn(evaluateLoopBody(finally, loopContext)); stop {
+		return result
+	}
+
+	switch accumulation {
+	case "collect":
+		return SuccessfulEvaluationResult{Expression: makeListFromSlice(collected, Span{})}
+	case "sum":
+		return SuccessfulEvaluationResult{Expression: sum}
+	}
+	return SuccessfulEvaluationResult{
+		Expression: Boolean{Value: false},
+	}
+}
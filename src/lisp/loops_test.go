@@ -0,0 +1,77 @@
+package lisp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoops(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "dotimes", source: "(setq sum 0) (dotimes (i 5) (setq sum (+ sum i))) sum", want: "10"},
+		{name: "dotimes result", source: "(dotimes (i 3 i))", want: "3"},
+		{name: "dotimes zero times", source: "(setq n 0) (dotimes (i 0) (setq n 1)) n", want: "0"},
+		{name: "dolist", source: "(setq out NIL) (dolist (x '(1 2 3)) (push x out)) out", want: "(3 2 1)"},
+		{name: "dolist result", source: "(dolist (x '(1 2) :end))", want: ":end"},
+		{name: "while", source: "(setq i 0) (while (< i 5) (setq i (+ i 1))) i", want: "5"},
+		{name: "do", source: "(do ((i 0 (+ i 1)) (acc NIL (cons i acc))) ((= i 3) acc))", want: "(2 1 0)"},
+		{name: "do steps in parallel", source: "(do ((a 1 b) (b 2 a) (n 0 (+ n 1))) ((= n 1) (list a b)))", want: "(2 1)"},
+		{name: "return from dotimes", source: "(dotimes (i 10) (when (= i 4) (return i)))", want: "4"},
+		{name: "return from while", source: "(while T (return :out))", want: ":out"},
+		{name: "return without a value", source: "(dolist (x '(1 2)) (return))", want: "NIL"},
+		{name: "return from the inner loop", source: "(dotimes (i 2) (dotimes (j 5) (return j)) (setq last i)) last", want: "1"},
+		{name: "return inside a let", source: "(dotimes (i 10) (let ((j (* i 2))) (when (> j 5) (return j))))", want: "6"},
+		{name: "a function returning from its own loop", source: "(defun f () (dotimes (i 10) (when (= i 2) (return i)))) (list (f) (f))", want: "(2 2)"},
+
+		{name: "loop for in collect", source: "(loop for x in '(1 2 3) collect (* x x))", want: "(1 4 9)"},
+		{name: "loop for from to", source: "(loop for i from 1 to 4 collect i)", want: "(1 2 3 4)"},
+		{name: "loop for below by", source: "(loop for i from 0 below 10 by 3 collect i)", want: "(0 3 6 9)"},
+		{name: "loop by a ratio", source: "(loop for i from 0 to 1 by 1/2 collect i)", want: "(0 1/2 1)"},
+		{name: "loop sum", source: "(loop for i from 1 to 100 sum i)", want: "5050"},
+		{name: "loop when", source: "(loop for i from 1 to 10 when (evenp i) collect i)", want: "(2 4 6 8 10)"},
+		{name: "loop in parallel", source: "(loop for x in '(a b c) for i from 1 collect (list i x))", want: "((1 a) (2 b) (3 c))"},
+		{name: "loop do and finally", source: "(setq n 0) (loop for i from 1 to 3 do (setq n (+ n i)) finally (return n))", want: "6"},
+		{name: "loop return", source: "(loop for i from 1 when (> (* i i) 50) return i)", want: "8"},
+		{name: "simple loop", source: "(setq i 0) (loop (setq i (+ i 1)) (when (= i 7) (return i)))", want: "7"},
+
+		{name: "return outside a loop", source: "(return 1)", want: "return is only allowed inside a loop", wantError: "invalid-form"},
+		{name: "return from a function called in a loop", source: "(defun f () (return 1)) (dotimes (i 3) (f))", want: "return is only allowed inside a loop", wantError: "invalid-form"},
+		{name: "return from a lambda called in a loop", source: "(dolist (x '(1 2)) (funcall (lambda () (return x))))", want: "return is only allowed inside a loop", wantError: "invalid-form"},
+		{name: "return in a loop step", source: "(do ((i 0 (return :step))) ((= i 1) :end))", want: ":step"},
+		{name: "return arity", source: "(dotimes (i 1) (return 1 2))", want: "return expects at most 1 argument", wantError: "arity-mismatch"},
+		{name: "dotimes without a header", source: "(dotimes)", want: "dotimes expects a (variable form [result]) header", wantError: "invalid-form"},
+		{name: "dotimes of a string", source: "(dotimes (i \"a\"))", want: "dotimes expects an int count", wantError: "type-error"},
+		{name: "dolist of a number", source: "(dolist (x 1))", want: "dolist expects a list but got 1", wantError: "type-error"},
+		{name: "while without a test", source: "(while)", want: "while expects a test", wantError: "invalid-form"},
+		{name: "do without an end clause", source: "(do ((i 0)))", wantError: "invalid-form"},
+		{name: "do with a bad variable", source: "(do (1) (T))", want: "do expects variables of the form", wantError: "invalid-form"},
+		{name: "loop by zero", source: "(loop for i from 0 to 10 by 0 collect i)", want: "to be a positive number but got 0", wantError: "type-error"},
+		{name: "loop by a negative step", source: "(loop for i from 0 to 10 by -1 collect i)", want: "to be a positive number but got -1", wantError: "type-error"},
+		{name: "loop for in a number", source: "(loop for x in 5 collect x)", want: "to be followed by a list but got 5", wantError: "type-error"},
+		{name: "loop for a string bound", source: "(loop for i from \"a\" to 2 collect i)", want: "to be numbers", wantError: "type-error"},
+		{name: "loop for without in or from", source: "(loop for x on '(1) collect x)", want: "loop expects in or from after for x but got on", wantError: "invalid-form"},
+		{name: "loop sum of a symbol", source: "(loop for x in '(a) sum x)", want: "loop expects sum to be followed by a number", wantError: "type-error"},
+		{name: "loop collect without a form", source: "(loop for x in '(a) collect)", want: "loop expects collect to be followed by a form", wantError: "invalid-form"},
+		{name: "an unknown loop clause", source: "(loop for x in '(a) repeat 2)", want: "loop does not support the clause repeat", wantError: "invalid-form"},
+	})
+}
+
+func TestTimeout(t *testing.T) {
+	interpreter := NewInterpreter(InterpreterOptions{Timeout: 20 * time.Millisecond})
+
+	tests := []evalTest{
+		{name: "simple loop", source: "(loop)", want: "loop exceeds the time limit of 20ms", wantError: "timeout"},
+		{name: "unbounded collect", source: "(loop for i from 0 collect i)", want: "loop exceeds the time limit", wantError: "timeout"},
+		{name: "dotimes", source: "(dotimes (i 1000000000) (+ i 1))", want: "dotimes exceeds the time limit", wantError: "timeout"},
+		{name: "while", source: "(while T)", want: "while exceeds the time limit", wantError: "timeout"},
+		{name: "do", source: "(do ((i 0 (+ i 1))) (NIL))", want: "do exceeds the time limit", wantError: "timeout"},
+		{name: "tail recursion", source: "(defun forever () (forever)) (forever)", want: "forever exceeds the time limit", wantError: "timeout"},
+		{name: "handlers do not catch timeouts", source: "(handler-case (loop) (error () :caught))", wantError: "timeout"},
+		{name: "nor does ignore-errors", source: "(loop (ignore-errors (loop)))", wantError: "timeout"},
+		{name: "the next evaluation has its own time", source: "(dotimes (i 1000) i)", want: "NIL"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkResult(t, test, interpreter.Eval(test.source))
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package lisp
+
+func quoteFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) != 1 {
+		return newEvaluationError(ArityMismatchError, span, "quote expects 1 argument but got %d", len(arguments))
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: arguments[0],
+	}
+}
+
+// evalFunction implements (eval form): the value of the argument is
+// evaluated again as code in the global environment.
+func evalFunction(arguments []Expression, global EvaluationContext) EvaluationResult {
+	if len(arguments) != 1 {
+		return newEvaluationError(ArityMismatchError, Span{}, "eval expects 1 argument but got %d", len(arguments))
+	}
+	if isCircular(arguments[0]) {
+		return newEvaluationError(InvalidFormError, Span{}, "eval cannot evaluate a circular form")
+	}
+
+	return arguments[0].Evaluate(global)
+}
+
+func quasiquoteFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) != 1 {
+		return newEvaluationError(ArityMismatchError, span, "quasiquote expects 1 argument but got %d", len(arguments))
+	}
+
+	return evaluateQuasiquote(arguments[0], 1, context)
+}
+
+// isFormNamed reports whether an expression is a list whose head is the
+// variable of the given name, such as (unquote x).
+func isFormNamed(expression Expression, name string) bool {
+	list, ok := expression.(*List)
+	if !ok {
+		return false
+	}
+	head, ok := list.left.(Symbol)
+	return ok && head.Name() == name
+}
+
+// quasiquoteArgument returns the argument of an (unquote x),
+// (unquote-splicing x) or (quasiquote x) form, which must have exactly one.
+func quasiquoteArgument(form *List) (Expression, EvaluationResult) {
+	arguments, ok := listToSlice(form.right)
+	if !ok || len(arguments) != 1 {
+		return nil, newEvaluationError(InvalidFormError, form.Span, "%s expects 1 argument in %s", form.left.Print(), form.Print())
+	}
+	return arguments[0], nil
+}
+
+// evaluateQuasiquote fills a backquoted template. Depth counts the nested
+// backquotes: only the unquotes at depth 1 are evaluated.
+func evaluateQuasiquote(template Expression, depth int, context EvaluationContext) EvaluationResult {
+	list, ok := template.(*List)
+	if !ok {
+		return SuccessfulEvaluationResult{Expression: template}
+	}
+
+	if isFormNamed(list, "unquote") || isFormNamed(list, "unquote-splicing") || isFormNamed(list, "quasiquote") {
+		argument, failure := quasiquoteArgument(list)
+		if failure != nil {
+			return failure
+		}
+		nestedDepth := depth - 1
+		if isFormNamed(list, "quasiquote") {
+			nestedDepth = depth + 1
+		}
+
+		if nestedDepth == 0 {
+			if isFormNamed(list, "unquote-splicing") {
+				return newEvaluationError(InvalidFormError, list.Span, ",@ can only be used in a list but got %s", list.Print())
+			}
+			return argument.Evaluate(context)
+		}
+
+		evaluationResult := evaluateQuasiquote(argument, nestedDepth, context)
+		if !evaluationResult.IsSuccessful() {
+			return evaluationResult
+		}
+		return SuccessfulEvaluationResult{
+			Expression: makeListFromSlice([]Expression{list.left, evaluationResult.(SuccessfulEvaluationResult).Expression}, list.Span),
+		}
+	}
+
+	elements := []Expression{}
+	var tail Expression = Boolean{Value: false}
+
+	for current := Expression(list); ; {
+		// in `(a . ,b), the unquote form is the tail of the list
+		if len(elements) > 0 && (isFormNamed(current, "unquote") || isFormNamed(current, "unquote-splicing")) {
+			evaluationResult := evaluateQuasiquote(current, depth, context)
+			if !evaluationResult.IsSuccessful() {
+				return evaluationResult
+			}
+			tail = evaluationResult.(SuccessfulEvaluationResult).Expression
+			break
+		}
+
+		cell, ok := current.(*List)
+		if !ok {
+			tail = current
+			break
+		}
+
+		element := cell.left
+
+		if depth == 1 && isFormNamed(element, "unquote-splicing") {
+			argument, failure := quasiquoteArgument(element.(*List))
+			if failure != nil {
+				return failure
+			}
+			evaluationResult := argument.Evaluate(context)
+			if !evaluationResult.IsSuccessful() {
+				return evaluationResult
+			}
+			value := evaluationResult.(SuccessfulEvaluationResult).Expression
+			splicedElements, ok := listToSlice(value)
+			if !ok {
+				return newEvaluationError(TypeMismatchError, element.GetSpan(), ",@ expects a list but got %s", describe(value))
+			}
+			elements = append(elements, splicedElements...)
+		} else {
+			evaluationResult := evaluateQuasiquote(element, depth, context)
+			if !evaluationResult.IsSuccessful() {
+				return evaluationResult
+			}
+			elements = append(elements, evaluationResult.(SuccessfulEvaluationResult).Expression)
+		}
+
+		current = cell.right
+	}
+
+	return SuccessfulEvaluationResult{Expression: makeDottedList(elements, tail, Span{})}
+}
+
+func defineMacro(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) == 0 || arguments[0].GetType() != "symbol" {
+		return newEvaluationError(InvalidFormError, span, "defmacro expects a macro name")
+	}
+
+	macroName := arguments[0].(Symbol).Name()
+	if failure := checkDefinable("defmacro", macroName, span, context); failure != nil {
+		return failure
+	}
+
+	macro, failure := makeFunction(macroName, arguments[1:], span, context)
+	if failure != nil {
+		return failure
+	}
+	macro.isMacro = true
+
+	context.DefineFunction(macroName, macro)
+
+	return SuccessfulEvaluationResult{
+		Expression: macro,
+	}
+}
+
+// expandMacro calls a macro with the unevaluated arguments of a form, and
+// returns the expansion.
+func expandMacro(macro FunctionDeclaration, form *List) EvaluationResult {
+	arguments, ok := listToSlice(form.right)
+	if !ok {
+		return newEvaluationError(InvalidFormError, form.Span, "cannot expand the dotted list %s", form.Print())
+	}
+
+	expansionResult := callFunctionDeclaration(macro, arguments, form.Span)
+	if expansionResult.IsSuccessful() && isCircular(expansionResult.(SuccessfulEvaluationResult).Expression) {
+		return newEvaluationError(InvalidFormError, form.Span, "%s expands into a circular form", macro.functionName)
+	}
+	return expansionResult
+}
+
+func expandAndEvaluate(macro FunctionDeclaration, form *List, context EvaluationContext) EvaluationResult {
+	expansionResult := expandMacro(macro, form)
+	if !expansionResult.IsSuccessful() {
+		return expansionResult
+	}
+
+	// an expansion may expand the same macro again
+	leave, failure := context.enterCall(macro.functionName, form.Span)
+	if failure != nil {
+		return failure
+	}
+	defer leave()
+
+	return evaluateTail(expansionResult.(SuccessfulEvaluationResult).Expression, context)
+}
+
+// lookupMacro returns the macro called by a form, if any.
+func lookupMacro(form Expression, context EvaluationContext) (FunctionDeclaration, bool) {
+	list, ok := form.(*List)
+	if !ok {
+		return FunctionDeclaration{}, false
+	}
+	head, ok := list.left.(Symbol)
+	if !ok {
+		return FunctionDeclaration{}, false
+	}
+	macro, ok := context.LookupFunction(head.Name())
+	return macro, ok && macro.isMacro
+}
+
+// macroexpandFunction implements macroexpand-1, which expands a form once
+// if it is a macro call, and macroexpand, which expands it until it is not
+// a macro call anymore.
+func macroexpandFunction(name string, repeat bool) func(arguments []Expression, global EvaluationContext) EvaluationResult {
+	return func(arguments []Expression, global EvaluationContext) EvaluationResult {
+		if len(arguments) != 1 {
+			return newEvaluationError(ArityMismatchError, Span{}, "%s expects 1 argument but got %d", name, len(arguments))
+		}
+
+		form := arguments[0]
+
+		for {
+			macro, ok := lookupMacro(form, global)
+			if !ok {
+				break
+			}
+
+			expansionResult := expandMacro(macro, form.(*List))
+			if !expansionResult.IsSuccessful() {
+				return expansionResult
+			}
+			form = expansionResult.(SuccessfulEvaluationResult).Expression
+
+			if !repeat {
+				break
+			}
+		}
+
+		return SuccessfulEvaluationResult{
+			Expression: form,
+		}
+	}
+}
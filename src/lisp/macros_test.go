@@ -0,0 +1,50 @@
+package lisp
+
+import (
+	"testing"
+)
+
+func TestMacros(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "defmacro", source: "(defmacro my-if (c a b) `(cond (,c ,a) (T ,b))) (my-if NIL 1 2)", want: "2"},
+		{name: "arguments are not evaluated", source: "(defmacro my-quote (x) `(quote ,x)) (my-quote (a b))", want: "(a b)"},
+		{name: "rest parameter", source: "(defmacro my-progn (&rest body) `(let () ,@body)) (my-progn 1 2 3)", want: "3"},
+		{name: "expansion sees the caller's variables", source: "(defmacro inc (v) `(setq ,v (+ ,v 1))) (let ((n 1)) (inc n) n)", want: "2"},
+		{name: "macros using macros", source: `
+			(defmacro my-when (c &rest body) ` + "`" + `(if ,c (progn ,@body) NIL))
+			(defmacro my-unless (c &rest body) ` + "`" + `(my-when (null ,c) ,@body))
+			(my-unless NIL :yes)`, want: ":yes"},
+		{name: "eval", source: "(eval '(+ 1 2))", want: "3"},
+
+		{name: "quasiquote without unquote", source: "`(a b)", want: "(a b)"},
+		{name: "unquote", source: "(setq x 5) `(a ,x)", want: "(a 5)"},
+		{name: "unquote an expression", source: "`(a ,(+ 1 2))", want: "(a 3)"},
+		{name: "splicing", source: "(setq xs '(1 2)) `(a ,@xs b)", want: "(a 1 2 b)"},
+		{name: "splicing an empty list", source: "`(a ,@NIL b)", want: "(a b)"},
+		{name: "splicing at the end", source: "(setq xs '(1 2)) `(a ,@xs)", want: "(a 1 2)"},
+		{name: "dotted unquote", source: "(setq xs '(1 2)) `(a . ,xs)", want: "(a 1 2)"},
+		{name: "nested quasiquote keeps inner unquotes", source: "(setq x 1) `(a `(b ,x))", want: "(a (quasiquote (b (unquote x))))"},
+		{name: "nested splicing keeps inner splices", source: "(setq x '(1)) `(a `(b ,@x))", want: "(a (quasiquote (b (unquote-splicing x))))"},
+		{name: "unquote in a nested quasiquote", source: "(setq x 1) `(a `(b ,,x))", want: "(a (quasiquote (b (unquote 1))))"},
+
+		{name: "macroexpand-1", source: "(defmacro inc (v) `(setq ,v (+ ,v 1))) (macroexpand-1 '(inc n))", want: "(setq n (+ n 1))"},
+		{name: "macroexpand expands repeatedly", source: `
+			(defmacro one (x) ` + "`" + `(two ,x))
+			(defmacro two (x) ` + "`" + `(list ,x))
+			(list (macroexpand-1 '(one 1)) (macroexpand '(one 1)))`, want: "((two 1) (list 1))"},
+		{name: "macroexpand of a function call", source: "(macroexpand '(car x))", want: "(car x)"},
+		{name: "macroexpand as a function", source: "(defmacro inc (v) `(setq ,v (+ ,v 1))) (mapcar #'macroexpand '((inc a) (car b)))", want: "((setq a (+ a 1)) (car b))"},
+		{name: "funcall of macroexpand-1", source: "(defmacro inc (v) `(setq ,v (+ ,v 1))) (funcall #'macroexpand-1 '(inc a))", want: "(setq a (+ a 1))"},
+
+		{name: "splicing a non-list", source: "`(a ,@1)", want: ",@ expects a list but got 1", wantError: "type-error"},
+		{name: "splicing at the top level", source: "`,@(list 1)", want: ",@ can only be used in a list", wantError: "invalid-form"},
+		{name: "splicing after a dot", source: "(setq xs '(1)) `(a . ,@xs)", want: ",@ can only be used in a list", wantError: "invalid-form"},
+		{name: "unquote without an argument", source: "`(a (unquote))", want: "unquote expects 1 argument", wantError: "invalid-form"},
+		{name: "unquote with two arguments", source: "`(a (unquote b c))", want: "unquote expects 1 argument", wantError: "invalid-form"},
+		{name: "quasiquote with two arguments", source: "(quasiquote a b)", wantError: "arity-mismatch"},
+		{name: "defmacro without a name", source: "(defmacro (x) x)", want: "defmacro expects a macro name", wantError: "invalid-form"},
+		{name: "macro arity", source: "(defmacro m (a) a) (m)", wantError: "arity-mismatch"},
+		{name: "macroexpand arity", source: "(macroexpand)", want: "macroexpand expects 1 argument", wantError: "arity-mismatch"},
+		{name: "recursive expansion", source: "(defmacro m () '(m)) (m)", wantError: "call-depth-exceeded"},
+	})
+}
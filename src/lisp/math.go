@@ -0,0 +1,391 @@
+package lisp
+
+import (
+	"math"
+	"math/big"
+)
+
+func expectNumber(functionName string, argument Expression) (Expression, EvaluationResult) {
+	numbers, failure := expectNumbers(functionName, []Expression{argument})
+	if failure != nil {
+		return nil, failure
+	}
+	return numbers[0], nil
+}
+
+func expectInteger(functionName string, argument Expression) (*big.Int, EvaluationResult) {
+	value := toBigInt(argument)
+	if value == nil {
+		return nil, newEvaluationError(TypeMismatchError, Span{}, "%s expects an integer but got %s", functionName, describe(argument))
+	}
+	return value, nil
+}
+
+const (
+	roundingFloor = iota
+	roundingCeiling
+	roundingTruncate
+	roundingRound
+)
+
+// roundRat rounds a fraction to an integer: toward negative infinity,
+// toward positive infinity, toward zero, or to the nearest integer with
+// ties to even.
+func roundRat(value *big.Rat, mode int) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	sign := big.NewInt(int64(remainder.Sign()))
+
+	switch mode {
+	case roundingFloor:
+		if remainder.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		}
+	case roundingCeiling:
+		if remainder.Sign() > 0 {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	case roundingRound:
+		twice := new(big.Int).Abs(remainder)
+		twice.Lsh(twice, 1)
+		comparison := twice.Cmp(value.Denom())
+		if comparison > 0 || (comparison == 0 && quotient.Bit(0) == 1) {
+			quotient.Add(quotient, sign)
+		}
+	}
+	return quotient
+}
+
+func roundFloat(value float64, mode int) float64 {
+	switch mode {
+	case roundingFloor:
+		return math.Floor(value)
+	case roundingCeiling:
+		return math.Ceil(value)
+	case roundingRound:
+		return math.RoundToEven(value)
+	}
+	return math.Trunc(value)
+}
+
+// divideRounded returns the quotient of a by b rounded to an integer, and
+// the remainder a - quotient * b.
+func divideRounded(a Expression, b Expression, mode int) (Expression, Expression, EvaluationResult) {
+	if isZero(b) {
+		return nil, nil, newEvaluationError(DivisionByZeroError, Span{}, "cannot divide %s by zero", a.Print())
+	}
+
+	var quotient Expression
+
+	if commonLevel(a, b) == floatLevel {
+		rounded := roundFloat(toFloat(a)/toFloat(b), mode)
+		if math.IsInf(rounded, 0) || math.IsNaN(rounded) {
+			return nil, nil, newEvaluationError(TypeMismatchError, Span{}, "cannot round %s to an integer", Float{Value: rounded}.Print())
+		}
+		integer, _ := big.NewFloat(rounded).Int(nil)
+		quotient = normalizeBigInt(integer)
+	} else {
+		quotient = normalizeBigInt(roundRat(new(big.Rat).Quo(toRat(a), toRat(b)), mode))
+	}
+
+	return quotient, subtractNumbers(a, multiplyNumbers(quotient, b)), nil
+}
+
+// roundingFunction implements floor, ceiling, truncate and round, which
+// return the quotient of (function number [divisor]) as an integer.
+func roundingFunction(functionName string, mode int) func(arguments []Expression) EvaluationResult {
+	return func(arguments []Expression) EvaluationResult {
+		if failure := checkArityRange(functionName, arguments, 1, 2); failure != nil {
+			return failure
+		}
+
+		numbers, failure := expectNumbers(functionName, arguments)
+		if failure != nil {
+			return failure
+		}
+		if len(numbers) == 1 {
+			numbers = append(numbers, Int{Value: 1})
+		}
+
+		quotient, _, failure := divideRounded(numbers[0], numbers[1], mode)
+		if failure != nil {
+			return failure
+		}
+
+		return SuccessfulEvaluationResult{
+			Expression: quotient,
+		}
+	}
+}
+
+// remainderFunction implements mod, whose result has the sign of the
+// divisor, and rem, whose result has the sign of the dividend.
+func remainderFunction(functionName string, mode int) func(arguments []Expression) EvaluationResult {
+	return func(arguments []Expression) EvaluationResult {
+		if failure := checkArity(functionName, arguments, 2); failure != nil {
+			return failure
+		}
+
+		numbers, failure := expectNumbers(functionName, arguments)
+		if failure != nil {
+			return failure
+		}
+
+		_, remainder, failure := divideRounded(numbers[0], numbers[1], mode)
+		if failure != nil {
+			return failure
+		}
+
+		return SuccessfulEvaluationResult{
+			Expression: remainder,
+		}
+	}
+}
+
+func absolute(arguments []Expression) EvaluationResult {
+	if failure := checkArity("abs", arguments, 1); failure != nil {
+		return failure
+	}
+
+	number, failure := expectNumber("abs", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	if value, ok := number.(Float); ok {
+		return SuccessfulEvaluationResult{Expression: Float{Value: math.Abs(value.Value)}}
+	}
+	if compareNumbers(number, Int{Value: 0}) < 0 {
+		number = subtractNumbers(Int{Value: 0}, number)
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: number,
+	}
+}
+
+// extremum implements min, when sign is -1, and max, when sign is 1.
+func extremum(functionName string, sign int) func(arguments []Expression) EvaluationResult {
+	return func(arguments []Expression) EvaluationResult {
+		if len(arguments) == 0 {
+			return newEvaluationError(ArityMismatchError, Span{}, "%s expects at least 1 argument", functionName)
+		}
+
+		numbers, failure := expectNumbers(functionName, arguments)
+		if failure != nil {
+			return failure
+		}
+
+		result := numbers[0]
+		for _, number := range numbers[1:] {
+			if compareNumbers(number, result) == sign {
+				result = number
+			}
+		}
+
+		return SuccessfulEvaluationResult{
+			Expression: result,
+		}
+	}
+}
+
+// integerFunction implements the variadic functions on integers, folding
+// operation over the arguments starting from identity.
+func integerFunction(functionName string, identity int64, operation func(a *big.Int, b *big.Int) *big.Int) func(arguments []Expression) EvaluationResult {
+	return func(arguments []Expression) EvaluationResult {
+		result := big.NewInt(identity)
+
+		for _, argument := range arguments {
+			value, failure := expectInteger(functionName, argument)
+			if failure != nil {
+				return failure
+			}
+			result = operation(result, value)
+		}
+
+		return SuccessfulEvaluationResult{
+			Expression: normalizeBigInt(result),
+		}
+	}
+}
+
+func greatestCommonDivisor(a *big.Int, b *big.Int) *big.Int {
+	return new(big.Int).GCD(nil, nil, new(big.Int).Abs(a), new(big.Int).Abs(b))
+}
+
+func leastCommonMultiple(a *big.Int, b *big.Int) *big.Int {
+	if a.Sign() == 0 || b.Sign() == 0 {
+		return new(big.Int)
+	}
+	product := new(big.Int).Abs(new(big.Int).Mul(a, b))
+	return product.Quo(product, greatestCommonDivisor(a, b))
+}
+
+// Bitwise operations treat negative integers as in two's complement.
+
+func bitwiseAnd(a *big.Int, b *big.Int) *big.Int {
+	return new(big.Int).And(a, b)
+}
+
+func bitwiseOr(a *big.Int, b *big.Int) *big.Int {
+	return new(big.Int).Or(a, b)
+}
+
+func bitwiseXor(a *big.Int, b *big.Int) *big.Int {
+	return new(big.Int).Xor(a, b)
+}
+
+// maxIntegerBits bounds the size of the integers made by expt and ash, so
+// that a large exponent fails instead of exhausting the memory.
+const maxIntegerBits = 1 << 20
+
+// powerTooLarge reports whether base to the power exponent may have more
+// than maxIntegerBits bits.
+func powerTooLarge(base *big.Int, exponent *big.Int) bool {
+	growth := int64(base.BitLen() - 1)
+	if growth <= 0 {
+		return false
+	}
+	return !exponent.IsInt64() || exponent.Int64() > maxIntegerBits/growth
+}
+
+// expt raises a base to a power, exactly when the base is exact and the
+// power an integer.
+func expt(arguments []Expression) EvaluationResult {
+	if failure := checkArity("expt", arguments, 2); failure != nil {
+		return failure
+	}
+
+	numbers, failure := expectNumbers("expt", arguments)
+	if failure != nil {
+		return failure
+	}
+	base, power := numbers[0], numbers[1]
+
+	exponent := toBigInt(power)
+	if exponent == nil || base.GetType() == "float" {
+		return SuccessfulEvaluationResult{
+			Expression: Float{Value: math.Pow(toFloat(base), toFloat(power))},
+		}
+	}
+
+	if exponent.Sign() < 0 && isZero(base) {
+		return newEvaluationError(DivisionByZeroError, Span{}, "cannot raise zero to the negative power %s", power.Print())
+	}
+
+	absoluteExponent := new(big.Int).Abs(exponent)
+	rational := toRat(base)
+	if powerTooLarge(rational.Num(), absoluteExponent) || powerTooLarge(rational.Denom(), absoluteExponent) {
+		return newEvaluationError(ArithmeticError, Span{}, "expt cannot raise %s to the power %s, the result would exceed %d bits", base.Print(), power.Print(), maxIntegerBits)
+	}
+	numerator := new(big.Int).Exp(rational.Num(), absoluteExponent, nil)
+	denominator := new(big.Int).Exp(rational.Denom(), absoluteExponent, nil)
+
+	if exponent.Sign() < 0 {
+		numerator, denominator = denominator, numerator
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: normalizeRat(new(big.Rat).SetFrac(numerator, denominator)),
+	}
+}
+
+func squareRoot(arguments []Expression) EvaluationResult {
+	if failure := checkArity("sqrt", arguments, 1); failure != nil {
+		return failure
+	}
+
+	number, failure := expectNumber("sqrt", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	if compareNumbers(number, Int{Value: 0}) < 0 {
+		return newEvaluationError(TypeMismatchError, Span{}, "sqrt expects a non negative number but got %s", describe(number))
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: Float{Value: math.Sqrt(toFloat(number))},
+	}
+}
+
+// increment implements 1+, when delta is 1, and 1-, when delta is -1.
+func increment(functionName string, delta int) func(arguments []Expression) EvaluationResult {
+	return func(arguments []Expression) EvaluationResult {
+		if failure := checkArity(functionName, arguments, 1); failure != nil {
+			return failure
+		}
+
+		number, failure := expectNumber(functionName, arguments[0])
+		if failure != nil {
+			return failure
+		}
+
+		return SuccessfulEvaluationResult{
+			Expression: addNumbers(number, Int{Value: delta}),
+		}
+	}
+}
+
+func zerop(arguments []Expression) EvaluationResult {
+	if failure := checkArity("zerop", arguments, 1); failure != nil {
+		return failure
+	}
+
+	number, failure := expectNumber("zerop", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: Boolean{Value: isZero(number)},
+	}
+}
+
+// parity implements evenp, when remainder is 0, and oddp, when it is 1.
+func parity(functionName string, remainder uint) func(arguments []Expression) EvaluationResult {
+	return func(arguments []Expression) EvaluationResult {
+		if failure := checkArity(functionName, arguments, 1); failure != nil {
+			return failure
+		}
+
+		value, failure := expectInteger(functionName, arguments[0])
+		if failure != nil {
+			return failure
+		}
+
+		return SuccessfulEvaluationResult{
+			Expression: Boolean{Value: value.Bit(0) == remainder},
+		}
+	}
+}
+
+// arithmeticShift implements (ash integer count), which shifts integer to
+// the left by count bits, or to the right when count is negative.
+func arithmeticShift(arguments []Expression) EvaluationResult {
+	if failure := checkArity("ash", arguments, 2); failure != nil {
+		return failure
+	}
+
+	value, failure := expectInteger("ash", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	count, ok := arguments[1].(Int)
+	if !ok {
+		return newEvaluationError(TypeMismatchError, Span{}, "ash expects an int count but got %s", describe(arguments[1]))
+	}
+
+	if count.Value >= 0 {
+		if value.Sign() != 0 && count.Value > maxIntegerBits-value.BitLen() {
+			return newEvaluationError(ArithmeticError, Span{}, "ash cannot shift %s by %d, the result would exceed %d bits", value, count.Value, maxIntegerBits)
+		}
+		return SuccessfulEvaluationResult{
+			Expression: normalizeBigInt(new(big.Int).Lsh(value, uint(count.Value))),
+		}
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: normalizeBigInt(new(big.Int).Rsh(value, uint(-count.Value))),
+	}
+}
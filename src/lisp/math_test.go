@@ -0,0 +1,49 @@
+package lisp
+
+import (
+	"testing"
+)
+
+func TestMath(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "variadic addition", source: "(list (+) (+ 1) (+ 1 2 3 4))", want: "(0 1 10)"},
+		{name: "variadic multiplication", source: "(list (*) (* 2 3 4))", want: "(1 24)"},
+		{name: "variadic subtraction", source: "(- 10 1 2 3)", want: "4"},
+		{name: "variadic division", source: "(/ 60 2 3)", want: "10"},
+		{name: "chained comparisons", source: "(list (< 1 2 3) (< 1 3 2) (<= 1 1 2) (> 3 2 1) (>= 3 3 4))", want: "(T NIL T T NIL)"},
+		{name: "chained equality", source: "(list (= 1 1 1) (= 1 1 2) (/= 1 2 3) (/= 1 2 1))", want: "(T NIL T NIL)"},
+		{name: "mod and rem", source: "(list (mod -7 3) (rem -7 3) (mod 7 -3) (rem 7 -3))", want: "(2 -1 -2 1)"},
+		{name: "mod of floats", source: "(mod 5.5 2)", want: "1.5"},
+		{name: "rounding", source: "(list (floor 7 2) (ceiling 7 2) (truncate -7 2) (round 5 2) (round 7 2))", want: "(3 4 -3 2 4)"},
+		{name: "rounding a float", source: "(list (floor -1.5) (round 2.5) (truncate 2.7))", want: "(-2 2 2)"},
+		{name: "abs", source: "(list (abs -3) (abs 1/2) (abs -2.5))", want: "(3 1/2 2.5)"},
+		{name: "min and max", source: "(list (min 3 1 2) (max 3 1 2) (max 1/2 0.25))", want: "(1 3 1/2)"},
+		{name: "gcd and lcm", source: "(list (gcd 12 18) (lcm 4 6) (gcd) (lcm))", want: "(6 12 0 1)"},
+		{name: "expt", source: "(list (expt 2 10) (expt 2 -2) (expt 2/3 2) (expt 4 0.5))", want: "(1024 1/4 4/9 2.0)"},
+		{name: "a large expt", source: "(length (number->string (expt 10 100)))", want: "101"},
+		{name: "expt of one to a huge power", source: "(list (expt 1 100000000000) (expt -1 100000000001) (expt 0 100000000000))", want: "(1 -1 0)"},
+		{name: "sqrt", source: "(list (sqrt 16) (sqrt 2.25))", want: "(4.0 1.5)"},
+		{name: "1+ and 1-", source: "(list (1+ 1) (1- 1/2))", want: "(2 -1/2)"},
+		{name: "predicates", source: "(list (zerop 0) (zerop 0.0) (evenp 4) (oddp 4) (oddp -3))", want: "(T T T NIL T)"},
+		{name: "bitwise operations", source: "(list (logand 12 10) (logior 12 10) (logxor 12 10))", want: "(8 14 6)"},
+		{name: "ash", source: "(list (ash 1 10) (ash 1024 -3) (ash -8 -1))", want: "(1024 128 -4)"},
+		{name: "ash to a big integer", source: "(ash 1 64)", want: "18446744073709551616"},
+		{name: "ash far right", source: "(ash 5 -100000000000)", want: "0"},
+
+		{name: "expt past the limit", source: "(expt 2 2000000)", want: "expt cannot raise 2 to the power 2000000, the result would exceed 1048576 bits", wantError: "arithmetic-error"},
+		{name: "expt of a ratio past the limit", source: "(expt 1/3 100000000000)", want: "expt cannot raise 1/3", wantError: "arithmetic-error"},
+		{name: "expt of a negative power past the limit", source: "(expt 10 -1000000)", wantError: "arithmetic-error"},
+		{name: "expt of zero to a negative power", source: "(expt 0 -1)", want: "cannot raise zero to the negative power -1", wantError: "division-by-zero"},
+		{name: "ash past the limit", source: "(ash 1 2000000)", want: "ash cannot shift 1 by 2000000, the result would exceed 1048576 bits", wantError: "arithmetic-error"},
+		{name: "the limit errors are arithmetic errors", source: "(handler-case (expt 3 10000000) (arithmetic-error () :caught))", want: ":caught"},
+		{name: "ash of a float", source: "(ash 1.5 1)", want: "ash expects an integer but got 1.5", wantError: "type-error"},
+		{name: "ash by a ratio", source: "(ash 1 1/2)", want: "ash expects an int count", wantError: "type-error"},
+		{name: "sqrt of a negative number", source: "(sqrt -4)", want: "sqrt expects a non negative number but got -4", wantError: "type-error"},
+		{name: "mod by zero", source: "(mod 5 0)", want: "cannot divide 5 by zero", wantError: "division-by-zero"},
+		{name: "floor of a string", source: "(floor \"a\")", want: "floor expects a number", wantError: "type-error"},
+		{name: "gcd of a ratio", source: "(gcd 1/2 2)", want: "gcd expects an integer", wantError: "type-error"},
+		{name: "min without arguments", source: "(min)", want: "min expects at least 1 argument", wantError: "arity-mismatch"},
+		{name: "expt arity", source: "(expt 2)", want: "expt expects 2 arguments but got 1", wantError: "arity-mismatch"},
+		{name: "comparison without arguments", source: "(<)", wantError: "arity-mismatch"},
+	})
+}
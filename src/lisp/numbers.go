@@ -0,0 +1,325 @@
+package lisp
+
+import (
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Numbers form a tower: Int, BigInt, Ratio and Float. Arithmetic converts
+// its operands to the highest of their levels, and integer and rational
+// results are brought back to the lowest level able to hold them, so that
+// an Int overflowing becomes a BigInt and a Ratio of denominator 1 becomes
+// an integer.
+
+type BigInt struct {
+	BaseTypeExpression
+	Value *big.Int
+	Span  Span
+}
+
+func (i BigInt) GetType() string {
+	return "bigint"
+}
+
+func (i BigInt) GetSpan() Span {
+	return i.Span
+}
+
+func (i BigInt) Print() string {
+	return i.Value.String()
+}
+
+func (i BigInt) Evaluate(context EvaluationContext) EvaluationResult {
+	return SuccessfulEvaluationResult{
+		Expression: i,
+	}
+}
+
+// Ratio is an exact fraction whose denominator is never 1.
+type Ratio struct {
+	BaseTypeExpression
+	Value *big.Rat
+	Span  Span
+}
+
+func (r Ratio) GetType() string {
+	return "ratio"
+}
+
+func (r Ratio) GetSpan() Span {
+	return r.Span
+}
+
+func (r Ratio) Print() string {
+	return r.Value.Num().String() + "/" + r.Value.Denom().String()
+}
+
+func (r Ratio) Evaluate(context EvaluationContext) EvaluationResult {
+	return SuccessfulEvaluationResult{
+		Expression: r,
+	}
+}
+
+type Float struct {
+	BaseTypeExpression
+	Value float64
+	Span  Span
+}
+
+func (f Float) GetType() string {
+	return "float"
+}
+
+func (f Float) GetSpan() Span {
+	return f.Span
+}
+
+// Print always renders a decimal point or an exponent, so that the reader
+// reads the result back as a float.
+func (f Float) Print() string {
+	result := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(result, ".eIN") {
+		result += ".0"
+	}
+	return result
+}
+
+func (f Float) Evaluate(context EvaluationContext) EvaluationResult {
+	return SuccessfulEvaluationResult{
+		Expression: f,
+	}
+}
+
+const (
+	intLevel = iota
+	bigIntLevel
+	ratioLevel
+	floatLevel
+)
+
+func numberLevel(expression Expression) (int, bool) {
+	switch expression.(type) {
+	case Int:
+		return intLevel, true
+	case BigInt:
+		return bigIntLevel, true
+	case Ratio:
+		return ratioLevel, true
+	case Float:
+		return floatLevel, true
+	}
+	return 0, false
+}
+
+func isNumber(expression Expression) bool {
+	_, ok := numberLevel(expression)
+	return ok
+}
+
+func expectNumbers(functionName string, arguments []Expression) ([]Expression, EvaluationResult) {
+	for _, argument := range arguments {
+		if !isNumber(argument) {
+			return nil, newEvaluationError(TypeMismatchError, Span{}, "%s expects a number but got %s", functionName, describe(argument))
+		}
+	}
+	return arguments, nil
+}
+
+func toBigInt(expression Expression) *big.Int {
+	switch n := expression.(type) {
+	case Int:
+		return big.NewInt(int64(n.Value))
+	case BigInt:
+		return n.Value
+	}
+	return nil
+}
+
+func toRat(expression Expression) *big.Rat {
+	switch n := expression.(type) {
+	case Int:
+		return new(big.Rat).SetInt64(int64(n.Value))
+	case BigInt:
+		return new(big.Rat).SetInt(n.Value)
+	case Ratio:
+		return n.Value
+	}
+	return nil
+}
+
+func toFloat(expression Expression) float64 {
+	switch n := expression.(type) {
+	case Int:
+		return float64(n.Value)
+	case BigInt:
+		result, _ := new(big.Float).SetInt(n.Value).Float64()
+		return result
+	case Ratio:
+		result, _ := n.Value.Float64()
+		return result
+	case Float:
+		return n.Value
+	}
+	return math.NaN()
+}
+
+// normalizeBigInt returns an Int when the value fits in one.
+func normalizeBigInt(value *big.Int) Expression {
+	if value.IsInt64() && int64(int(value.Int64())) == value.Int64() {
+		return Int{Value: int(value.Int64())}
+	}
+	return BigInt{Value: value}
+}
+
+// normalizeRat returns an integer when the denominator is 1.
+func normalizeRat(value *big.Rat) Expression {
+	if value.IsInt() {
+		return normalizeBigInt(new(big.Int).Set(value.Num()))
+	}
+	return Ratio{Value: value}
+}
+
+func commonLevel(a Expression, b Expression) int {
+	levelA, _ := numberLevel(a)
+	levelB, _ := numberLevel(b)
+	if levelA > levelB {
+		return levelA
+	}
+	return levelB
+}
+
+func addNumbers(a Expression, b Expression) Expression {
+	switch commonLevel(a, b) {
+	case intLevel:
+		x, y := a.(Int).Value, b.(Int).Value
+		result := x + y
+		if (result > x) == (y > 0) {
+			return Int{Value: result}
+		}
+	case ratioLevel:
+		return normalizeRat(new(big.Rat).Add(toRat(a), toRat(b)))
+	case floatLevel:
+		return Float{Value: toFloat(a) + toFloat(b)}
+	}
+	return normalizeBigInt(new(big.Int).Add(toBigInt(a), toBigInt(b)))
+}
+
+func subtractNumbers(a Expression, b Expression) Expression {
+	switch commonLevel(a, b) {
+	case intLevel:
+		x, y := a.(Int).Value, b.(Int).Value
+		result := x - y
+		if (result < x) == (y > 0) {
+			return Int{Value: result}
+		}
+	case ratioLevel:
+		return normalizeRat(new(big.Rat).Sub(toRat(a), toRat(b)))
+	case floatLevel:
+		return Float{Value: toFloat(a) - toFloat(b)}
+	}
+	return normalizeBigInt(new(big.Int).Sub(toBigInt(a), toBigInt(b)))
+}
+
+func multiplyNumbers(a Expression, b Expression) Expression {
+	switch commonLevel(a, b) {
+	case intLevel:
+		x, y := a.(Int).Value, b.(Int).Value
+		result := x * y
+		if x == 0 || (result/x == y && !(x == -1 && y == math.MinInt) && !(y == -1 && x == math.MinInt)) {
+			return Int{Value: result}
+		}
+	case ratioLevel:
+		return normalizeRat(new(big.Rat).Mul(toRat(a), toRat(b)))
+	case floatLevel:
+		return Float{Value: toFloat(a) * toFloat(b)}
+	}
+	return normalizeBigInt(new(big.Int).Mul(toBigInt(a), toBigInt(b)))
+}
+
+func isZero(expression Expression) bool {
+	switch n := expression.(type) {
+	case Int:
+		return n.Value == 0
+	case BigInt:
+		return n.Value.Sign() == 0
+	case Ratio:
+		return n.Value.Sign() == 0
+	case Float:
+		return n.Value == 0
+	}
+	return false
+}
+
+// divideNumbers divides exactly: the quotient of two integers is a Ratio
+// when the division is not exact.
+func divideNumbers(a Expression, b Expression) (Expression, EvaluationResult) {
+	if isZero(b) {
+		return nil, newEvaluationError(DivisionByZeroError, Span{}, "cannot divide %s by zero", a.Print())
+	}
+
+	if commonLevel(a, b) == floatLevel {
+		return Float{Value: toFloat(a) / toFloat(b)}, nil
+	}
+	return normalizeRat(new(big.Rat).Quo(toRat(a), toRat(b))), nil
+}
+
+// compareNumbers returns -1, 0 or 1 depending on whether a is lower than,
+// equal to or greater than b.
+func compareNumbers(a Expression, b Expression) int {
+	switch commonLevel(a, b) {
+	case intLevel:
+		x, y := a.(Int).Value, b.(Int).Value
+		if x < y {
+			return -1
+		} else if x > y {
+			return 1
+		}
+		return 0
+	case floatLevel:
+		x, y := toFloat(a), toFloat(b)
+		if x < y {
+			return -1
+		} else if x > y {
+			return 1
+		}
+		return 0
+	}
+	return toRat(a).Cmp(toRat(b))
+}
+
+var ratioPattern = regexp.MustCompile(`^[+-]?[0-9]+/[0-9]+$`)
+var floatPattern = regexp.MustCompile(`^[+-]?([0-9]*\.[0-9]+([eE][+-]?[0-9]+)?|[0-9]+(\.[0-9]*)?[eE][+-]?[0-9]+)$`)
+var integerPattern = regexp.MustCompile(`^[+-]?[0-9]+$`)
+
+// parseNumber reads the number written in a token, if the token is one.
+func parseNumber(text string, span Span) (Expression, bool, error) {
+	if integerPattern.MatchString(text) {
+		if intValue, err := strconv.Atoi(text); err == nil {
+			return Int{Value: intValue, Span: span}, true, nil
+		}
+		value, _ := new(big.Int).SetString(text, 10)
+		return BigInt{Value: value, Span: span}, true, nil
+	}
+
+	if ratioPattern.MatchString(text) {
+		parts := strings.SplitN(text, "/", 2)
+		if strings.TrimLeft(parts[1], "0") == "" {
+			return nil, true, SyntaxError{Message: "the ratio " + text + " has a zero denominator", Span: span}
+		}
+		value, _ := new(big.Rat).SetString(text)
+		return withSpan(normalizeRat(value), span), true, nil
+	}
+
+	if floatPattern.MatchString(text) {
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, true, SyntaxError{Message: "the float " + text + " is out of range", Span: span}
+		}
+		return Float{Value: value, Span: span}, true, nil
+	}
+
+	return nil, false, nil
+}
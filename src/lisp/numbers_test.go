@@ -0,0 +1,45 @@
+package lisp
+
+import (
+	"testing"
+)
+
+func TestNumbers(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "integers", source: "(+ 1 2)", want: "3"},
+		{name: "floats", source: "1.5", want: "1.5"},
+		{name: "float arithmetic", source: "(* 1.5 2.0)", want: "3.0"},
+		{name: "ratios", source: "1/3", want: "1/3"},
+		{name: "ratios are normalized", source: "4/6", want: "2/3"},
+		{name: "whole ratios are integers", source: "6/3", want: "2"},
+		{name: "division of integers", source: "(/ 1 3)", want: "1/3"},
+		{name: "exact division", source: "(/ 6 3)", want: "2"},
+		{name: "ratio arithmetic", source: "(+ 1/3 2/3)", want: "1"},
+		{name: "integers and ratios", source: "(* 3 1/6)", want: "1/2"},
+		{name: "floats are contagious", source: "(+ 1/2 0.25)", want: "0.75"},
+		{name: "integers and floats", source: "(+ 1 0.5)", want: "1.5"},
+		{name: "negative numbers", source: "(- 5)", want: "-5"},
+		{name: "reciprocal", source: "(/ 4)", want: "1/4"},
+
+		{name: "big integers", source: "123456789012345678901234567890", want: "123456789012345678901234567890"},
+		{name: "overflow to a big integer", source: "(* 4611686018427387904 4)", want: "18446744073709551616"},
+		{name: "addition overflow", source: "(+ 9223372036854775807 1)", want: "9223372036854775808"},
+		{name: "big integers shrink back", source: "(- (+ 9223372036854775807 1) 1)", want: "9223372036854775807"},
+		{name: "big integer arithmetic", source: "(- 100000000000000000000 1)", want: "99999999999999999999"},
+		{name: "factorial", source: "(defun f (n) (if (= n 0) 1 (* n (f (- n 1))))) (f 25)", want: "15511210043330985984000000"},
+
+		{name: "comparison across types", source: "(list (= 1 1.0) (< 1/3 0.5) (> 100000000000000000000 1))", want: "(T T T)"},
+		{name: "eql distinguishes types", source: "(list (eql 1 1) (eql 1 1.0) (eql 1/2 1/2))", want: "(T NIL T)"},
+		{name: "type-of", source: "(list (type-of 1) (type-of 1.5) (type-of 1/2) (type-of 100000000000000000000))", want: "(integer float ratio integer)"},
+		{name: "numberp", source: "(list (numberp 1/2) (numberp 1.0) (numberp 'a))", want: "(T T NIL)"},
+
+		{name: "a number and a string", source: "(+ 1 \"a\")", want: "+ expects a number but got \"a\" (string)", wantError: "type-error"},
+		{name: "a ratio and a symbol", source: "(* 1/2 'a)", want: "* expects a number", wantError: "type-error"},
+		{name: "integer division by zero", source: "(/ 1 0)", want: "cannot divide 1 by zero", wantError: "division-by-zero"},
+		{name: "ratio division by zero", source: "(/ 1/2 0)", wantError: "division-by-zero"},
+		{name: "float division by zero", source: "(/ 1.5 0.0)", wantError: "division-by-zero"},
+		{name: "a zero denominator", source: "1/0", wantError: "reader-error"},
+		{name: "subtraction without arguments", source: "(-)", want: "- expects at least 1 argument", wantError: "arity-mismatch"},
+		{name: "comparison of a symbol", source: "(< 1 'a)", wantError: "type-error"},
+	})
+}
@@ -0,0 +1,404 @@
+package lisp
+
+import (
+	"strings"
+)
+
+// Packages are namespaces for the symbols read by the reader, and thus for
+// the functions, macros, global variables, structures and condition types
+// they name. The reader qualifies the symbols it reads in the package
+// selected by in-package: foo read in the package geometry is the symbol
+// geometry::foo, unless foo is exported by a package geometry uses, in
+// which case it is that package's symbol. A name may also be qualified
+// explicitly: pkg:name refers to a name exported by pkg, pkg::name to any
+// name of pkg. The symbols of cl-user, the package selected at first, keep
+// their plain names, as do keywords and the names of builtins, special
+// forms, builtin types and standard variables, which are shared by all
+// packages and cannot be redefined. symbol-name returns the name of a
+// symbol without its package.
+
+// Package is a namespace for symbols.
+type Package struct {
+	name    string
+	exports map[string]bool
+	uses    []*Package
+}
+
+type packageRegistry struct {
+	packages map[string]*Package
+	current  *Package
+}
+
+const defaultPackageName = "cl-user"
+
+// standardPackageNames are the names of the builtins, which every package
+// sees, so that (:use :cl) is accepted and does nothing.
+var standardPackageNames = map[string]bool{"cl": true, "common-lisp": true, "lisp": true}
+
+func newPackageRegistry() *packageRegistry {
+	registry := &packageRegistry{packages: make(map[string]*Package)}
+	registry.current = registry.define(defaultPackageName)
+	return registry
+}
+
+// define returns the package of a name, creating it when needed.
+func (registry *packageRegistry) define(name string) *Package {
+	if p, ok := registry.packages[name]; ok {
+		return p
+	}
+	p := &Package{name: name, exports: make(map[string]bool)}
+	registry.packages[name] = p
+	return p
+}
+
+func (p *Package) use(used *Package) {
+	if used == p {
+		return
+	}
+	for _, u := range p.uses {
+		if u == used {
+			return
+		}
+	}
+	p.uses = append(p.uses, used)
+}
+
+// defaultPackage returns cl-user, the package of the plain names.
+func (context EvaluationContext) defaultPackage() *Package {
+	return context.Global().packages.packages[defaultPackageName]
+}
+
+// splitQualifiedName splits pkg:name and pkg::name. Keywords are not
+// qualified names.
+func splitQualifiedName(name string) (packageName string, symbolName string, internal bool, ok bool) {
+	index := strings.Index(name, ":")
+	if index <= 0 {
+		return "", name, false, false
+	}
+	symbolName = name[index+1:]
+	if strings.HasPrefix(symbolName, ":") {
+		return name[:index], symbolName[1:], true, true
+	}
+	return name[:index], symbolName, false, true
+}
+
+// unqualifiedName returns a name without its package.
+func unqualifiedName(name string) string {
+	_, symbolName, _, _ := splitQualifiedName(name)
+	return symbolName
+}
+
+// standardTypeNames are the names of the builtin types, as returned by
+// type-of and used as specializers.
+var standardTypeNames = map[string]bool{
+	"t": true, "integer": true, "int": true, "bigint": true, "ratio": true, "rational": true, "float": true,
+	"real": true, "number": true, "boolean": true, "null": true, "cons": true, "list": true, "sequence": true,
+	"string": true, "vector": true, "hash-table": true, "symbol": true, "keyword": true, "function": true,
+	"macro": true, "builtin": true, "structure": true, "structure-object": true,
+}
+
+// isStandardName reports whether a name is shared by all packages.
+func isStandardName(name string) bool {
+	_, builtin := builtinFunctions[name]
+	_, interpreterBuiltin := interpreterBuiltins[name]
+	_, special := specialForms[name]
+	return builtin || interpreterBuiltin || special || standardTypeNames[name] || isBuiltinConditionType(name) ||
+		strings.HasPrefix(name, "&") || name == "unquote" || name == "unquote-splicing" || name == featuresVariable
+}
+
+// readName returns the name of the symbol the reader reads as name in the
+// current package, as explained at the top of this file. A qualified name
+// which cannot be resolved yet, such as the name of a package which does
+// not exist, is kept as written, and fails when it is looked up.
+func (registry *packageRegistry) readName(name string) string {
+	if strings.HasPrefix(name, ":") || isStandardName(name) {
+		return name
+	}
+
+	if packageName, symbolName, internal, ok := splitQualifiedName(name); ok {
+		if standardPackageNames[packageName] && isStandardName(symbolName) {
+			return symbolName
+		}
+		p, exists := registry.packages[packageName]
+		if !exists || (!internal && !p.exports[symbolName]) {
+			return name
+		}
+		return p.qualify(symbolName)
+	}
+
+	for _, used := range registry.current.uses {
+		if used.exports[name] {
+			return used.qualify(name)
+		}
+	}
+	return registry.current.qualify(name)
+}
+
+// qualify returns the name of the symbol of a package, which is plain in
+// cl-user.
+func (p *Package) qualify(name string) string {
+	if p.name == defaultPackageName {
+		return name
+	}
+	return p.name + "::" + name
+}
+
+// setfAccessor returns the accessor of the name of a setf function.
+func setfAccessor(name string) (string, bool) {
+	if strings.HasPrefix(name, "(setf ") && strings.HasSuffix(name, ")") {
+		return name[len("(setf ") : len(name)-1], true
+	}
+	return "", false
+}
+
+// functionKeys returns the keys under which a function name may be found
+// in the functions of the global frame, in the order they are tried. The
+// setf function of an accessor, named (setf accessor), lives in the
+// package of the accessor.
+func (context EvaluationContext) functionKeys(name string) []string {
+	if accessor, ok := setfAccessor(name); ok {
+		keys := []string{}
+		for _, key := range context.functionKeys(accessor) {
+			index := strings.Index(key, "::")
+			keys = append(keys, key[:index+2]+setfFunctionName(key[index+2:]))
+		}
+		return keys
+	}
+
+	if packageName, symbolName, internal, ok := splitQualifiedName(name); ok {
+		p, exists := context.Global().packages.packages[packageName]
+		if !exists || (!internal && !p.exports[symbolName]) {
+			return nil
+		}
+		return []string{p.name + "::" + symbolName}
+	}
+
+	current := context.defaultPackage()
+	keys := []string{current.name + "::" + name}
+	for _, used := range current.uses {
+		if used.exports[name] {
+			keys = append(keys, used.name+"::"+name)
+		}
+	}
+	return keys
+}
+
+// definitionKey returns the key under which a function is defined: in the
+// package its name is qualified with, or else in cl-user.
+func (context EvaluationContext) definitionKey(name string) string {
+	if accessor, ok := setfAccessor(name); ok {
+		key := context.definitionKey(accessor)
+		index := strings.Index(key, "::")
+		return key[:index+2] + setfFunctionName(key[index+2:])
+	}
+
+	if packageName, symbolName, _, ok := splitQualifiedName(name); ok {
+		if _, exists := context.Global().packages.packages[packageName]; exists {
+			return packageName + "::" + symbolName
+		}
+	}
+	return context.defaultPackage().name + "::" + name
+}
+
+// checkDefinable fails when a form would define a function named like a
+// builtin, or qualified with a package which does not exist.
+func checkDefinable(formName string, name string, span Span, context EvaluationContext) EvaluationResult {
+	if accessor, ok := setfAccessor(name); ok {
+		name = accessor
+	}
+
+	_, builtin := builtinFunctions[name]
+	_, interpreterBuiltin := interpreterBuiltins[name]
+	_, special := specialForms[name]
+	if builtin || interpreterBuiltin || special {
+		return newEvaluationError(InvalidFormError, span, "%s cannot redefine %s, which is built in", formName, name)
+	}
+
+	if packageName, _, _, ok := splitQualifiedName(name); ok {
+		if _, exists := context.Global().packages.packages[packageName]; !exists {
+			return newEvaluationError(InvalidFormError, span, "%s cannot define %s: the package %s does not exist", formName, name, packageName)
+		}
+	}
+	return nil
+}
+
+// designatorName returns the name designated by a symbol, a keyword or a
+// string, as in (in-package :name) or (export '(name "other")). The package
+// of a symbol is ignored.
+func designatorName(expression Expression) (string, bool) {
+	switch value := expression.(type) {
+	case Symbol:
+		return strings.TrimPrefix(unqualifiedName(value.Name()), ":"), true
+	case String:
+		return value.Value, true
+	}
+	return "", false
+}
+
+func designatorNames(formName string, what string, expressions []Expression) ([]string, EvaluationResult) {
+	names := []string{}
+	for _, expression := range expressions {
+		name, ok := designatorName(expression)
+		if !ok {
+			return nil, newEvaluationError(TypeMismatchError, expression.GetSpan(), "%s expects a %s name but got %s", formName, what, describe(expression))
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// findPackage returns the existing package designated by an expression.
+func findPackage(formName string, expression Expression, context EvaluationContext) (*Package, EvaluationResult) {
+	name, ok := designatorName(expression)
+	if !ok {
+		return nil, newEvaluationError(TypeMismatchError, expression.GetSpan(), "%s expects a package name but got %s", formName, describe(expression))
+	}
+	p, ok := context.Global().packages.packages[name]
+	if !ok {
+		return nil, newEvaluationError(InvalidFormError, expression.GetSpan(), "%s: the package %s does not exist", formName, name)
+	}
+	return p, nil
+}
+
+// defpackageFunction implements (defpackage name [(:use package...)]
+// [(:export name...)]). Its arguments are not evaluated. Defining a package
+// again adds to its uses and exports.
+func defpackageFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) == 0 {
+		return newEvaluationError(ArityMismatchError, span, "defpackage expects a package name")
+	}
+
+	name, ok := designatorName(arguments[0])
+	if !ok {
+		return newEvaluationError(TypeMismatchError, arguments[0].GetSpan(), "defpackage expects a package name but got %s", describe(arguments[0]))
+	}
+
+	registry := context.Global().packages
+	uses := []*Package{}
+	exports := []string{}
+	for _, option := range arguments[1:] {
+		elements, _ := listToSlice(option)
+		if len(elements) == 0 {
+			return newEvaluationError(InvalidFormError, option.GetSpan(), "defpackage expects an option of the form (:option value...) but got %s", option.Print())
+		}
+
+		switch {
+		case isFormSymbol(elements[0], ":use"):
+			for _, used := range elements[1:] {
+				if name, ok := designatorName(used); ok && standardPackageNames[name] {
+					continue
+				}
+				p, failure := findPackage("defpackage", used, context)
+				if failure != nil {
+					return failure
+				}
+				uses = append(uses, p)
+			}
+		case isFormSymbol(elements[0], ":export"):
+			names, failure := designatorNames("defpackage", "function", elements[1:])
+			if failure != nil {
+				return failure
+			}
+			exports = append(exports, names...)
+		default:
+			return newEvaluationError(InvalidFormError, option.GetSpan(), "defpackage does not support the option %s", elements[0].Print())
+		}
+	}
+
+	p := registry.define(name)
+	for _, used := range uses {
+		p.use(used)
+	}
+	for _, exported := range exports {
+		p.exports[exported] = true
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: String{Value: name},
+	}
+}
+
+// inPackageFunction implements (in-package name), which selects the
+// package of the top level code that follows.
+func inPackageFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) != 1 {
+		return newEvaluationError(ArityMismatchError, span, "in-package expects 1 argument but got %d", len(arguments))
+	}
+
+	p, failure := findPackage("in-package", arguments[0], context)
+	if failure != nil {
+		return failure
+	}
+	context.Global().packages.current = p
+
+	return SuccessfulEvaluationResult{
+		Expression: String{Value: p.name},
+	}
+}
+
+// packageArguments reads the arguments of export and use-package: a name
+// or a list of names, then an optional package which defaults to the
+// current one.
+func packageArguments(formName string, arguments []Expression, global EvaluationContext) ([]Expression, *Package, EvaluationResult) {
+	if len(arguments) < 1 || len(arguments) > 2 {
+		return nil, nil, newEvaluationError(ArityMismatchError, Span{}, "%s expects between 1 and 2 arguments but got %d", formName, len(arguments))
+	}
+
+	p := global.Global().packages.current
+	if len(arguments) == 2 {
+		var failure EvaluationResult
+		if p, failure = findPackage(formName, arguments[1], global); failure != nil {
+			return nil, nil, failure
+		}
+	}
+
+	if elements, ok := listToSlice(arguments[0]); ok && !isNil(arguments[0]) {
+		return elements, p, nil
+	}
+	return []Expression{arguments[0]}, p, nil
+}
+
+// exportFunction implements (export names [package]), where names is a
+// name or a list of names.
+func exportFunction(arguments []Expression, global EvaluationContext) EvaluationResult {
+	designators, p, failure := packageArguments("export", arguments, global)
+	if failure != nil {
+		return failure
+	}
+	names, failure := designatorNames("export", "function", designators)
+	if failure != nil {
+		return failure
+	}
+
+	for _, name := range names {
+		p.exports[name] = true
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: Boolean{Value: true},
+	}
+}
+
+// usePackageFunction implements (use-package packages [package]), where
+// packages is a package name or a list of them.
+func usePackageFunction(arguments []Expression, global EvaluationContext) EvaluationResult {
+	designators, p, failure := packageArguments("use-package", arguments, global)
+	if failure != nil {
+		return failure
+	}
+
+	for _, designator := range designators {
+		if name, ok := designatorName(designator); ok && standardPackageNames[name] {
+			continue
+		}
+		used, failure := findPackage("use-package", designator, global)
+		if failure != nil {
+			return failure
+		}
+		p.use(used)
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: Boolean{Value: true},
+	}
+}
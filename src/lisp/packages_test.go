@@ -0,0 +1,69 @@
+package lisp
+
+import (
+	"testing"
+)
+
+const geometryPackage = `
+	(defpackage :geometry (:use :cl) (:export area *unit*))
+	(in-package :geometry)
+	(defvar *unit* 1)
+	(defvar *hidden* 2)
+	(defun helper (x) (* x x *unit*))
+	(defun area (side) (helper side))
+	(in-package :cl-user)
+`
+
+func TestPackages(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "an exported function", source: geometryPackage + "(geometry:area 3)", want: "9"},
+		{name: "an internal function", source: geometryPackage + "(geometry::helper 2)", want: "4"},
+		{name: "an exported variable", source: geometryPackage + "geometry:*unit*", want: "1"},
+		{name: "an internal variable", source: geometryPackage + "geometry::*hidden*", want: "2"},
+		{name: "names are not shared", source: geometryPackage + "(defun helper (x) :mine) (list (helper 1) (geometry:area 2))", want: "(:mine 4)"},
+		{name: "variables are not shared", source: geometryPackage + "(setq *unit* 10) (list *unit* (geometry:area 1))", want: "(10 1)"},
+		{name: "use-package", source: geometryPackage + "(use-package :geometry) (area 2)", want: "4"},
+		{name: "the :use option", source: geometryPackage + "(defpackage :app (:use :geometry)) (in-package :app) (area 5)", want: "25"},
+		{name: "export", source: geometryPackage + "(in-package :geometry) (export 'helper) (in-package :cl-user) (geometry:helper 3)", want: "9"},
+		{name: "export as a function", source: geometryPackage + "(mapc #'export '(helper) '(:geometry)) (geometry:helper 3)", want: "9"},
+		{name: "apply of use-package", source: geometryPackage + "(apply #'use-package (list :geometry)) (area 2)", want: "4"},
+		{name: "export to a package", source: geometryPackage + "(export \"helper\" :geometry) (geometry:helper 3)", want: "9"},
+		{name: "symbols are qualified", source: geometryPackage + "(list (eq 'geometry::helper 'helper) (symbol-name 'geometry::helper))", want: "(NIL helper)"},
+		{name: "intern in the current package", source: geometryPackage + "(in-package :geometry) (eq (intern \"helper\") 'helper)", want: "T"},
+		{name: "keywords are shared", source: "(defpackage :p) (in-package :p) (eq :key (intern \":key\"))", want: "T"},
+		{name: "builtins are shared", source: "(defpackage :p) (in-package :p) (list (car '(1)) (eq 'car 'cl:car))", want: "(1 T)"},
+		{name: "structures in a package", source: "(defpackage :shapes (:export make-circle circle-r)) (in-package :shapes) (defstruct circle r) (in-package :cl-user) (shapes:circle-r (shapes:make-circle :r 3))", want: "3"},
+		{name: "#S in a package", source: "(defpackage :shapes) (in-package :shapes) (defstruct circle r) (in-package :cl-user) (shapes::circle-r #S(shapes::circle :r 4))", want: "4"},
+		{name: "conditions in a package", source: "(defpackage :errors (:export oops)) (in-package :errors) (define-condition oops (error)) (in-package :cl-user) (handler-case (error 'errors:oops) (errors:oops () :caught))", want: ":caught"},
+		{name: "defpackage again adds exports", source: geometryPackage + "(defpackage :geometry (:export helper)) (geometry:helper 2)", want: "4"},
+		{name: "defpackage returns the name", source: "(defpackage :p)", want: "p"},
+		{name: "in-package returns the name", source: "(defpackage :p) (in-package \"p\")", want: "p"},
+
+		{name: "an unexported function", source: geometryPackage + "(geometry:helper 2)", want: "the function geometry:helper is undefined", wantError: "undefined-function"},
+		{name: "an unexported variable", source: geometryPackage + "geometry:*hidden*", want: "the variable geometry:*hidden* is unbound", wantError: "unbound-variable"},
+		{name: "an internal name without a package", source: geometryPackage + "(helper 2)", want: "the function helper is undefined", wantError: "undefined-function"},
+		{name: "an unknown package", source: "(nowhere:f 1)", want: "the function nowhere:f is undefined", wantError: "undefined-function"},
+		{name: "defining in an unknown package", source: "(defun nowhere::f () 1)", want: "defun cannot define nowhere::f: the package nowhere does not exist", wantError: "invalid-form"},
+		{name: "redefining a builtin", source: "(defpackage :p) (in-package :p) (defun car (x) x)", want: "defun cannot redefine car, which is built in", wantError: "invalid-form"},
+		{name: "in-package of an unknown package", source: "(in-package :nowhere)", want: "in-package: the package nowhere does not exist", wantError: "invalid-form"},
+		{name: "in-package arity", source: "(in-package)", want: "in-package expects 1 argument but got 0", wantError: "arity-mismatch"},
+		{name: "in-package of a number", source: "(in-package 1)", want: "in-package expects a package name but got 1", wantError: "type-error"},
+		{name: "using an unknown package", source: "(defpackage :p (:use :nowhere))", want: "defpackage: the package nowhere does not exist", wantError: "invalid-form"},
+		{name: "an unknown defpackage option", source: "(defpackage :p (:nicknames :q))", want: "defpackage does not support the option :nicknames", wantError: "invalid-form"},
+		{name: "a bad defpackage option", source: "(defpackage :p :use)", want: "defpackage expects an option of the form (:option value...)", wantError: "invalid-form"},
+		{name: "defpackage without a name", source: "(defpackage)", want: "defpackage expects a package name", wantError: "arity-mismatch"},
+		{name: "export of a number", source: "(export 1)", want: "export expects a function name but got 1", wantError: "type-error"},
+		{name: "export arity", source: "(export)", want: "export expects between 1 and 2 arguments but got 0", wantError: "arity-mismatch"},
+		{name: "use-package of an unknown package", source: "(use-package :nowhere)", want: "use-package: the package nowhere does not exist", wantError: "invalid-form"},
+	})
+}
+
+func TestCurrentPackagePersists(t *testing.T) {
+	interpreter := NewInterpreter(InterpreterOptions{})
+
+	checkResult(t, evalTest{want: "p"}, interpreter.Eval("(defpackage :p) (in-package :p)"))
+	checkResult(t, evalTest{want: "#p::f"}, interpreter.Eval("(defun f () :p)"))
+	checkResult(t, evalTest{want: ":p"}, interpreter.Eval("(f)"))
+	checkResult(t, evalTest{want: "cl-user"}, interpreter.Eval("(in-package :cl-user)"))
+	checkResult(t, evalTest{want: "the function f is undefined", wantError: "undefined-function"}, interpreter.Eval("(f)"))
+}
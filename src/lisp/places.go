@@ -0,0 +1,331 @@
+package lisp
+
+// Places are the locations setf can assign: variables, and the forms
+// calling an accessor with a setter in placeSetters, such as (car x) or
+// (nth 2 x), or with a setf function, such as the accessors of structures.
+
+// placeSetters maps the accessors that can be used as places to their
+// setters, which receive the evaluated arguments of the accessor, the new
+// value and the global context, and return the value.
+var placeSetters map[string]func(arguments []Expression, value Expression, global EvaluationContext) EvaluationResult
+
+// place is a resolved place: its subforms have already been evaluated, so
+// that reading and then writing it evaluates them only once.
+type place struct {
+	form      Expression
+	variable  string
+	accessor  string
+	arguments []Expression
+	context   EvaluationContext
+}
+
+// resolvePlace evaluates the subforms of a place. Macro calls are expanded
+// until they are a variable or an accessor call.
+func resolvePlace(functionName string, form Expression, context EvaluationContext) (place, EvaluationResult) {
+	for {
+		macro, ok := lookupMacro(form, context)
+		if !ok {
+			break
+		}
+		expansionResult := expandMacro(macro, form.(*List))
+		if !expansionResult.IsSuccessful() {
+			return place{}, expansionResult
+		}
+		form = expansionResult.(SuccessfulEvaluationResult).Expression
+	}
+
+	if symbol, ok := form.(Symbol); ok && !symbol.IsKeyword() {
+		if failure := checkAssignable(functionName, symbol, context); failure != nil {
+			return place{}, failure
+		}
+		return place{form: form, variable: symbol.Name(), context: context}, nil
+	}
+
+	list, ok := form.(*List)
+	if !ok {
+		return place{}, newEvaluationError(InvalidFormError, form.GetSpan(), "%s cannot assign %s, which is not a place", functionName, describe(form))
+	}
+
+	head, ok := list.left.(Symbol)
+	if ok && placeSetters[head.Name()] == nil {
+		_, ok = context.LookupFunction(setfFunctionName(head.Name()))
+	}
+	if !ok {
+		return place{}, newEvaluationError(InvalidFormError, form.GetSpan(), "%s cannot assign %s, which is not a place", functionName, form.Print())
+	}
+
+	forms, ok := listToSlice(list.right)
+	if !ok {
+		return place{}, newEvaluationError(InvalidFormError, form.GetSpan(), "%s cannot assign the dotted list %s", functionName, form.Print())
+	}
+
+	arguments := []Expression{}
+	for _, argument := range forms {
+		evaluationResult := argument.Evaluate(context)
+		if !evaluationResult.IsSuccessful() {
+			return place{}, evaluationResult
+		}
+		arguments = append(arguments, evaluationResult.(SuccessfulEvaluationResult).Expression)
+	}
+
+	return place{form: form, accessor: head.Name(), arguments: arguments, context: context}, nil
+}
+
+// get reads the current value of a place.
+func (p place) get() EvaluationResult {
+	if p.variable != "" {
+		return p.form.Evaluate(p.context)
+	}
+
+	accessor, ok := lookupFunctionValue(p.accessor, p.context)
+	if !ok {
+		return newEvaluationError(UnknownFunctionError, p.form.GetSpan(), "the function %s is undefined", p.accessor)
+	}
+	return applyFunction(accessor, p.arguments, p.form.GetSpan())
+}
+
+// set assigns a new value to a place.
+func (p place) set(value Expression) EvaluationResult {
+	if p.variable != "" {
+		p.context.SetVariable(p.variable, value)
+		return SuccessfulEvaluationResult{Expression: value}
+	}
+
+	setter, ok := placeSetters[p.accessor]
+	if !ok {
+		function, ok := p.context.LookupFunction(setfFunctionName(p.accessor))
+		if !ok {
+			return newEvaluationError(UnknownFunctionError, p.form.GetSpan(), "the function %s is undefined", setfFunctionName(p.accessor))
+		}
+		return applyFunction(function, append([]Expression{value}, p.arguments...), p.form.GetSpan())
+	}
+
+	result := setter(p.arguments, value, p.context.Global())
+	if failure, ok := result.(UnsuccessfulEvaluationResult); ok && failure.Error.Span.IsZero() {
+		failure.Error.Span = p.form.GetSpan()
+	}
+	return result
+}
+
+// setfFunction implements (setf place value ...), which assigns each place
+// in turn and returns the last value.
+func setfFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments)%2 != 0 {
+		return newEvaluationError(ArityMismatchError, span, "setf expects places and values in pairs but got %s", countArguments(len(arguments)))
+	}
+
+	var result EvaluationResult = SuccessfulEvaluationResult{Expression: Boolean{Value: false, Span: span}}
+
+	for i := 0; i < len(arguments); i += 2 {
+		target, failure := resolvePlace("setf", arguments[i], context)
+		if failure != nil {
+			return failure
+		}
+
+		evaluationResult := arguments[i+1].Evaluate(context)
+		if !evaluationResult.IsSuccessful() {
+			return evaluationResult
+		}
+
+		result = target.set(evaluationResult.(SuccessfulEvaluationResult).Expression)
+		if !result.IsSuccessful() {
+			return result
+		}
+	}
+
+	return result
+}
+
+// pushFunction implements (push item place), which conses item onto the
+// list stored in place.
+func pushFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) != 2 {
+		return newEvaluationError(ArityMismatchError, span, "push expects an item and a place but got %s", countArguments(len(arguments)))
+	}
+
+	itemResult := arguments[0].Evaluate(context)
+	if !itemResult.IsSuccessful() {
+		return itemResult
+	}
+
+	target, failure := resolvePlace("push", arguments[1], context)
+	if failure != nil {
+		return failure
+	}
+
+	currentResult := target.get()
+	if !currentResult.IsSuccessful() {
+		return currentResult
+	}
+
+	return target.set(&List{
+		left:  itemResult.(SuccessfulEvaluationResult).Expression,
+		right: currentResult.(SuccessfulEvaluationResult).Expression,
+		Span:  span,
+	})
+}
+
+// popFunction implements (pop place), which removes the first element of
+// the list stored in place and returns it.
+func popFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) != 1 {
+		return newEvaluationError(ArityMismatchError, span, "pop expects a place but got %s", countArguments(len(arguments)))
+	}
+
+	target, failure := resolvePlace("pop", arguments[0], context)
+	if failure != nil {
+		return failure
+	}
+
+	currentResult := target.get()
+	if !currentResult.IsSuccessful() {
+		return currentResult
+	}
+
+	current := currentResult.(SuccessfulEvaluationResult).Expression
+	if isNil(current) {
+		return currentResult
+	}
+	cons, ok := current.(*List)
+	if !ok {
+		return newEvaluationError(TypeMismatchError, span, "pop expects a list but got %s", describe(current))
+	}
+
+	if failure := target.set(cons.right); !failure.IsSuccessful() {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: cons.left,
+	}
+}
+
+// incrementFunction implements (incf place [delta]), when sign is 1, and
+// (decf place [delta]), when sign is -1.
+func incrementFunction(functionName string, sign int) specialForm {
+	return func(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+		if len(arguments) < 1 || len(arguments) > 2 {
+			return newEvaluationError(ArityMismatchError, span, "%s expects a place and an optional delta but got %s", functionName, countArguments(len(arguments)))
+		}
+
+		target, failure := resolvePlace(functionName, arguments[0], context)
+		if failure != nil {
+			return failure
+		}
+
+		currentResult := target.get()
+		if !currentResult.IsSuccessful() {
+			return currentResult
+		}
+		current, failure := expectNumber(functionName, currentResult.(SuccessfulEvaluationResult).Expression)
+		if failure != nil {
+			failure.(UnsuccessfulEvaluationResult).Error.Span = span
+			return failure
+		}
+
+		var delta Expression = Int{Value: 1}
+		if len(arguments) == 2 {
+			deltaResult := arguments[1].Evaluate(context)
+			if !deltaResult.IsSuccessful() {
+				return deltaResult
+			}
+			delta, failure = expectNumber(functionName, deltaResult.(SuccessfulEvaluationResult).Expression)
+			if failure != nil {
+				failure.(UnsuccessfulEvaluationResult).Error.Span = arguments[1].GetSpan()
+				return failure
+			}
+		}
+
+		if sign < 0 {
+			return target.set(subtractNumbers(current, delta))
+		}
+		return target.set(addNumbers(current, delta))
+	}
+}
+
+// setCons replaces the car of a cons, when left is true, or its cdr.
+func setCons(functionName string, expression Expression, value Expression, left bool) EvaluationResult {
+	cons, ok := expression.(*List)
+	if !ok {
+		return newEvaluationError(TypeMismatchError, Span{}, "%s expects a cons but got %s", functionName, describe(expression))
+	}
+
+	if left {
+		cons.left = value
+	} else {
+		cons.right = value
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: value,
+	}
+}
+
+// replaceCons implements rplaca, when left is true, and rplacd, which
+// modify a cons and return it.
+func replaceCons(functionName string, left bool) func(arguments []Expression) EvaluationResult {
+	return func(arguments []Expression) EvaluationResult {
+		if failure := checkArity(functionName, arguments, 2); failure != nil {
+			return failure
+		}
+
+		if failure := setCons(functionName, arguments[0], arguments[1], left); !failure.IsSuccessful() {
+			return failure
+		}
+
+		return SuccessfulEvaluationResult{
+			Expression: arguments[0],
+		}
+	}
+}
+
+// cxrSetter assigns the place (cxr list), such as (car x) or (cadr x):
+// the last operation of the name selects the field of the cons reached by
+// the others.
+func cxrSetter(functionName string) func(arguments []Expression, value Expression, global EvaluationContext) EvaluationResult {
+	return func(arguments []Expression, value Expression, global EvaluationContext) EvaluationResult {
+		if failure := checkArity(functionName, arguments, 1); failure != nil {
+			return failure
+		}
+
+		cons := arguments[0]
+		if len(functionName) > 3 {
+			evaluationResult := cxr("c" + functionName[2:])(arguments)
+			if !evaluationResult.IsSuccessful() {
+				return evaluationResult
+			}
+			cons = evaluationResult.(SuccessfulEvaluationResult).Expression
+		}
+
+		return setCons(functionName, cons, value, functionName[1] == 'a')
+	}
+}
+
+func nthSetter(arguments []Expression, value Expression, global EvaluationContext) EvaluationResult {
+	if failure := checkArity("nth", arguments, 2); failure != nil {
+		return failure
+	}
+
+	n, failure := expectIndex("nth", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	cons, failure := nthcdrOf("nth", n, arguments[1])
+	if failure != nil {
+		return failure
+	}
+
+	if isNil(cons) {
+		return newEvaluationError(TypeMismatchError, Span{}, "cannot assign the element %d of %s, which is too short", n, arguments[1].Print())
+	}
+	return setCons("nth", cons, value, true)
+}
+
+func getSetter(arguments []Expression, value Expression, global EvaluationContext) EvaluationResult {
+	if failure := checkArity("get", arguments, 2); failure != nil {
+		return failure
+	}
+
+	return putProperty([]Expression{arguments[0], arguments[1], value}, global)
+}
@@ -0,0 +1,71 @@
+package lisp
+
+import (
+	"testing"
+)
+
+func TestPlaces(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "rplaca and rplacd", source: "(setq x (list 1 2)) (rplaca x 9) (rplacd x '(8)) x", want: "(9 8)"},
+		{name: "rplaca returns the cons", source: "(setq x (list 1)) (eq (rplaca x 2) x)", want: "T"},
+		{name: "conses are shared", source: "(setq x (list 1 2)) (setq y x) (rplaca y :changed) x", want: "(:changed 2)"},
+		{name: "setf of a variable", source: "(setf a 1 b (+ a 1)) (list a b)", want: "(1 2)"},
+		{name: "setf returns the last value", source: "(setf a 1 b 2)", want: "2"},
+		{name: "setf of car and cdr", source: "(setq x (list 1 2 3)) (setf (car x) :a (cdr (cdr x)) NIL) x", want: "(:a 2)"},
+		{name: "setf of cadr", source: "(setq x (list 1 2 3)) (setf (cadr x) :b) x", want: "(1 :b 3)"},
+		{name: "setf of nth", source: "(setq x (list 1 2 3)) (setf (nth 2 x) :c) x", want: "(1 2 :c)"},
+		{name: "setf of get", source: "(setf (get 'apple 'color) 'red) (get 'apple 'color)", want: "red"},
+		{name: "setf of gethash", source: "(setq h (make-hash-table)) (setf (gethash :k h) 1) (gethash :k h)", want: "1"},
+		{name: "setf of aref", source: "(setq v (vector 1 2)) (setf (aref v 0) :a) v", want: "#(:a 2)"},
+		{name: "setf of a structure slot", source: "(defstruct point x y) (setq p (make-point :x 1)) (setf (point-x p) 5) (point-x p)", want: "5"},
+		{name: "setf through a macro", source: "(defmacro head (x) `(car ,x)) (setq x (list 1)) (setf (head x) 2) x", want: "(2)"},
+		{name: "push and pop", source: "(setq x NIL) (push 1 x) (push 2 x) (list (pop x) x)", want: "(2 (1))"},
+		{name: "pop of the empty list", source: "(setq x NIL) (list (pop x) x)", want: "(NIL NIL)"},
+		{name: "push onto a place", source: "(setq x (list NIL)) (push :a (car x)) x", want: "((:a))"},
+		{name: "incf and decf", source: "(setq n 1) (incf n) (incf n 10) (decf n 2) n", want: "10"},
+		{name: "incf of a place", source: "(setq v (vector 1)) (incf (aref v 0) 1/2) v", want: "#(3/2)"},
+		{name: "subforms are evaluated once", source: "(setq v (vector 0 0)) (setq i 0) (incf (aref v (progn (setq i (+ i 1)) i))) (list v i)", want: "(#(0 1) 1)"},
+
+		{name: "rplaca of NIL", source: "(rplaca NIL 1)", want: "rplaca expects a cons but got NIL", wantError: "type-error"},
+		{name: "rplacd of a number", source: "(rplacd 1 2)", want: "rplacd expects a cons", wantError: "type-error"},
+		{name: "setf of car of NIL", source: "(setf (car NIL) 1)", want: "expects a cons", wantError: "type-error"},
+		{name: "setf of nth past the end", source: "(setq x (list 1)) (setf (nth 3 x) 2)", want: "cannot assign the element 3 of (1), which is too short", wantError: "type-error"},
+		{name: "setf of aref past the end", source: "(setf (aref (vector) 0) 1)", want: "aref expects an index below 0", wantError: "type-error"},
+		{name: "setf with an odd number of arguments", source: "(setf a)", want: "setf expects places and values in pairs but got 1 argument", wantError: "arity-mismatch"},
+		{name: "setf of a number", source: "(setf 1 2)", want: "setf cannot assign 1 (int), which is not a place", wantError: "invalid-form"},
+		{name: "setf of a call that is not a place", source: "(setf (list 1) 2)", want: "setf cannot assign (list 1), which is not a place", wantError: "invalid-form"},
+		{name: "setf of a dotted form", source: "(setf (car . x) 2)", want: "setf cannot assign the dotted list", wantError: "invalid-form"},
+		{name: "pop of a number", source: "(setq x 1) (pop x)", want: "pop expects a list but got 1", wantError: "type-error"},
+		{name: "push arity", source: "(push 1)", want: "push expects an item and a place", wantError: "arity-mismatch"},
+		{name: "incf of a string", source: "(setq s \"a\") (incf s)", wantError: "type-error"},
+		{name: "incf arity", source: "(incf)", want: "incf expects a place and an optional delta", wantError: "arity-mismatch"},
+	})
+}
+
+func TestCircularLists(t *testing.T) {
+	const circular = "(setq l (list 1 2)) (rplacd (cdr l) l) "
+
+	runEvalTests(t, []evalTest{
+		{name: "printing a circular cdr", source: "(let ((l (list 1))) (rplacd l l) l)", want: "#1=(1 . #1#)"},
+		{name: "printing a cycle in the middle", source: "(let ((l (list 1 2 3))) (rplacd (cddr l) (cdr l)) l)", want: "(1 . #1=(2 3 . #1#))"},
+		{name: "printing a circular car", source: "(let ((l (list 1 2))) (rplaca l l) l)", want: "#1=(#1# 2)"},
+		{name: "printing a shared list", source: "(let ((l (list 1))) (list l l))", want: "((1) (1))"},
+		{name: "printing a circular vector", source: "(let ((v (vector 1 2))) (setf (aref v 0) v) v)", want: "#1=#(#1# 2)"},
+		{name: "printing a circular structure", source: "(defstruct node next) (let ((n (make-node))) (setf (node-next n) n) n)", want: "#1=#S(node :next #1#)"},
+		{name: "equal to itself", source: circular + "(equal l l)", want: "T"},
+		{name: "equal to the same unfolding", source: circular + "(setq m (list 1 2 1 2)) (rplacd (cdddr m) m) (equal l m)", want: "T"},
+		{name: "not equal to another unfolding", source: circular + "(setq m (list 1 3)) (rplacd (cdr m) m) (equal l m)", want: "NIL"},
+		{name: "not equal to a proper list", source: circular + "(equal l '(1 2 1 2))", want: "NIL"},
+		{name: "a hash key with a circular car", source: "(setq h (make-hash-table :test 'equal)) (setq k (list 1)) (rplaca k k) (setf (gethash k h) :found) (gethash k h)", want: ":found"},
+		{name: "nth of a circular list", source: circular + "(nth 5 l)", want: "2"},
+
+		{name: "length of a circular list", source: circular + "(length l)", want: "length expects a list but got a circular list", wantError: "type-error"},
+		{name: "mapcar of a circular list", source: circular + "(mapcar #'1+ l)", want: "mapcar expects a list but got a circular list", wantError: "type-error"},
+		{name: "last of a circular list", source: circular + "(last l)", want: "last expects a list but got a circular list", wantError: "type-error"},
+		{name: "copy-list of a circular list", source: circular + "(copy-list l)", want: "copy-list expects a list but got a circular list", wantError: "type-error"},
+		{name: "apply to a circular list", source: circular + "(apply #'+ l)", want: "apply expects its last argument to be a list but got #1=(1 2 . #1#)", wantError: "type-error"},
+		{name: "dolist over a circular list", source: circular + "(dolist (x l) x)", want: "dolist expects a list but got #1=(1 2 . #1#)", wantError: "type-error"},
+		{name: "eval of a circular form", source: "(setq form (list 'progn 1)) (rplaca (cdr form) form) (eval form)", want: "eval cannot evaluate a circular form", wantError: "invalid-form"},
+		{name: "a macro expanding into a circular form", source: "(defmacro m () (let ((form (list 'progn 1))) (rplaca (cdr form) form) form)) (m)", want: "m expands into a circular form", wantError: "invalid-form"},
+	})
+}
@@ -0,0 +1,126 @@
+package lisp
+
+// isEqual reports whether two values are structurally equal: lists are
+// compared element by element, strings by content, and other values with
+// isEql. Circular lists are equal when their unfoldings are.
+func isEqual(a Expression, b Expression) bool {
+	return equalValues(a, b, map[[2]*List]bool{})
+}
+
+// equalValues compares two values, assuming that the pairs of conses
+// already being compared are equal, which stops on cycles.
+func equalValues(a Expression, b Expression, assumed map[[2]*List]bool) bool {
+	for {
+		x, ok := a.(*List)
+		if !ok {
+			if s, ok := a.(String); ok {
+				t, ok := b.(String)
+				return ok && s.Value == t.Value
+			}
+			return isEql(a, b)
+		}
+
+		y, ok := b.(*List)
+		if !ok {
+			return false
+		}
+		if x == y || assumed[[2]*List{x, y}] {
+			return true
+		}
+		assumed[[2]*List{x, y}] = true
+
+		if !equalValues(x.left, y.left, assumed) {
+			return false
+		}
+		a, b = x.right, y.right
+	}
+}
+
+// equality implements eql and equal, which compare two values with test.
+func equality(functionName string, test func(a Expression, b Expression) bool) func(arguments []Expression) EvaluationResult {
+	return func(arguments []Expression) EvaluationResult {
+		if failure := checkArity(functionName, arguments, 2); failure != nil {
+			return failure
+		}
+
+		return SuccessfulEvaluationResult{
+			Expression: Boolean{Value: test(arguments[0], arguments[1])},
+		}
+	}
+}
+
+// typePredicate implements the predicates such as consp or stringp, which
+// tell whether their argument is of a type.
+func typePredicate(functionName string, test func(argument Expression) bool) func(arguments []Expression) EvaluationResult {
+	return func(arguments []Expression) EvaluationResult {
+		if failure := checkArity(functionName, arguments, 1); failure != nil {
+			return failure
+		}
+
+		return SuccessfulEvaluationResult{
+			Expression: Boolean{Value: test(arguments[0])},
+		}
+	}
+}
+
+func isCons(expression Expression) bool {
+	return expression.GetType() == "list"
+}
+
+func isAtom(expression Expression) bool {
+	return !isCons(expression)
+}
+
+func isList(expression Expression) bool {
+	return isCons(expression) || isNil(expression)
+}
+
+func isString(expression Expression) bool {
+	return expression.GetType() == "string"
+}
+
+func isSymbol(expression Expression) bool {
+	return expression.GetType() == "symbol"
+}
+
+// typeName returns the name of the type of a value, as given by type-of.
+func typeName(expression Expression) string {
+	switch value := expression.(type) {
+	case Int, BigInt:
+		return "integer"
+	case Boolean:
+		if value.Value {
+			return "boolean"
+		}
+		return "null"
+	case *List:
+		return "cons"
+	case Symbol:
+		if value.IsKeyword() {
+			return "keyword"
+		}
+		return "symbol"
+	case Builtin:
+		return "function"
+	case FunctionDeclaration:
+		if value.isMacro {
+			return "macro"
+		}
+		return "function"
+	case Condition:
+		return value.Error.TypeName()
+	case *Structure:
+		return value.structureType.name
+	}
+	return expression.GetType()
+}
+
+func typeOf(arguments []Expression) EvaluationResult {
+	if failure := checkArity("type-of", arguments, 1); failure != nil {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: Intern(typeName(arguments[0])),
+	}
+}
@@ -0,0 +1,36 @@
+package lisp
+
+import (
+	"testing"
+)
+
+func TestPredicates(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "eql of numbers", source: "(list (eql 2 2) (eql 2 2.0) (eql 1.5 1.5) (eql 100000000000000000000 100000000000000000000))", want: "(T NIL T T)"},
+		{name: "eql of strings", source: "(eql \"a\" \"a\")", want: "NIL"},
+		{name: "eql of symbols", source: "(list (eql 'a 'a) (eql :k :k) (eql 'a :a))", want: "(T T NIL)"},
+		{name: "equal of lists", source: "(list (equal '(1 (2 \"x\")) '(1 (2 \"x\"))) (equal '(1 2) '(1 3)) (equal '(1 . 2) '(1 . 2)))", want: "(T NIL T)"},
+		{name: "equal of strings", source: "(list (equal \"ab\" \"ab\") (equal \"ab\" \"AB\"))", want: "(T NIL)"},
+		{name: "equal of numbers", source: "(list (equal 1 1) (equal 1 1.0))", want: "(T NIL)"},
+		{name: "equal of vectors", source: "(setq v (vector 1)) (list (equal v v) (equal (vector 1) (vector 1)))", want: "(T NIL)"},
+		{name: "eq is identity for lists", source: "(list (eq '(1) '(1)) (equal '(1) '(1)))", want: "(NIL T)"},
+
+		{name: "null", source: "(list (null NIL) (null '()) (null 0) (null '(1)))", want: "(T T NIL NIL)"},
+		{name: "atom", source: "(list (atom 1) (atom 'a) (atom NIL) (atom '(1)))", want: "(T T T NIL)"},
+		{name: "consp and listp", source: "(list (consp '(1)) (consp NIL) (listp NIL) (listp '(1)) (listp 1))", want: "(T NIL T T NIL)"},
+		{name: "numberp, stringp and symbolp", source: "(list (numberp 1.5) (stringp \"s\") (symbolp 'a) (symbolp \"a\") (symbolp :k))", want: "(T T T NIL T)"},
+		{name: "functionp", source: "(list (functionp #'car) (functionp (lambda ())) (functionp 'car))", want: "(T T NIL)"},
+
+		{name: "type-of", source: `(list (type-of 1) (type-of 1.0) (type-of 1/2) (type-of "s") (type-of 'a) (type-of :k))`, want: "(integer float ratio string symbol keyword)"},
+		{name: "type-of lists and booleans", source: "(list (type-of '(1)) (type-of NIL) (type-of T))", want: "(cons null boolean)"},
+		{name: "type-of functions", source: "(defmacro m () 1) (list (type-of #'car) (type-of (lambda ())) (type-of #'m))", want: "(function function macro)"},
+		{name: "type-of containers", source: "(list (type-of (vector)) (type-of (make-hash-table)))", want: "(vector hash-table)"},
+		{name: "type-of a structure", source: "(defstruct point x) (type-of (make-point))", want: "point"},
+		{name: "type-of a condition", source: "(handler-case (car 1) (error (e) (type-of e)))", want: "type-error"},
+
+		{name: "eql arity", source: "(eql 1)", want: "eql expects 2 arguments but got 1", wantError: "arity-mismatch"},
+		{name: "equal arity", source: "(equal 1 2 3)", want: "equal expects 2 arguments but got 3", wantError: "arity-mismatch"},
+		{name: "null arity", source: "(null)", want: "null expects 1 argument but got 0", wantError: "arity-mismatch"},
+		{name: "type-of arity", source: "(type-of 1 2)", wantError: "arity-mismatch"},
+	})
+}
@@ -0,0 +1,683 @@
+package lisp
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Position locates a character in a source text. Offset is a byte offset,
+// Line and Column start at 1 and Column counts runes. File is the name of
+// the file the source text was read from, if any.
+type Position struct {
+	File   string
+	Offset int
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	if p.File != "" {
+		return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Span is the region of source text an expression was read from. End is
+// the position just after the last character.
+type Span struct {
+	Start Position
+	End   Position
+}
+
+func (s Span) String() string {
+	return s.Start.String()
+}
+
+// IsZero reports whether the span does not come from any source text,
+// which is the case of values built during evaluation.
+func (s Span) IsZero() bool {
+	return s.Start.Line == 0
+}
+
+type SyntaxError struct {
+	Message string
+	Span    Span
+}
+
+func (e SyntaxError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Span, e.Message)
+}
+
+type TokenKind int
+
+const (
+	EndOfFileToken TokenKind = iota
+	LeftParenthesisToken
+	RightParenthesisToken
+	QuoteToken
+	FunctionQuoteToken
+	VectorToken
+	// DispatchToken is # followed by any other character, as in #; or #+.
+	DispatchToken
+	BackquoteToken
+	UnquoteToken
+	UnquoteSplicingToken
+	StringToken
+	AtomToken
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case LeftParenthesisToken:
+		return "'('"
+	case RightParenthesisToken:
+		return "')'"
+	case QuoteToken:
+		return "quote"
+	case FunctionQuoteToken:
+		return "#'"
+	case VectorToken:
+		return "'#('"
+	case DispatchToken:
+		return "dispatch macro"
+	case BackquoteToken:
+		return "backquote"
+	case UnquoteToken:
+		return "','"
+	case UnquoteSplicingToken:
+		return "',@'"
+	case StringToken:
+		return "string"
+	case AtomToken:
+		return "atom"
+	}
+	return "end of input"
+}
+
+type Token struct {
+	Kind TokenKind
+	// Text is the source text of the token. For strings it is the content
+	// found between the double quotes, with escape sequences decoded.
+	Text string
+	Span Span
+}
+
+// Lexer splits a source text into tokens, skipping whitespace and comments.
+type Lexer struct {
+	source   string
+	position Position
+}
+
+func NewLexer(source string) *Lexer {
+	return &Lexer{
+		source:   source,
+		position: Position{Offset: 0, Line: 1, Column: 1},
+	}
+}
+
+func (l *Lexer) peekRune() (rune, int) {
+	if l.position.Offset >= len(l.source) {
+		return utf8.RuneError, 0
+	}
+	return utf8.DecodeRuneInString(l.source[l.position.Offset:])
+}
+
+func (l *Lexer) advance() rune {
+	r, size := l.peekRune()
+	if size == 0 {
+		return r
+	}
+	l.position.Offset += size
+
+	// "\r\n" and a lone "\r" both count as a single line break
+	if r == '\n' || (r == '\r' && !strings.HasPrefix(l.source[l.position.Offset:], "\n")) {
+		l.position.Line += 1
+		l.position.Column = 1
+	} else if r != '\r' {
+		l.position.Column += 1
+	}
+	return r
+}
+
+func isWhitespace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' || r == '\v'
+}
+
+func isDelimiter(r rune) bool {
+	return isWhitespace(r) || r == '(' || r == ')' || r == '"' || r == ';' || r == '\'' || r == '`' || r == ','
+}
+
+func (l *Lexer) skipWhitespaceAndComments() error {
+	for {
+		r, size := l.peekRune()
+		if size == 0 {
+			return nil
+		}
+		if isWhitespace(r) {
+			l.advance()
+			continue
+		}
+		if r == ';' {
+			for size > 0 && r != '\n' && r != '\r' {
+				l.advance()
+				r, size = l.peekRune()
+			}
+			continue
+		}
+		if strings.HasPrefix(l.source[l.position.Offset:], "#|") {
+			if err := l.skipBlockComment(); err != nil {
+				return err
+			}
+			continue
+		}
+		return nil
+	}
+}
+
+// skipBlockComment skips a comment written #| ... |#, which may contain
+// other block comments.
+func (l *Lexer) skipBlockComment() error {
+	start := l.position
+	depth := 0
+
+	for {
+		rest := l.source[l.position.Offset:]
+		switch {
+		case rest == "":
+			return SyntaxError{Message: "unterminated block comment", Span: Span{Start: start, End: l.position}}
+		case strings.HasPrefix(rest, "#|"):
+			depth += 1
+		case strings.HasPrefix(rest, "|#"):
+			depth -= 1
+		default:
+			l.advance()
+			continue
+		}
+		l.advance()
+		l.advance()
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// NextToken returns the next token of the source, or a token of kind
+// EndOfFileToken once the whole source has been consumed.
+func (l *Lexer) NextToken() (Token, error) {
+	if err := l.skipWhitespaceAndComments(); err != nil {
+		return Token{}, err
+	}
+
+	start := l.position
+	r, size := l.peekRune()
+
+	if size == 0 {
+		return Token{Kind: EndOfFileToken, Span: Span{Start: start, End: start}}, nil
+	}
+
+	switch r {
+	case '(':
+		l.advance()
+		return Token{Kind: LeftParenthesisToken, Text: "(", Span: Span{Start: start, End: l.position}}, nil
+	case ')':
+		l.advance()
+		return Token{Kind: RightParenthesisToken, Text: ")", Span: Span{Start: start, End: l.position}}, nil
+	case '\'':
+		l.advance()
+		return Token{Kind: QuoteToken, Text: "'", Span: Span{Start: start, End: l.position}}, nil
+	case '`':
+		l.advance()
+		return Token{Kind: BackquoteToken, Text: "`", Span: Span{Start: start, End: l.position}}, nil
+	case ',':
+		l.advance()
+		if strings.HasPrefix(l.source[l.position.Offset:], "@") {
+			l.advance()
+			return Token{Kind: UnquoteSplicingToken, Text: ",@", Span: Span{Start: start, End: l.position}}, nil
+		}
+		return Token{Kind: UnquoteToken, Text: ",", Span: Span{Start: start, End: l.position}}, nil
+	case '"':
+		return l.readString()
+	case '#':
+		if strings.HasPrefix(l.source[l.position.Offset:], "#'") {
+			l.advance()
+			l.advance()
+			return Token{Kind: FunctionQuoteToken, Text: "#'", Span: Span{Start: start, End: l.position}}, nil
+		}
+		if strings.HasPrefix(l.source[l.position.Offset:], "#(") {
+			l.advance()
+			l.advance()
+			return Token{Kind: VectorToken, Text: "#(", Span: Span{Start: start, End: l.position}}, nil
+		}
+		if next, size := utf8.DecodeRuneInString(l.source[l.position.Offset+1:]); size > 0 && !isWhitespace(next) && next != ')' {
+			l.advance()
+			l.advance()
+			return Token{Kind: DispatchToken, Text: "#" + string(next), Span: Span{Start: start, End: l.position}}, nil
+		}
+	}
+
+	for size > 0 && !isDelimiter(r) {
+		l.advance()
+		r, size = l.peekRune()
+	}
+
+	return Token{
+		Kind: AtomToken,
+		Text: l.source[start.Offset:l.position.Offset],
+		Span: Span{Start: start, End: l.position},
+	}, nil
+}
+
+func (l *Lexer) readString() (Token, error) {
+	start := l.position
+	l.advance()
+
+	var value strings.Builder
+
+	for {
+		r, size := l.peekRune()
+		if size == 0 {
+			return Token{}, SyntaxError{
+				Message: "unterminated string",
+				Span:    Span{Start: start, End: l.position},
+			}
+		}
+		l.advance()
+		if r == '"' {
+			break
+		}
+		if r != '\\' {
+			value.WriteRune(r)
+			continue
+		}
+
+		escapeStart := l.position
+		escaped, size := l.peekRune()
+		if size == 0 {
+			continue
+		}
+		l.advance()
+
+		switch escaped {
+		case '"', '\\':
+			value.WriteRune(escaped)
+		case 'n':
+			value.WriteRune('\n')
+		case 't':
+			value.WriteRune('\t')
+		case 'r':
+			value.WriteRune('\r')
+		case 'u':
+			codePoint, err := l.readUnicodeEscape(escapeStart)
+			if err != nil {
+				return Token{}, err
+			}
+			value.WriteRune(codePoint)
+		default:
+			return Token{}, SyntaxError{
+				Message: fmt.Sprintf("unknown escape sequence \\%c in string", escaped),
+				Span:    Span{Start: escapeStart, End: l.position},
+			}
+		}
+	}
+
+	return Token{
+		Kind: StringToken,
+		Text: value.String(),
+		Span: Span{Start: start, End: l.position},
+	}, nil
+}
+
+// readUnicodeEscape reads the {...} part of a \u{...} escape sequence,
+// holding the hexadecimal code point of a character.
+func (l *Lexer) readUnicodeEscape(escapeStart Position) (rune, error) {
+	invalid := SyntaxError{
+		Message: "invalid unicode escape sequence, expected \\u{hexadecimal code point}",
+		Span:    Span{Start: escapeStart, End: l.position},
+	}
+
+	if r, _ := l.peekRune(); r != '{' {
+		return 0, invalid
+	}
+	l.advance()
+
+	digitsStart := l.position.Offset
+	for {
+		r, size := l.peekRune()
+		if size == 0 || r == '"' {
+			return 0, invalid
+		}
+		l.advance()
+		if r == '}' {
+			break
+		}
+	}
+
+	digits := l.source[digitsStart : l.position.Offset-1]
+	codePoint, err := strconv.ParseUint(digits, 16, 32)
+	invalid.Span.End = l.position
+	if err != nil || digits == "" || !utf8.ValidRune(rune(codePoint)) {
+		return 0, invalid
+	}
+	return rune(codePoint), nil
+}
+
+// Tokenize returns all the tokens of a source text, without the final
+// EndOfFileToken.
+func Tokenize(source string) ([]Token, error) {
+	lexer := NewLexer(source)
+	tokens := []Token{}
+
+	for {
+		token, err := lexer.NextToken()
+		if err != nil {
+			return nil, err
+		}
+		if token.Kind == EndOfFileToken {
+			return tokens, nil
+		}
+		tokens = append(tokens, token)
+	}
+}
+
+// Reader builds expressions out of the tokens produced by a Lexer.
+type Reader struct {
+	lexer  *Lexer
+	peeked *Token
+	// backquoteDepth counts the backquotes enclosing the datum being read,
+	// commas being only allowed inside a backquote.
+	backquoteDepth int
+	readtable      *readtable
+}
+
+func NewReader(source string) *Reader {
+	return &Reader{
+		lexer:     NewLexer(source),
+		readtable: newReadtable(),
+	}
+}
+
+// NewFileReader returns a reader whose spans name the file the source text
+// comes from.
+func NewFileReader(source string, file string) *Reader {
+	reader := NewReader(source)
+	reader.lexer.position.File = file
+	return reader
+}
+
+func (r *Reader) nextToken() (Token, error) {
+	if r.peeked != nil {
+		token := *r.peeked
+		r.peeked = nil
+		return token, nil
+	}
+	return r.lexer.NextToken()
+}
+
+func (r *Reader) peekToken() (Token, error) {
+	if r.peeked == nil {
+		token, err := r.lexer.NextToken()
+		if err != nil {
+			return token, err
+		}
+		r.peeked = &token
+	}
+	return *r.peeked, nil
+}
+
+// Read returns the next expression of the source, or io.EOF once the whole
+// source has been read.
+func (r *Reader) Read() (Expression, error) {
+	token, err := r.nextDatumToken()
+	if err != nil {
+		return nil, err
+	}
+	if token.Kind == EndOfFileToken {
+		return nil, io.EOF
+	}
+	return r.readExpression(token)
+}
+
+// ReadAll returns every expression of a source text.
+func ReadAll(source string) ([]Expression, error) {
+	reader := NewReader(source)
+	expressions := []Expression{}
+
+	for {
+		expression, err := reader.Read()
+		if err == io.EOF {
+			return expressions, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		expressions = append(expressions, expression)
+	}
+}
+
+// readExpression reads the datum starting with the given token. Programs
+// are read as data too: a form such as (+ 1 2) is read as a list.
+func (r *Reader) readExpression(token Token) (Expression, error) {
+	switch token.Kind {
+	case LeftParenthesisToken:
+		elements, tail, span, err := r.readElements(token, r.readExpression)
+		if err != nil {
+			return nil, err
+		}
+		if tail != nil {
+			return makeDottedList(elements, tail, span), nil
+		}
+		return makeListFromSlice(elements, span), nil
+	case VectorToken:
+		elements, tail, span, err := r.readElements(token, r.readExpression)
+		if err != nil {
+			return nil, err
+		}
+		if tail != nil {
+			return nil, SyntaxError{Message: "a vector cannot be a dotted list", Span: span}
+		}
+		return &Vector{elements: elements, Span: span}, nil
+	case QuoteToken:
+		return r.readPrefixed(token, "quote")
+	case FunctionQuoteToken:
+		return r.readPrefixed(token, "function")
+	case DispatchToken:
+		return r.readDispatch(token)
+	case BackquoteToken:
+		r.backquoteDepth += 1
+		defer func() { r.backquoteDepth -= 1 }()
+		return r.readPrefixed(token, "quasiquote")
+	case UnquoteToken, UnquoteSplicingToken:
+		if r.backquoteDepth == 0 {
+			return nil, SyntaxError{Message: fmt.Sprintf("%s outside of a backquote", token.Kind), Span: token.Span}
+		}
+		r.backquoteDepth -= 1
+		defer func() { r.backquoteDepth += 1 }()
+		if token.Kind == UnquoteSplicingToken {
+			return r.readPrefixed(token, "unquote-splicing")
+		}
+		return r.readPrefixed(token, "unquote")
+	}
+	return r.readAtom(token)
+}
+
+// readPrefixed reads the datum following a prefix token such as "'" as a
+// list whose head names the prefix, as in (quote datum).
+func (r *Reader) readPrefixed(token Token, name string) (Expression, error) {
+	datum, err := r.readDatum(token)
+	if err != nil {
+		return nil, err
+	}
+
+	span := Span{Start: token.Span.Start, End: datum.GetSpan().End}
+	return makeListFromSlice([]Expression{InternAt(name, token.Span), datum}, span), nil
+}
+
+// readDatum reads the datum following a prefix token.
+func (r *Reader) readDatum(prefix Token) (Expression, error) {
+	next, err := r.nextDatumToken()
+	if err != nil {
+		return nil, err
+	}
+	if next.Kind == EndOfFileToken || next.Kind == RightParenthesisToken {
+		name := prefix.Kind.String()
+		if prefix.Kind == DispatchToken {
+			name = prefix.Text
+		}
+		return nil, SyntaxError{Message: fmt.Sprintf("missing expression after %s", name), Span: prefix.Span}
+	}
+	return r.readExpression(next)
+}
+
+// nextDatumToken returns the next token, skipping the data commented out
+// with #; and the data of #+ and #- whose feature expression does not
+// hold.
+func (r *Reader) nextDatumToken() (Token, error) {
+	for {
+		token, err := r.nextToken()
+		if err != nil || token.Kind != DispatchToken {
+			return token, err
+		}
+
+		switch token.Text {
+		case "#;":
+			if _, err := r.readDatum(token); err != nil {
+				return Token{}, err
+			}
+		case "#+", "#-":
+			feature, err := r.readDatum(token)
+			if err != nil {
+				return Token{}, err
+			}
+			holds, err := r.readtable.featureHolds(feature)
+			if err != nil {
+				return Token{}, err
+			}
+			if holds == (token.Text == "#+") {
+				next, err := r.nextDatumToken()
+				if err == nil && (next.Kind == EndOfFileToken || next.Kind == RightParenthesisToken) {
+					return Token{}, SyntaxError{Message: fmt.Sprintf("missing expression after %s", token.Text), Span: token.Span}
+				}
+				return next, err
+			}
+			if _, err := r.readDatum(token); err != nil {
+				return Token{}, err
+			}
+		default:
+			return token, nil
+		}
+	}
+}
+
+func (r *Reader) readAtom(token Token) (Expression, error) {
+	switch token.Kind {
+	case StringToken:
+		return String{Value: token.Text, Span: token.Span}, nil
+	case AtomToken:
+		if token.Text == "T" {
+			return Boolean{Value: true, Span: token.Span}, nil
+		}
+		if token.Text == "NIL" {
+			return Boolean{Value: false, Span: token.Span}, nil
+		}
+		if number, ok, err := parseNumber(token.Text, token.Span); ok {
+			return number, err
+		}
+		return InternAt(r.readtable.readName(token.Text), token.Span), nil
+	case RightParenthesisToken:
+		return nil, SyntaxError{Message: "unexpected ')'", Span: token.Span}
+	}
+	return nil, SyntaxError{Message: fmt.Sprintf("unexpected %s", token.Kind), Span: token.Span}
+}
+
+// readElements reads expressions until the parenthesis opened by the
+// opening token is closed, and returns the span of the whole list. In a
+// dotted list such as (a b . c), the expression following the dot is
+// returned as the tail of the list.
+func (r *Reader) readElements(opening Token, readElement func(Token) (Expression, error)) ([]Expression, Expression, Span, error) {
+	elements := []Expression{}
+	var tail Expression
+
+	for {
+		token, err := r.nextDatumToken()
+		if err != nil {
+			return nil, nil, Span{}, err
+		}
+		if token.Kind == EndOfFileToken {
+			return nil, nil, Span{}, SyntaxError{
+				Message: fmt.Sprintf("unterminated list opened at %s", opening.Span.Start),
+				Span:    Span{Start: opening.Span.Start, End: token.Span.End},
+			}
+		}
+		if token.Kind == RightParenthesisToken {
+			return elements, tail, Span{Start: opening.Span.Start, End: token.Span.End}, nil
+		}
+		if tail != nil {
+			return nil, nil, Span{}, SyntaxError{Message: "a dotted list must end right after the expression following the dot", Span: token.Span}
+		}
+		if token.Kind == AtomToken && token.Text == "." {
+			if len(elements) == 0 {
+				return nil, nil, Span{}, SyntaxError{Message: "a dot must follow at least one element of a list", Span: token.Span}
+			}
+			token, err = r.nextDatumToken()
+			if err != nil {
+				return nil, nil, Span{}, err
+			}
+			if token.Kind == RightParenthesisToken || token.Kind == EndOfFileToken {
+				return nil, nil, Span{}, SyntaxError{Message: "a dot must be followed by an expression", Span: token.Span}
+			}
+			tail, err = readElement(token)
+			if err != nil {
+				return nil, nil, Span{}, err
+			}
+			continue
+		}
+		element, err := readElement(token)
+		if err != nil {
+			return nil, nil, Span{}, err
+		}
+		elements = append(elements, element)
+	}
+}
+
+func makeListFromSlice(elements []Expression, span Span) Expression {
+	return makeDottedList(elements, Boolean{Value: false, Span: span}, span)
+}
+
+// makeDottedList builds a list of elements whose last cdr is tail.
+func makeDottedList(elements []Expression, tail Expression, span Span) Expression {
+	result := tail
+
+	for i := len(elements) - 1; i >= 0; i-- {
+		result = &List{
+			left:  elements[i],
+			right: result,
+			Span:  Span{Start: elements[i].GetSpan().Start, End: span.End},
+		}
+	}
+
+	return withSpan(result, span)
+}
+
+func withSpan(expression Expression, span Span) Expression {
+	switch e := expression.(type) {
+	case *List:
+		e.Span = span
+		return e
+	case Boolean:
+		e.Span = span
+		return e
+	case Int:
+		e.Span = span
+		return e
+	case BigInt:
+		e.Span = span
+		return e
+	case Ratio:
+		e.Span = span
+		return e
+	}
+	return expression
+}
@@ -0,0 +1,144 @@
+package lisp
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadAll(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"atoms", `1 -2 foo "bar" T NIL`, "1 -2 foo bar T NIL"},
+		{"nested lists", "(a (b (c)) ())", "(a (b (c)) NIL)"},
+		{"whitespace and comments", "(a ; comment\n\tb)\n", "(a b)"},
+		{"quote", "'a '(1 2)", "(quote a) (quote (1 2))"},
+		{"function quote", "#'car", "(function car)"},
+		{"backquote", "`(a ,b ,@c)", "(quasiquote (a (unquote b) (unquote-splicing c)))"},
+		{"dotted pair", "(a . b)", "(a . b)"},
+		{"dotted list", "(a b . c)", "(a b . c)"},
+		{"dot before a list", "(a . (b c))", "(a b c)"},
+		{"string escapes", `"a\"b\tc"`, "a\"b\tc"},
+		{"empty source", "  ; nothing\n", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expressions, err := ReadAll(test.source)
+			if err != nil {
+				t.Fatalf("ReadAll(%q) failed: %s", test.source, err)
+			}
+			printed := []string{}
+			for _, expression := range expressions {
+				printed = append(printed, expression.Print())
+			}
+			if got := strings.Join(printed, " "); got != test.want {
+				t.Errorf("ReadAll(%q) = %s, want %s", test.source, got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"unterminated list", "(a (b)", "1:1: unterminated list"},
+		{"unexpected parenthesis", "a)", "1:2: unexpected ')'"},
+		{"unterminated string", `(a "b)`, "1:4: unterminated string"},
+		{"dot at the start", "(. a)", "1:2:"},
+		{"two expressions after a dot", "(a . b c)", "1:8:"},
+		{"nothing after a dot", "(a .)", "1:5: a dot must be followed by an expression"},
+		{"quote at the end", "(a ')", "1:4: missing expression after quote"},
+		{"comma outside backquote", "(a ,b)", "1:4:"},
+		{"position on a later line", "(a\n  (b", "2:3: unterminated list"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ReadAll(test.source)
+			if err == nil {
+				t.Fatalf("ReadAll(%q) succeeded, want an error", test.source)
+			}
+			if _, ok := err.(SyntaxError); !ok {
+				t.Fatalf("ReadAll(%q) failed with %T, want a SyntaxError", test.source, err)
+			}
+			if !strings.HasPrefix(err.Error(), test.want) {
+				t.Errorf("ReadAll(%q) failed with %q, want %q", test.source, err, test.want)
+			}
+		})
+	}
+}
+
+func TestReaderSpans(t *testing.T) {
+	reader := NewFileReader("(first\n  (second 2))\n\"third\"", "spans.lisp")
+
+	first, err := reader.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := first.(*List).right.(*List).left
+	third, err := reader.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reader.Read(); err != io.EOF {
+		t.Errorf("Read at the end returned %v, want io.EOF", err)
+	}
+
+	tests := []struct {
+		expression Expression
+		start      string
+		endOffset  int
+	}{
+		{first, "spans.lisp:1:1", 20},
+		{second, "spans.lisp:2:3", 19},
+		{third, "spans.lisp:3:1", 28},
+	}
+	for _, test := range tests {
+		span := test.expression.GetSpan()
+		if span.Start.String() != test.start || span.End.Offset != test.endOffset {
+			t.Errorf("%s spans %s to offset %d, want %s to offset %d", test.expression.Print(), span.Start, span.End.Offset, test.start, test.endOffset)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tokens, err := Tokenize("(f 'x #'g `(,y ,@z) \"s\")")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []TokenKind{
+		LeftParenthesisToken, AtomToken, QuoteToken, AtomToken, FunctionQuoteToken, AtomToken,
+		BackquoteToken, LeftParenthesisToken, UnquoteToken, AtomToken, UnquoteSplicingToken, AtomToken,
+		RightParenthesisToken, StringToken, RightParenthesisToken,
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("Tokenize returned %d tokens, want %d", len(tokens), len(want))
+	}
+	for i, token := range tokens {
+		if token.Kind != want[i] {
+			t.Errorf("token %d %q is a %s, want a %s", i, token.Text, token.Kind, want[i])
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "expression across lines", source: "(+ 1\n   2)", want: "3"},
+		{name: "syntax error", source: "(+ 1", want: "unterminated list", wantError: "reader-error"},
+	})
+
+	if result := Parse("(a b"); result.IsSucccessful() {
+		t.Errorf("Parse of an unterminated list succeeded")
+	}
+	if result := Parse("(a b)"); !result.IsSucccessful() {
+		t.Errorf("Parse of (a b) failed: %s", result.(UnsuccessfulParseResult).Message)
+	}
+}
@@ -0,0 +1,175 @@
+package lisp
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Besides the dispatch macros the reader knows, #' #( #; #+ and #-, and
+// #S which reads structures, the dispatch macros #x may be defined by Go
+// code with Interpreter.SetDispatchMacro and by Lisp code with
+// (set-dispatch-macro-character "#" "x" function), where the function
+// receives the datum following #x and returns the expression to read in its
+// place. #+feature datum reads datum only when the feature is in the list
+// *features*, and #-feature datum only when it is not; a feature may be
+// combined with (:and ...), (:or ...) and (:not ...).
+
+// ReaderMacro reads the expression written after a dispatch macro: it is
+// called once #x is read, and may read the data that follow with
+// reader.Read. The span is the one of #x.
+type ReaderMacro func(reader *Reader, span Span) (Expression, error)
+
+const featuresVariable = "*features*"
+
+type readtable struct {
+	dispatch map[rune]ReaderMacro
+	// features returns the value of *features*.
+	features func() Expression
+	// readName returns the name of the symbol read as a name, qualified
+	// with its package.
+	readName func(name string) string
+}
+
+func newReadtable() *readtable {
+	return &readtable{
+		dispatch: make(map[rune]ReaderMacro),
+		features: standardFeatures,
+		readName: func(name string) string { return name },
+	}
+}
+
+func standardFeatures() Expression {
+	return makeListFromSlice([]Expression{Intern(":golisp")}, Span{})
+}
+
+// standardDispatchCharacters are read by the reader itself, and cannot be
+// redefined.
+var standardDispatchCharacters = map[rune]bool{'\'': true, '(': true, '|': true, ';': true, '+': true, '-': true}
+
+// isDefinableDispatchCharacter reports whether #character may be defined
+// as a dispatch macro: it must be neither standard nor end a token.
+func isDefinableDispatchCharacter(character rune) bool {
+	return !standardDispatchCharacters[character] && !isWhitespace(character) && character != ')'
+}
+
+// featureHolds evaluates the feature expression of #+ and #-.
+func (t *readtable) featureHolds(feature Expression) (bool, error) {
+	if name, ok := designatorName(feature); ok {
+		features, _ := listToSlice(t.features())
+		for _, present := range features {
+			if presentName, ok := designatorName(present); ok && presentName == name {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	elements, ok := listToSlice(feature)
+	if ok && len(elements) > 0 {
+		operator, _ := designatorName(elements[0])
+		switch {
+		case operator == "not" && len(elements) == 2:
+			holds, err := t.featureHolds(elements[1])
+			return !holds, err
+		case operator == "and" || operator == "or":
+			for _, element := range elements[1:] {
+				holds, err := t.featureHolds(element)
+				if err != nil || holds == (operator == "or") {
+					return holds, err
+				}
+			}
+			return operator == "and", nil
+		}
+	}
+	return false, SyntaxError{Message: fmt.Sprintf("invalid feature expression %s", feature.Print()), Span: feature.GetSpan()}
+}
+
+// readDispatch reads the expression of a dispatch macro defined with the
+// readtable.
+func (r *Reader) readDispatch(token Token) (Expression, error) {
+	character, _ := utf8.DecodeRuneInString(token.Text[1:])
+	macro, ok := r.readtable.dispatch[character]
+	if !ok {
+		return nil, SyntaxError{Message: fmt.Sprintf("undefined dispatch macro %s", token.Text), Span: token.Span}
+	}
+
+	expression, err := r.callReaderMacro(macro, token)
+	if err != nil {
+		return nil, err
+	}
+	if expression == nil {
+		return nil, SyntaxError{Message: fmt.Sprintf("the dispatch macro %s returned no expression", token.Text), Span: token.Span}
+	}
+	if expression.GetSpan().IsZero() {
+		expression = withSpan(expression, token.Span)
+	}
+	return expression, nil
+}
+
+// callReaderMacro calls a dispatch macro, converting the Go panics it may
+// raise into syntax errors located at #x.
+func (r *Reader) callReaderMacro(macro ReaderMacro, token Token) (expression Expression, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			expression, err = nil, SyntaxError{Message: fmt.Sprintf("the dispatch macro %s failed: %v", token.Text, recovered), Span: token.Span}
+		}
+	}()
+
+	return macro(r, token.Span)
+}
+
+// readerMacroExpression returns the expression computed by the Lisp code
+// of a dispatch macro. Any result but a successful one is a syntax error.
+func readerMacroExpression(macroName string, result EvaluationResult, span Span) (Expression, error) {
+	switch r := result.(type) {
+	case SuccessfulEvaluationResult:
+		return r.Expression, nil
+	case UnsuccessfulEvaluationResult:
+		return nil, SyntaxError{Message: r.Error.Message, Span: span}
+	}
+	return nil, SyntaxError{Message: fmt.Sprintf("the dispatch macro %s did not return an expression", macroName), Span: span}
+}
+
+// lispReaderMacro makes a reader macro out of a Lisp function of the datum
+// following the macro.
+func lispReaderMacro(character rune, function Expression) ReaderMacro {
+	return func(reader *Reader, span Span) (Expression, error) {
+		datum, err := reader.readDatum(Token{Kind: DispatchToken, Text: "#" + string(character), Span: span})
+		if err != nil {
+			return nil, err
+		}
+
+		return readerMacroExpression("#"+string(character), applyFunction(function, []Expression{datum}, span), span)
+	}
+}
+
+// setDispatchMacroCharacterFunction implements
+// (set-dispatch-macro-character "#" character function), the characters
+// being given as strings of one character.
+func setDispatchMacroCharacterFunction(arguments []Expression, global EvaluationContext) EvaluationResult {
+	if len(arguments) != 3 {
+		return newEvaluationError(ArityMismatchError, Span{}, "set-dispatch-macro-character expects 3 arguments but got %d", len(arguments))
+	}
+
+	dispatchCharacter, ok := arguments[0].(String)
+	if !ok || dispatchCharacter.Value != "#" {
+		return newEvaluationError(TypeMismatchError, Span{}, "set-dispatch-macro-character only supports the dispatch character \"#\" but got %s", describe(arguments[0]))
+	}
+	subCharacter, ok := arguments[1].(String)
+	if !ok || utf8.RuneCountInString(subCharacter.Value) != 1 {
+		return newEvaluationError(TypeMismatchError, Span{}, "set-dispatch-macro-character expects a string of one character but got %s", describe(arguments[1]))
+	}
+	if !isFunction(arguments[2]) {
+		return newEvaluationError(TypeMismatchError, Span{}, "set-dispatch-macro-character expects a function but got %s", describe(arguments[2]))
+	}
+
+	character, _ := utf8.DecodeRuneInString(subCharacter.Value)
+	if !isDefinableDispatchCharacter(character) {
+		return newEvaluationError(InvalidFormError, Span{}, "set-dispatch-macro-character cannot define the dispatch macro #%c", character)
+	}
+	global.Global().readtable.dispatch[character] = lispReaderMacro(character, arguments[2])
+
+	return SuccessfulEvaluationResult{
+		Expression: Boolean{Value: true},
+	}
+}
@@ -0,0 +1,87 @@
+package lisp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReaderMacros(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "line comments", source: "(list 1 ; one\n 2) ; end", want: "(1 2)"},
+		{name: "block comments", source: "(list 1 #| two\n three |# 4)", want: "(1 4)"},
+		{name: "nested block comments", source: "(list #| a #| b |# c |# 1)", want: "(1)"},
+		{name: "datum comments", source: "(list 1 #;(ignored form) 2)", want: "(1 2)"},
+		{name: "function quote", source: "(funcall #'+ 1 2)", want: "3"},
+		{name: "vector literals", source: "#(1 (2) :three)", want: "#(1 (2) :three)"},
+		{name: "#+ of a present feature", source: "(list #+golisp :yes :always)", want: "(:yes :always)"},
+		{name: "#+ of a missing feature", source: "(list #+sbcl :no :always)", want: "(:always)"},
+		{name: "#- of a missing feature", source: "(list #-sbcl :yes)", want: "(:yes)"},
+		{name: "#+ of feature expressions", source: "(list #+(or sbcl golisp) 1 #+(and sbcl golisp) 2 #+(not sbcl) 3)", want: "(1 3)"},
+		{name: "#+ follows *features*", source: "(push :extra *features*) (eval (car (list '#+extra :no)))", want: ":no"},
+		{name: "a Lisp dispatch macro", source: "(set-dispatch-macro-character \"#\" \"d\" (lambda (datum) (list 'quote (list datum datum)))) #d(1)", want: "((1) (1))"},
+		{name: "a dispatch macro computing code", source: "(set-dispatch-macro-character \"#\" \"n\" (lambda (n) (* n 10))) (+ #n4 1)", want: "41"},
+		{name: "a dispatch macro with a named function", source: "(defun negate (x) (- x)) (set-dispatch-macro-character \"#\" \"m\" #'negate) #m5", want: "-5"},
+
+		{name: "set-dispatch-macro-character as a function", source: "(apply #'set-dispatch-macro-character (list \"#\" \"d\" (lambda (n) (* n 2)))) #d21", want: "42"},
+
+		{name: "an undefined dispatch macro", source: "#z 1", want: "undefined dispatch macro #z", wantError: "reader-error"},
+		{name: "an unterminated block comment", source: "1 #| open", wantError: "reader-error"},
+		{name: "an invalid feature expression", source: "#+(xor a b) 1", want: "invalid feature expression (xor a b)", wantError: "reader-error"},
+		{name: "a dispatch macro signaling an error", source: "(set-dispatch-macro-character \"#\" \"e\" (lambda (x) (car x))) #e1", want: "car expects a list but got 1", wantError: "reader-error"},
+		{name: "a return in a dispatch macro", source: "(set-dispatch-macro-character \"#\" \"r\" (lambda (x) (return x))) #r1", want: "return is only allowed inside a loop", wantError: "reader-error"},
+		{name: "a dispatch macro without a datum", source: "(set-dispatch-macro-character \"#\" \"d\" (lambda (x) x)) (list #d)", wantError: "reader-error"},
+		{name: "redefining a standard dispatch macro", source: "(set-dispatch-macro-character \"#\" \"(\" (lambda (x) x))", want: "set-dispatch-macro-character cannot define the dispatch macro #(", wantError: "invalid-form"},
+		{name: "another dispatch character", source: "(set-dispatch-macro-character \"$\" \"d\" (lambda (x) x))", want: "only supports the dispatch character \"#\"", wantError: "type-error"},
+		{name: "a long sub-character", source: "(set-dispatch-macro-character \"#\" \"dd\" (lambda (x) x))", want: "expects a string of one character but got \"dd\"", wantError: "type-error"},
+		{name: "a macro that is not a function", source: "(set-dispatch-macro-character \"#\" \"d\" 1)", want: "set-dispatch-macro-character expects a function but got 1", wantError: "type-error"},
+		{name: "set-dispatch-macro-character arity", source: "(set-dispatch-macro-character \"#\")", want: "expects 3 arguments but got 1", wantError: "arity-mismatch"},
+	})
+}
+
+func TestGoReaderMacros(t *testing.T) {
+	interpreter := NewInterpreter(InterpreterOptions{})
+
+	macros := map[rune]ReaderMacro{
+		't': func(reader *Reader, span Span) (Expression, error) {
+			datum, err := reader.Read()
+			if err != nil {
+				return nil, err
+			}
+			return makeListFromSlice([]Expression{Intern("list"), datum, datum}, span), nil
+		},
+		'p': func(reader *Reader, span Span) (Expression, error) {
+			var list *List
+			return list.left, nil
+		},
+		'f': func(reader *Reader, span Span) (Expression, error) {
+			return nil, errors.New("always fails")
+		},
+		'n': func(reader *Reader, span Span) (Expression, error) {
+			return nil, nil
+		},
+	}
+	for character, macro := range macros {
+		if err := interpreter.SetDispatchMacro(character, macro); err != nil {
+			t.Fatalf("SetDispatchMacro('%c') failed: %s", character, err)
+		}
+	}
+	for _, character := range "'(|;+- )" {
+		if err := interpreter.SetDispatchMacro(character, macros['t']); err == nil {
+			t.Errorf("SetDispatchMacro('%c') redefined a standard dispatch macro", character)
+		}
+	}
+
+	tests := []evalTest{
+		{name: "a Go dispatch macro", source: "#t(+ 1 2)", want: "(3 3)"},
+		{name: "a panicking dispatch macro", source: "(list #p 1)", want: "the dispatch macro #p failed: runtime error", wantError: "reader-error"},
+		{name: "a failing dispatch macro", source: "#f", want: "always fails", wantError: "reader-error"},
+		{name: "a dispatch macro without an expression", source: "#n", want: "the dispatch macro #n returned no expression", wantError: "reader-error"},
+		{name: "the interpreter still works", source: "#t1", want: "(1 1)"},
+		{name: "the standard dispatch macros still work", source: "(list (funcall #'car '(1)) #(1) #+golisp 2)", want: "(1 #(1) 2)"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkResult(t, test, interpreter.Eval(test.source))
+		})
+	}
+}
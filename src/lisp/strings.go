@@ -0,0 +1,313 @@
+package lisp
+
+import (
+	"math/big"
+	"strings"
+	"unicode/utf8"
+)
+
+// String builtins count positions and lengths in characters (runes), not
+// in bytes.
+
+func expectString(functionName string, argument Expression) (string, EvaluationResult) {
+	value, ok := argument.(String)
+	if !ok {
+		return "", newEvaluationError(TypeMismatchError, Span{}, "%s expects a string but got %s", functionName, describe(argument))
+	}
+	return value.Value, nil
+}
+
+func expectIndex(functionName string, argument Expression) (int, EvaluationResult) {
+	value, ok := argument.(Int)
+	if !ok || value.Value < 0 {
+		return 0, newEvaluationError(TypeMismatchError, Span{}, "%s expects a non negative int but got %s", functionName, describe(argument))
+	}
+	return value.Value, nil
+}
+
+func concat(arguments []Expression) EvaluationResult {
+	var result strings.Builder
+
+	for _, argument := range arguments {
+		value, failure := expectString("concat", argument)
+		if failure != nil {
+			return failure
+		}
+		result.WriteString(value)
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: String{Value: result.String()},
+	}
+}
+
+// substring implements (substring string start [end]).
+func substring(arguments []Expression) EvaluationResult {
+	if failure := checkArityRange("substring", arguments, 2, 3); failure != nil {
+		return failure
+	}
+
+	value, failure := expectString("substring", arguments[0])
+	if failure != nil {
+		return failure
+	}
+	runes := []rune(value)
+
+	start, failure := expectIndex("substring", arguments[1])
+	if failure != nil {
+		return failure
+	}
+
+	end := len(runes)
+	if len(arguments) == 3 && !isNil(arguments[2]) {
+		end, failure = expectIndex("substring", arguments[2])
+		if failure != nil {
+			return failure
+		}
+	}
+
+	if start > end || end > len(runes) {
+		return newEvaluationError(TypeMismatchError, Span{}, "substring bounds %d and %d are out of the %d characters of %q", start, end, len(runes), value)
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: String{Value: string(runes[start:end])},
+	}
+}
+
+func stringLength(arguments []Expression) EvaluationResult {
+	if failure := checkArity("string-length", arguments, 1); failure != nil {
+		return failure
+	}
+
+	value, failure := expectString("string-length", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: Int{Value: utf8.RuneCountInString(value)},
+	}
+}
+
+func stringUpcase(arguments []Expression) EvaluationResult {
+	if failure := checkArity("string-upcase", arguments, 1); failure != nil {
+		return failure
+	}
+
+	value, failure := expectString("string-upcase", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: String{Value: strings.ToUpper(value)},
+	}
+}
+
+func stringDowncase(arguments []Expression) EvaluationResult {
+	if failure := checkArity("string-downcase", arguments, 1); failure != nil {
+		return failure
+	}
+
+	value, failure := expectString("string-downcase", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: String{Value: strings.ToLower(value)},
+	}
+}
+
+// split implements (split string [separator]), which returns the list of
+// the parts of string. Without separator, the string is split around runs
+// of whitespace.
+func split(arguments []Expression) EvaluationResult {
+	if failure := checkArityRange("split", arguments, 1, 2); failure != nil {
+		return failure
+	}
+
+	value, failure := expectString("split", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	var parts []string
+	if len(arguments) == 1 {
+		parts = strings.Fields(value)
+	} else {
+		separator, failure := expectString("split", arguments[1])
+		if failure != nil {
+			return failure
+		}
+		parts = strings.Split(value, separator)
+	}
+
+	elements := []Expression{}
+	for _, part := range parts {
+		elements = append(elements, String{Value: part})
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: makeListFromSlice(elements, Span{}),
+	}
+}
+
+// join implements (join list [separator]), the inverse of split.
+func join(arguments []Expression) EvaluationResult {
+	if failure := checkArityRange("join", arguments, 1, 2); failure != nil {
+		return failure
+	}
+
+	elements, ok := listToSlice(arguments[0])
+	if !ok {
+		return newEvaluationError(TypeMismatchError, Span{}, "join expects a list of strings but got %s", describe(arguments[0]))
+	}
+
+	separator := ""
+	if len(arguments) == 2 {
+		var failure EvaluationResult
+		separator, failure = expectString("join", arguments[1])
+		if failure != nil {
+			return failure
+		}
+	}
+
+	parts := []string{}
+	for _, element := range elements {
+		part, failure := expectString("join", element)
+		if failure != nil {
+			return failure
+		}
+		parts = append(parts, part)
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: String{Value: strings.Join(parts, separator)},
+	}
+}
+
+func compareStrings(functionName string, arguments []Expression) (int, EvaluationResult) {
+	if failure := checkArity(functionName, arguments, 2); failure != nil {
+		return 0, failure
+	}
+
+	a, failure := expectString(functionName, arguments[0])
+	if failure != nil {
+		return 0, failure
+	}
+	b, failure := expectString(functionName, arguments[1])
+	if failure != nil {
+		return 0, failure
+	}
+
+	// comparing UTF-8 bytes orders the strings by code points
+	return strings.Compare(a, b), nil
+}
+
+func stringEqual(arguments []Expression) EvaluationResult {
+	comparison, failure := compareStrings("string=", arguments)
+	if failure != nil {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: Boolean{Value: comparison == 0},
+	}
+}
+
+func stringLess(arguments []Expression) EvaluationResult {
+	comparison, failure := compareStrings("string<", arguments)
+	if failure != nil {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: Boolean{Value: comparison < 0},
+	}
+}
+
+// search implements (search needle haystack), which returns the position
+// of the first occurrence of needle in haystack, or NIL.
+func search(arguments []Expression) EvaluationResult {
+	if failure := checkArity("search", arguments, 2); failure != nil {
+		return failure
+	}
+
+	needle, failure := expectString("search", arguments[0])
+	if failure != nil {
+		return failure
+	}
+	haystack, failure := expectString("search", arguments[1])
+	if failure != nil {
+		return failure
+	}
+
+	index := strings.Index(haystack, needle)
+	if index < 0 {
+		return SuccessfulEvaluationResult{
+			Expression: Boolean{Value: false},
+		}
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: Int{Value: utf8.RuneCountInString(haystack[:index])},
+	}
+}
+
+// stringToNumber returns the number written in a string, or NIL when the
+// string does not hold a number.
+func stringToNumber(arguments []Expression) EvaluationResult {
+	if failure := checkArity("string->number", arguments, 1); failure != nil {
+		return failure
+	}
+
+	value, failure := expectString("string->number", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	number, ok, err := parseNumber(strings.TrimSpace(value), Span{})
+	if !ok || err != nil {
+		return SuccessfulEvaluationResult{
+			Expression: Boolean{Value: false},
+		}
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: number,
+	}
+}
+
+// numberToString implements (number->string number [radix]), the radix
+// being only allowed for integers.
+func numberToString(arguments []Expression) EvaluationResult {
+	if failure := checkArityRange("number->string", arguments, 1, 2); failure != nil {
+		return failure
+	}
+
+	if _, failure := expectNumbers("number->string", arguments[:1]); failure != nil {
+		return failure
+	}
+
+	if len(arguments) == 1 {
+		return SuccessfulEvaluationResult{
+			Expression: String{Value: arguments[0].Print()},
+		}
+	}
+
+	radix, ok := arguments[1].(Int)
+	if !ok || radix.Value < 2 || radix.Value > 36 {
+		return newEvaluationError(TypeMismatchError, Span{}, "number->string expects a radix between 2 and 36 but got %s", describe(arguments[1]))
+	}
+
+	integer := toBigInt(arguments[0])
+	if integer == nil {
+		return newEvaluationError(TypeMismatchError, Span{}, "number->string only accepts a radix for integers but got %s", describe(arguments[0]))
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: String{Value: new(big.Int).Set(integer).Text(radix.Value)},
+	}
+}
@@ -0,0 +1,39 @@
+package lisp
+
+import (
+	"testing"
+)
+
+func TestStrings(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "escape sequences", source: `(string-length "a\n\t\"\\")`, want: "5"},
+		{name: "a newline escape", source: `(split "a\nb" "\n")`, want: "(a b)"},
+		{name: "a unicode escape", source: `"caf\u{e9}"`, want: "café"},
+		{name: "concat", source: `(concat "foo" "bar" "")`, want: "foobar"},
+		{name: "concat without arguments", source: `(string-length (concat))`, want: "0"},
+		{name: "lengths count characters", source: `(string-length "héllo")`, want: "5"},
+		{name: "substring", source: `(substring "héllo" 1 3)`, want: "él"},
+		{name: "substring to the end", source: `(substring "hello" 2)`, want: "llo"},
+		{name: "upcase and downcase", source: `(concat (string-upcase "abc") (string-downcase "DEF"))`, want: "ABCdef"},
+		{name: "split on whitespace", source: `(length (split "  a b\t c "))`, want: "3"},
+		{name: "split on a separator", source: `(split "a,b,,c" ",")`, want: "(a b  c)"},
+		{name: "join", source: `(join (list "a" "b" "c") "-")`, want: "a-b-c"},
+		{name: "join undoes split", source: `(join (split "x/y/z" "/") "/")`, want: "x/y/z"},
+		{name: "string=", source: `(list (string= "a" "a") (string= "a" "b"))`, want: "(T NIL)"},
+		{name: "string<", source: `(list (string< "abc" "abd") (string< "b" "a"))`, want: "(T NIL)"},
+		{name: "search", source: `(list (search "lo" "hello") (search "x" "hello"))`, want: "(3 NIL)"},
+		{name: "string->number", source: `(list (string->number "42") (string->number "1/2") (string->number "x"))`, want: "(42 1/2 NIL)"},
+		{name: "number->string", source: `(concat (number->string 255 16) " " (number->string 1.5))`, want: "ff 1.5"},
+		{name: "stringp", source: `(list (stringp "a") (stringp 'a))`, want: "(T NIL)"},
+
+		{name: "an unknown escape", source: `"a\qb"`, want: "unknown escape sequence \\q", wantError: "reader-error"},
+		{name: "concat of a number", source: `(concat "a" 1)`, want: "concat expects a string but got 1", wantError: "type-error"},
+		{name: "substring out of bounds", source: `(substring "abc" 1 5)`, want: "substring bounds 1 and 5 are out of the 3 characters", wantError: "type-error"},
+		{name: "substring with a negative start", source: `(substring "abc" -1)`, want: "substring expects a non negative int", wantError: "type-error"},
+		{name: "string-length of a symbol", source: `(string-length 'abc)`, wantError: "type-error"},
+		{name: "join of a number", source: `(join 1)`, want: "join expects a list of strings", wantError: "type-error"},
+		{name: "number->string with a bad radix", source: `(number->string 10 1)`, want: "radix between 2 and 36", wantError: "type-error"},
+		{name: "number->string of a float in a radix", source: `(number->string 1.5 2)`, want: "only accepts a radix for integers", wantError: "type-error"},
+		{name: "string= arity", source: `(string= "a")`, wantError: "arity-mismatch"},
+	})
+}
@@ -0,0 +1,333 @@
+package lisp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Structures are the records defined with defstruct. A definition such as
+// (defstruct point x (y 0)) defines the functions make-point, point-x,
+// point-y, point-p and copy-point, and the setf functions of the accessors.
+// These functions are made of Lisp code calling Go builtins, so that the
+// constructor gets its keyword parameters and slot defaults from the lambda
+// list of a regular function. Structures print as #S(point :x 1 :y 0),
+// which reads back by calling the constructor with the slot values.
+
+type structureType struct {
+	name  string
+	slots []string
+	// constructor is the keyword constructor, nil when defstruct was told
+	// not to define one.
+	constructor Expression
+}
+
+// Structure is an instance of a type defined with defstruct.
+type Structure struct {
+	BaseTypeExpression
+	structureType *structureType
+	values        []Expression
+	Span          Span
+}
+
+func (s *Structure) GetType() string {
+	return "structure"
+}
+
+func (s *Structure) GetSpan() Span {
+	return s.Span
+}
+
+// Print writes a structure as #S(name :slot value ...).
+func (s *Structure) Print() string {
+	return printExpression(s)
+}
+
+func (s *Structure) Evaluate(context EvaluationContext) EvaluationResult {
+	return SuccessfulEvaluationResult{
+		Expression: s,
+	}
+}
+
+// setfFunctionName is the name under which the function assigning a place
+// (accessor ...) is defined.
+func setfFunctionName(accessor string) string {
+	return fmt.Sprintf("(setf %s)", accessor)
+}
+
+// structureSlot is a slot in a defstruct form, written either as a name or
+// as (name [default [:read-only flag] [:type type]]).
+type structureSlot struct {
+	name         Symbol
+	defaultValue Expression
+	readOnly     bool
+}
+
+func parseStructureSlot(specification Expression) (structureSlot, EvaluationResult) {
+	if symbol, ok := specification.(Symbol); ok {
+		return structureSlot{name: symbol, defaultValue: Boolean{Value: false}}, nil
+	}
+
+	elements, ok := listToSlice(specification)
+	if !ok || len(elements) == 0 || (len(elements) > 1 && len(elements)%2 != 0) || elements[0].GetType() != "symbol" {
+		return structureSlot{}, newEvaluationError(InvalidFormError, specification.GetSpan(), "defstruct expects a slot name or (name [default option value...]) but got %s", specification.Print())
+	}
+
+	slot := structureSlot{name: elements[0].(Symbol), defaultValue: Boolean{Value: false}}
+	if len(elements) > 1 {
+		slot.defaultValue = elements[1]
+	}
+	for i := 2; i < len(elements); i += 2 {
+		switch {
+		case isFormSymbol(elements[i], ":read-only"):
+			slot.readOnly = isTrue(elements[i+1])
+		case isFormSymbol(elements[i], ":type"):
+		default:
+			return structureSlot{}, newEvaluationError(InvalidFormError, elements[i].GetSpan(), "defstruct does not support the slot option %s", elements[i].Print())
+		}
+	}
+	return slot, nil
+}
+
+// parseStructureName reads the name of a defstruct form and its options,
+// written name or (name (:conc-name prefix) (:constructor name) ...). It
+// returns the name and the names of the functions to define, an empty name
+// meaning the function is not defined.
+func parseStructureName(specification Expression) (string, map[string]string, EvaluationResult) {
+	elements := []Expression{specification}
+	if specification.GetType() == "list" {
+		elements, _ = listToSlice(specification)
+	}
+	if len(elements) == 0 || elements[0].GetType() != "symbol" {
+		return "", nil, newEvaluationError(InvalidFormError, specification.GetSpan(), "defstruct expects a structure name but got %s", describe(specification))
+	}
+
+	// make-name and copy-name are in the package of the name
+	name := elements[0].(Symbol).Name()
+	qualifier := strings.TrimSuffix(name, unqualifiedName(name))
+	names := map[string]string{
+		":conc-name":   name + "-",
+		":constructor": qualifier + "make-" + unqualifiedName(name),
+		":predicate":   name + "-p",
+		":copier":      qualifier + "copy-" + unqualifiedName(name),
+	}
+
+	for _, option := range elements[1:] {
+		optionElements, _ := listToSlice(option)
+		if len(optionElements) == 0 || len(optionElements) > 2 {
+			return "", nil, newEvaluationError(InvalidFormError, option.GetSpan(), "defstruct expects an option of the form (:option value) but got %s", option.Print())
+		}
+		keyword, ok := optionElements[0].(Symbol)
+		if ok {
+			_, ok = names[keyword.Name()]
+		}
+		if !ok {
+			return "", nil, newEvaluationError(InvalidFormError, option.GetSpan(), "defstruct does not support the option %s", optionElements[0].Print())
+		}
+
+		value := ""
+		if len(optionElements) == 2 && !isNil(optionElements[1]) {
+			symbol, ok := optionElements[1].(Symbol)
+			if !ok {
+				return "", nil, newEvaluationError(InvalidFormError, option.GetSpan(), "defstruct expects a name in the option %s", option.Print())
+			}
+			value = symbol.Name()
+		}
+		names[keyword.Name()] = value
+	}
+
+	return name, names, nil
+}
+
+// accessorName returns the name of the accessor of a slot, which is in the
+// package of the prefix, or of the slot name when there is no prefix.
+func accessorName(concName string, slot structureSlot) string {
+	if concName == "" {
+		return slot.name.Name()
+	}
+	return concName + unqualifiedName(slot.name.Name())
+}
+
+// defineStructure implements (defstruct name-and-options slot...).
+func defineStructure(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	if len(arguments) == 0 {
+		return newEvaluationError(ArityMismatchError, span, "defstruct expects a structure name")
+	}
+
+	name, names, failure := parseStructureName(arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	slotForms := arguments[1:]
+	if len(slotForms) > 0 && slotForms[0].GetType() == "string" {
+		slotForms = slotForms[1:]
+	}
+
+	definition := &structureType{name: name}
+	slots := []structureSlot{}
+	for _, slotForm := range slotForms {
+		slot, failure := parseStructureSlot(slotForm)
+		if failure != nil {
+			return failure
+		}
+		slots = append(slots, slot)
+		definition.slots = append(definition.slots, unqualifiedName(slot.name.Name()))
+	}
+
+	// none of the functions is defined when one of them is named like a
+	// builtin
+	for _, slot := range slots {
+		if failure := checkDefinable("defstruct", accessorName(names[":conc-name"], slot), span, context); failure != nil {
+			return failure
+		}
+	}
+	for _, option := range []string{":constructor", ":predicate", ":copier"} {
+		if failure := checkDefinable("defstruct", names[option], span, context); names[option] != "" && failure != nil {
+			return failure
+		}
+	}
+
+	// each function is (lambda parameters (funcall builtin arguments...))
+	define := func(functionName string, parameters []Expression, builtin func(arguments []Expression) EvaluationResult, arguments ...Expression) EvaluationResult {
+		call := append([]Expression{Intern("funcall"), Builtin{Name: functionName, function: builtin}}, arguments...)
+		function, failure := makeFunction(functionName, []Expression{makeListFromSlice(parameters, Span{}), makeListFromSlice(call, span)}, span, context)
+		if failure != nil {
+			return failure
+		}
+		context.DefineFunction(functionName, function)
+		return nil
+	}
+
+	object := Intern("object")
+	value := Intern("value")
+
+	if constructor := names[":constructor"]; constructor != "" {
+		parameters := []Expression{Intern("&key")}
+		variables := []Expression{}
+		for _, slot := range slots {
+			parameters = append(parameters, makeListFromSlice([]Expression{slot.name, slot.defaultValue}, Span{}))
+			variables = append(variables, slot.name)
+		}
+		if failure := define(constructor, parameters, definition.construct, variables...); failure != nil {
+			return failure
+		}
+		definition.constructor, _ = context.LookupFunction(constructor)
+	}
+
+	for i, slot := range slots {
+		accessor := accessorName(names[":conc-name"], slot)
+		if failure := define(accessor, []Expression{object}, definition.reader(accessor, i), object); failure != nil {
+			return failure
+		}
+		if !slot.readOnly {
+			if failure := define(setfFunctionName(accessor), []Expression{value, object}, definition.writer(accessor, i), value, object); failure != nil {
+				return failure
+			}
+		}
+	}
+
+	if predicate := names[":predicate"]; predicate != "" {
+		if failure := define(predicate, []Expression{object}, definition.predicate(), object); failure != nil {
+			return failure
+		}
+	}
+
+	if copier := names[":copier"]; copier != "" {
+		if failure := define(copier, []Expression{object}, definition.copier(copier), object); failure != nil {
+			return failure
+		}
+	}
+
+	context.Global().structures[name] = definition
+
+	return SuccessfulEvaluationResult{
+		Expression: InternAt(name, arguments[0].GetSpan()),
+	}
+}
+
+// structureReaderMacro reads #S(name :slot value ...), calling the
+// constructor of the structures defined in the global context with the
+// slot values, which are not evaluated.
+func structureReaderMacro(global EvaluationContext) ReaderMacro {
+	return func(reader *Reader, span Span) (Expression, error) {
+		datum, err := reader.readDatum(Token{Kind: DispatchToken, Text: "#S", Span: span})
+		if err != nil {
+			return nil, err
+		}
+
+		elements, ok := listToSlice(datum)
+		if !ok || len(elements) == 0 || elements[0].GetType() != "symbol" {
+			return nil, SyntaxError{Message: fmt.Sprintf("#S expects (name :slot value...) but got %s", datum.Print()), Span: span}
+		}
+
+		name := elements[0].(Symbol).Name()
+		definition, ok := global.structures[name]
+		if !ok {
+			return nil, SyntaxError{Message: fmt.Sprintf("#S cannot read %s, which is not a structure", name), Span: span}
+		}
+		if definition.constructor == nil {
+			return nil, SyntaxError{Message: fmt.Sprintf("#S cannot read %s, which has no constructor", name), Span: span}
+		}
+		return readerMacroExpression("#S", applyFunction(definition.constructor, elements[1:], span), span)
+	}
+}
+
+func (t *structureType) construct(arguments []Expression) EvaluationResult {
+	return SuccessfulEvaluationResult{
+		Expression: &Structure{structureType: t, values: append([]Expression{}, arguments...)},
+	}
+}
+
+func (t *structureType) expectStructure(functionName string, argument Expression) (*Structure, EvaluationResult) {
+	structure, ok := argument.(*Structure)
+	if !ok || structure.structureType != t {
+		return nil, newEvaluationError(TypeMismatchError, Span{}, "%s expects a %s but got %s", functionName, t.name, describe(argument))
+	}
+	return structure, nil
+}
+
+func (t *structureType) reader(functionName string, index int) func(arguments []Expression) EvaluationResult {
+	return func(arguments []Expression) EvaluationResult {
+		structure, failure := t.expectStructure(functionName, arguments[0])
+		if failure != nil {
+			return failure
+		}
+		return SuccessfulEvaluationResult{
+			Expression: structure.values[index],
+		}
+	}
+}
+
+func (t *structureType) writer(functionName string, index int) func(arguments []Expression) EvaluationResult {
+	return func(arguments []Expression) EvaluationResult {
+		structure, failure := t.expectStructure(functionName, arguments[1])
+		if failure != nil {
+			return failure
+		}
+		structure.values[index] = arguments[0]
+		return SuccessfulEvaluationResult{
+			Expression: arguments[0],
+		}
+	}
+}
+
+func (t *structureType) predicate() func(arguments []Expression) EvaluationResult {
+	return func(arguments []Expression) EvaluationResult {
+		structure, ok := arguments[0].(*Structure)
+		return SuccessfulEvaluationResult{
+			Expression: Boolean{Value: ok && structure.structureType == t},
+		}
+	}
+}
+
+func (t *structureType) copier(functionName string) func(arguments []Expression) EvaluationResult {
+	return func(arguments []Expression) EvaluationResult {
+		structure, failure := t.expectStructure(functionName, arguments[0])
+		if failure != nil {
+			return failure
+		}
+		return SuccessfulEvaluationResult{
+			Expression: &Structure{structureType: t, values: append([]Expression{}, structure.values...)},
+		}
+	}
+}
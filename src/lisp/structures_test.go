@@ -0,0 +1,56 @@
+package lisp
+
+import (
+	"testing"
+)
+
+func TestStructures(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "defstruct returns the name", source: "(defstruct point x y)", want: "point"},
+		{name: "constructor and accessors", source: "(defstruct point x y) (setq p (make-point :x 1 :y 2)) (list (point-x p) (point-y p))", want: "(1 2)"},
+		{name: "defaults", source: "(defstruct point (x 0) y) (make-point)", want: "#S(point :x 0 :y NIL)"},
+		{name: "defaults are evaluated on each call", source: "(setq n 0) (defstruct counter (id (setq n (+ n 1)))) (list (counter-id (make-counter)) (counter-id (make-counter)))", want: "(1 2)"},
+		{name: "predicate", source: "(defstruct point x) (defstruct other x) (list (point-p (make-point)) (point-p (make-other)) (point-p 1))", want: "(T NIL NIL)"},
+		{name: "copier", source: "(defstruct point x) (setq p (make-point :x 1)) (setq q (copy-point p)) (setf (point-x q) 2) (list (point-x p) (point-x q))", want: "(1 2)"},
+		{name: "printing", source: "(defstruct point x y) (make-point :x 1 :y \"s\")", want: "#S(point :x 1 :y s)"},
+		{name: "conc-name", source: "(defstruct (point (:conc-name p-)) x) (p-x (make-point :x 3))", want: "3"},
+		{name: "no conc-name", source: "(defstruct (point (:conc-name)) x) (x (make-point :x 3))", want: "3"},
+		{name: "constructor name", source: "(defstruct (point (:constructor new-point)) x) (point-x (new-point :x 4))", want: "4"},
+		{name: "predicate name", source: "(defstruct (point (:predicate is-point)) x) (is-point (make-point))", want: "T"},
+		{name: "read-only slot", source: "(defstruct point (x 0 :read-only T)) (setf (point-x (make-point)) 1)", want: "setf cannot assign (point-x (make-point)), which is not a place", wantError: "invalid-form"},
+		{name: "redefinition", source: "(defstruct point x) (defstruct point x y) (make-point :y 1)", want: "#S(point :x NIL :y 1)"},
+		{name: "structures as hash keys", source: "(defstruct point x) (setq p (make-point)) (setq h (make-hash-table)) (setf (gethash p h) :p) (list (gethash p h) (gethash (make-point) h))", want: "(:p NIL)"},
+
+		{name: "#S reads a structure", source: "(defstruct point x y) (point-y #S(point :y 2 :x 1))", want: "2"},
+		{name: "#S uses the defaults", source: "(defstruct point (x 7) y) #S(point)", want: "#S(point :x 7 :y NIL)"},
+		{name: "#S does not evaluate the values", source: "(defstruct box content) (box-content #S(box :content (+ 1 2)))", want: "(+ 1 2)"},
+		{name: "#S in lower case", source: "(defstruct point x) #s(point :x 1)", want: "#S(point :x 1)"},
+		{name: "#S quoted", source: "(defstruct point x) (point-p '#S(point :x 1))", want: "T"},
+
+		{name: "#S of an unknown structure", source: "#S(nothing :x 1)", want: "#S cannot read nothing, which is not a structure", wantError: "reader-error"},
+		{name: "#S without a constructor", source: "(defstruct (point (:constructor NIL)) x) #S(point :x 1)", want: "#S cannot read point, which has no constructor", wantError: "reader-error"},
+		{name: "#S of a number", source: "#S(1 2)", want: "#S expects (name :slot value...) but got (1 2)", wantError: "reader-error"},
+		{name: "#S of a symbol", source: "#S point", wantError: "reader-error"},
+		{name: "#S with an unknown slot", source: "(defstruct point x) #S(point :z 1)", want: "does not accept the keyword :z", wantError: "reader-error"},
+		{name: "an accessor of another type", source: "(defstruct point x) (defstruct other x) (point-x (make-other))", want: "point-x expects a point but got", wantError: "type-error"},
+		{name: "an accessor of a number", source: "(defstruct point x) (point-x 1)", want: "point-x expects a point but got 1 (int)", wantError: "type-error"},
+		{name: "an unknown constructor keyword", source: "(defstruct point x) (make-point :z 1)", want: "make-point does not accept the keyword :z, only :x", wantError: "arity-mismatch"},
+		{name: "defstruct without a name", source: "(defstruct)", want: "defstruct expects a structure name", wantError: "arity-mismatch"},
+		{name: "a number as a name", source: "(defstruct 1 x)", want: "defstruct expects a structure name but got 1", wantError: "invalid-form"},
+		{name: "a bad slot", source: "(defstruct point (x 0 1))", want: "defstruct expects a slot name or (name [default option value...])", wantError: "invalid-form"},
+		{name: "an unknown slot option", source: "(defstruct point (x 0 :size 1))", want: "defstruct does not support the slot option :size", wantError: "invalid-form"},
+		{name: "an unknown option", source: "(defstruct (point (:include thing)) x)", want: "defstruct does not support the option :include", wantError: "invalid-form"},
+		{name: "a bad option", source: "(defstruct (point (:conc-name a b)) x)", want: "defstruct expects an option of the form (:option value)", wantError: "invalid-form"},
+		{name: "an option with a number", source: "(defstruct (point (:constructor 1)) x)", want: "defstruct expects a name in the option", wantError: "invalid-form"},
+	})
+}
+
+func TestStructuresReadBack(t *testing.T) {
+	interpreter := NewInterpreter(InterpreterOptions{})
+
+	printed := interpreter.Eval("(defstruct point x y) (make-point :x 1 :y '(a (b)))")
+	checkResult(t, evalTest{want: "#S(point :x 1 :y (a (b)))"}, printed)
+
+	source := "(setq p " + printed.(SuccessfulEvaluationResult).Expression.Print() + ")"
+	checkResult(t, evalTest{want: "(T (a (b)))"}, interpreter.Eval(source+" (list (point-p p) (point-y p))"))
+}
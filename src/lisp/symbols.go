@@ -0,0 +1,319 @@
+package lisp
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// symbolData holds what is shared by every occurrence of a symbol.
+type symbolData struct {
+	name     string
+	interned bool
+}
+
+// Symbol is an occurrence of a symbol. Symbols read with the same name are
+// interned: they share the same symbolData and are eq, whatever the place
+// they were read from.
+type Symbol struct {
+	BaseTypeExpression
+	data *symbolData
+	Span Span
+}
+
+// The symbol table is shared by all interpreters, which is harmless as
+// symbols only hold their names; what an interpreter attaches to them is
+// kept in its symbolState.
+var symbolTable = map[string]*symbolData{}
+var symbolTableMutex sync.Mutex
+
+// symbolState holds the property lists of the symbols of an interpreter,
+// lists alternating indicators and values, and the number of symbols made
+// by gensym.
+type symbolState struct {
+	plists        map[*symbolData]Expression
+	gensymCounter int
+}
+
+func newSymbolState() *symbolState {
+	return &symbolState{plists: make(map[*symbolData]Expression)}
+}
+
+// plist returns the property list of a symbol.
+func (state *symbolState) plist(symbol Symbol) Expression {
+	if plist, ok := state.plists[symbol.data]; ok {
+		return plist
+	}
+	return Boolean{Value: false}
+}
+
+// Intern returns the symbol of the given name, creating it on first use.
+func Intern(name string) Symbol {
+	return InternAt(name, Span{})
+}
+
+// InternAt returns the symbol of the given name, as read at span.
+func InternAt(name string, span Span) Symbol {
+	symbolTableMutex.Lock()
+	defer symbolTableMutex.Unlock()
+
+	data, ok := symbolTable[name]
+	if !ok {
+		data = &symbolData{name: name, interned: true}
+		symbolTable[name] = data
+	}
+	return Symbol{data: data, Span: span}
+}
+
+func (s Symbol) Name() string {
+	return s.data.name
+}
+
+// IsKeyword reports whether the symbol is a keyword such as :name, which
+// evaluates to itself.
+func (s Symbol) IsKeyword() bool {
+	return strings.HasPrefix(s.data.name, ":") && len(s.data.name) > 1
+}
+
+func (s Symbol) GetType() string {
+	return "symbol"
+}
+
+func (s Symbol) GetSpan() Span {
+	return s.Span
+}
+
+func (s Symbol) Print() string {
+	if !s.data.interned {
+		return "#:" + s.data.name
+	}
+	return s.data.name
+}
+
+func (s Symbol) Evaluate(context EvaluationContext) EvaluationResult {
+
+	if s.IsKeyword() {
+		return SuccessfulEvaluationResult{
+			Expression: s,
+		}
+	}
+
+	if variableValue, ok := context.LookupVariable(s.Name()); ok {
+		return SuccessfulEvaluationResult{
+			Expression: variableValue,
+		}
+	}
+
+	return newEvaluationError(UnboundVariableError, s.Span, "the variable %s is unbound", s.Name())
+}
+
+// isEq reports whether two values are the same object. Symbols, conses,
+// vectors, hash tables, structures and functions are compared by identity,
+// builtins by name, numbers and booleans by value.
+func isEq(a Expression, b Expression) bool {
+	switch x := a.(type) {
+	case Symbol:
+		y, ok := b.(Symbol)
+		return ok && x.data == y.data
+	case Int:
+		y, ok := b.(Int)
+		return ok && x.Value == y.Value
+	case Boolean:
+		y, ok := b.(Boolean)
+		return ok && x.Value == y.Value
+	case *List:
+		y, ok := b.(*List)
+		return ok && x == y
+	case *Vector:
+		y, ok := b.(*Vector)
+		return ok && x == y
+	case *HashTable:
+		y, ok := b.(*HashTable)
+		return ok && x == y
+	case *Structure:
+		y, ok := b.(*Structure)
+		return ok && x == y
+	case FunctionDeclaration:
+		y, ok := b.(FunctionDeclaration)
+		return ok && x.identity != nil && x.identity == y.identity
+	case Builtin:
+		y, ok := b.(Builtin)
+		return ok && x.Name == y.Name
+	}
+	return false
+}
+
+// isEql extends isEq to numbers of the same type with the same value.
+func isEql(a Expression, b Expression) bool {
+	levelA, okA := numberLevel(a)
+	levelB, okB := numberLevel(b)
+	if okA && okB {
+		return levelA == levelB && compareNumbers(a, b) == 0
+	}
+	return isEq(a, b)
+}
+
+func expectSymbol(functionName string, argument Expression) (Symbol, EvaluationResult) {
+	symbol, ok := argument.(Symbol)
+	if !ok {
+		return Symbol{}, newEvaluationError(TypeMismatchError, Span{}, "%s expects a symbol but got %s", functionName, describe(argument))
+	}
+	return symbol, nil
+}
+
+func eq(arguments []Expression) EvaluationResult {
+	if failure := checkArity("eq", arguments, 2); failure != nil {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: Boolean{Value: isEq(arguments[0], arguments[1])},
+	}
+}
+
+func symbolName(arguments []Expression) EvaluationResult {
+	if failure := checkArity("symbol-name", arguments, 1); failure != nil {
+		return failure
+	}
+
+	symbol, failure := expectSymbol("symbol-name", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: String{Value: unqualifiedName(symbol.Name())},
+	}
+}
+
+// intern returns the symbol of a name in the current package.
+func intern(arguments []Expression, global EvaluationContext) EvaluationResult {
+	if failure := checkArity("intern", arguments, 1); failure != nil {
+		return failure
+	}
+
+	name, ok := arguments[0].(String)
+	if !ok {
+		return newEvaluationError(TypeMismatchError, Span{}, "intern expects a string but got %s", describe(arguments[0]))
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: Intern(global.packages.readName(name.Value)),
+	}
+}
+
+// gensym returns a new symbol that is not interned, and thus cannot be eq
+// to any symbol written in a program. An optional string prefixes its name.
+func gensym(arguments []Expression, global EvaluationContext) EvaluationResult {
+	if len(arguments) > 1 {
+		return newEvaluationError(ArityMismatchError, Span{}, "gensym expects at most 1 argument but got %d", len(arguments))
+	}
+
+	prefix := "G"
+	if len(arguments) == 1 {
+		prefixString, ok := arguments[0].(String)
+		if !ok {
+			return newEvaluationError(TypeMismatchError, Span{}, "gensym expects a string but got %s", describe(arguments[0]))
+		}
+		prefix = prefixString.Value
+	}
+
+	global.symbols.gensymCounter += 1
+	name := fmt.Sprintf("%s%d", prefix, global.symbols.gensymCounter)
+
+	return SuccessfulEvaluationResult{
+		Expression: Symbol{data: &symbolData{name: name}},
+	}
+}
+
+func getProperty(arguments []Expression, global EvaluationContext) EvaluationResult {
+	if len(arguments) != 2 && len(arguments) != 3 {
+		return newEvaluationError(ArityMismatchError, Span{}, "get expects a symbol, an indicator and an optional default but got %s", countArguments(len(arguments)))
+	}
+
+	symbol, failure := expectSymbol("get", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	properties, _ := listToSlice(global.symbols.plist(symbol))
+
+	for i := 0; i+1 < len(properties); i += 2 {
+		if isEq(properties[i], arguments[1]) {
+			return SuccessfulEvaluationResult{Expression: properties[i+1]}
+		}
+	}
+
+	if len(arguments) == 3 {
+		return SuccessfulEvaluationResult{Expression: arguments[2]}
+	}
+	return SuccessfulEvaluationResult{Expression: Boolean{Value: false}}
+}
+
+// putProperty implements (put symbol indicator value), which sets a
+// property of a symbol and returns the value.
+func putProperty(arguments []Expression, global EvaluationContext) EvaluationResult {
+	if failure := checkArity("put", arguments, 3); failure != nil {
+		return failure
+	}
+
+	symbol, failure := expectSymbol("put", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	properties, _ := listToSlice(global.symbols.plist(symbol))
+	found := false
+
+	for i := 0; i+1 < len(properties); i += 2 {
+		if isEq(properties[i], arguments[1]) {
+			properties[i+1] = arguments[2]
+			found = true
+		}
+	}
+	if !found {
+		properties = append(properties, arguments[1], arguments[2])
+	}
+	global.symbols.plists[symbol.data] = makeListFromSlice(properties, Span{})
+
+	return SuccessfulEvaluationResult{Expression: arguments[2]}
+}
+
+func removeProperty(arguments []Expression, global EvaluationContext) EvaluationResult {
+	if failure := checkArity("remprop", arguments, 2); failure != nil {
+		return failure
+	}
+
+	symbol, failure := expectSymbol("remprop", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	properties, _ := listToSlice(global.symbols.plist(symbol))
+	remaining := []Expression{}
+	found := false
+
+	for i := 0; i+1 < len(properties); i += 2 {
+		if isEq(properties[i], arguments[1]) {
+			found = true
+			continue
+		}
+		remaining = append(remaining, properties[i], properties[i+1])
+	}
+	global.symbols.plists[symbol.data] = makeListFromSlice(remaining, Span{})
+
+	return SuccessfulEvaluationResult{Expression: Boolean{Value: found}}
+}
+
+func symbolPlist(arguments []Expression, global EvaluationContext) EvaluationResult {
+	if failure := checkArity("symbol-plist", arguments, 1); failure != nil {
+		return failure
+	}
+
+	symbol, failure := expectSymbol("symbol-plist", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{Expression: global.symbols.plist(symbol)}
+}
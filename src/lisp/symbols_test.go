@@ -0,0 +1,60 @@
+package lisp
+
+import (
+	"testing"
+)
+
+func TestSymbols(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "quote of a symbol", source: "'foo", want: "foo"},
+		{name: "quote of a number", source: "'42", want: "42"},
+		{name: "keywords evaluate to themselves", source: ":key", want: ":key"},
+		{name: "symbols are interned", source: "(eq 'foo 'foo)", want: "T"},
+		{name: "different names", source: "(eq 'foo 'bar)", want: "NIL"},
+		{name: "intern", source: "(eq (intern \"foo\") 'foo)", want: "T"},
+		{name: "symbol-name", source: "(symbol-name 'foo)", want: "foo"},
+		{name: "symbols as values", source: "(setq s 'foo) (list s (symbolp s))", want: "(foo T)"},
+		{name: "gensym", source: "(list (gensym) (gensym \"X\"))", want: "(#:G1 #:X2)"},
+		{name: "gensyms are not interned", source: "(setq g (gensym)) (list (eq g g) (eq g (intern (symbol-name g))))", want: "(T NIL)"},
+
+		{name: "eq of numbers", source: "(eq 3 3)", want: "T"},
+		{name: "eq of lists", source: "(setq a (list 1)) (list (eq a a) (eq a (list 1)))", want: "(T NIL)"},
+		{name: "eq of a function", source: "(defun f () 1) (eq #'f #'f)", want: "T"},
+		{name: "eq of two lambdas", source: "(eq (lambda () 1) (lambda () 1))", want: "NIL"},
+		{name: "eq of a builtin", source: "(eq #'car #'car)", want: "T"},
+		{name: "eq of different builtins", source: "(eq #'car #'cdr)", want: "NIL"},
+		{name: "functions as hash keys", source: `
+			(setq table (make-hash-table :test 'eq))
+			(defun f () 1)
+			(setf (gethash #'f table) :f)
+			(setf (gethash #'car table) :car)
+			(list (gethash #'f table) (gethash #'car table))`, want: "(:f :car)"},
+
+		{name: "put and get", source: "(put 'apple 'color 'red) (get 'apple 'color)", want: "red"},
+		{name: "put replaces a property", source: "(put 'apple 'color 'red) (put 'apple 'color 'green) (symbol-plist 'apple)", want: "(color green)"},
+		{name: "get with a default", source: "(get 'apple 'size :unknown)", want: ":unknown"},
+		{name: "get of a missing property", source: "(get 'apple 'size)", want: "NIL"},
+		{name: "remprop", source: "(put 'apple 'color 'red) (put 'apple 'size 3) (list (remprop 'apple 'color) (remprop 'apple 'color) (symbol-plist 'apple))", want: "(T NIL (size 3))"},
+
+		{name: "intern of a number", source: "(intern 1)", want: "intern expects a string but got 1", wantError: "type-error"},
+		{name: "symbol-name of a string", source: "(symbol-name \"foo\")", want: "symbol-name expects a symbol", wantError: "type-error"},
+		{name: "gensym of a number", source: "(gensym 1)", want: "gensym expects a string", wantError: "type-error"},
+		{name: "gensym arity", source: "(gensym \"a\" \"b\")", want: "gensym expects at most 1 argument", wantError: "arity-mismatch"},
+		{name: "get of a number", source: "(get 1 'color)", want: "get expects a symbol", wantError: "type-error"},
+		{name: "get arity", source: "(get 'apple)", wantError: "arity-mismatch"},
+		{name: "put arity", source: "(put 'apple 'color)", wantError: "arity-mismatch"},
+		{name: "eq arity", source: "(eq 'a)", wantError: "arity-mismatch"},
+		{name: "unbound symbol", source: "foo", want: "the variable foo is unbound", wantError: "unbound-variable"},
+	})
+}
+
+func TestSymbolStateIsPerInterpreter(t *testing.T) {
+	first := NewInterpreter(InterpreterOptions{})
+	second := NewInterpreter(InterpreterOptions{})
+
+	checkResult(t, evalTest{want: "#:G1"}, first.Eval("(gensym)"))
+	checkResult(t, evalTest{want: "#:G2"}, first.Eval("(put 'apple 'color 'red) (gensym)"))
+	checkResult(t, evalTest{want: "#:G1"}, second.Eval("(gensym)"))
+	checkResult(t, evalTest{want: "NIL"}, second.Eval("(get 'apple 'color)"))
+	checkResult(t, evalTest{want: "red"}, first.Eval("(get 'apple 'color)"))
+}
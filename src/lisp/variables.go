@@ -0,0 +1,176 @@
+package lisp
+
+// Global variables declared with defvar or defparameter are dynamic: let,
+// the parameters of functions, the variables of loops and of handler-case
+// rebind them for the time their body runs, so that the functions it calls
+// see the new value, and restore them afterwards, even on error. Their
+// value always lives in the global frame. Variables declared with
+// defconstant cannot be assigned nor rebound.
+
+type variableKind int
+
+const (
+	lexicalVariable variableKind = iota
+	dynamicVariable
+	constantVariable
+)
+
+func (context EvaluationContext) variableKind(name string) variableKind {
+	return context.Global().declarations[name]
+}
+
+// checkAssignable fails when a variable is a constant.
+func checkAssignable(formName string, symbol Symbol, context EvaluationContext) EvaluationResult {
+	if context.variableKind(symbol.Name()) == constantVariable {
+		return newEvaluationError(InvalidFormError, symbol.GetSpan(), "%s cannot change the constant %s", formName, symbol.Name())
+	}
+	return nil
+}
+
+// bindDynamic gives a dynamic variable a new global value, and returns the
+// function restoring the previous one.
+func (context EvaluationContext) bindDynamic(name string, value Expression) func() {
+	global := context.Global()
+	previous, bound := global.variables[name]
+	global.variables[name] = value
+
+	return func() {
+		if bound {
+			global.variables[name] = previous
+		} else {
+			delete(global.variables, name)
+		}
+	}
+}
+
+// binder binds the variables of a binding form, such as let, a function
+// call or a loop: the lexical ones in the frame of the form, and the
+// dynamic ones globally until the form returns.
+type binder struct {
+	frame    EvaluationContext
+	names    []string
+	restores []func()
+}
+
+func newBinder(frame EvaluationContext) *binder {
+	return &binder{frame: frame}
+}
+
+// bind binds a variable. Binding a dynamic variable again, as loops do on
+// each iteration, assigns it.
+func (b *binder) bind(name string, value Expression) {
+	if b.frame.variableKind(name) != dynamicVariable {
+		b.frame.DefineVariable(name, value)
+		return
+	}
+	for _, bound := range b.names {
+		if bound == name {
+			b.frame.Global().variables[name] = value
+			return
+		}
+	}
+	b.names = append(b.names, name)
+	b.restores = append(b.restores, b.frame.bindDynamic(name, value))
+}
+
+// isDynamic reports whether the form rebinds dynamic variables, in which
+// case its body must be done before they are restored, rather than return
+// a call in tail position.
+func (b *binder) isDynamic() bool {
+	return len(b.restores) > 0
+}
+
+// restore restores the dynamic variables once the form returns result. A
+// condition is signaled before, while the variables are still bound.
+func (b *binder) restore(result EvaluationResult) EvaluationResult {
+	if !b.isDynamic() {
+		return result
+	}
+	result = signalCondition(result, b.frame)
+	for i := len(b.restores) - 1; i >= 0; i-- {
+		b.restores[i]()
+	}
+	b.names, b.restores = nil, nil
+	return result
+}
+
+// parseDefinition reads (form name [value [documentation]]), the value
+// being required when valueRequired is set.
+func parseDefinition(formName string, arguments []Expression, span Span, valueRequired bool) (Symbol, EvaluationResult) {
+	if valueRequired && (len(arguments) < 2 || len(arguments) > 3) {
+		return Symbol{}, newEvaluationError(ArityMismatchError, span, "%s expects a name, a value and an optional documentation string but got %s", formName, countArguments(len(arguments)))
+	}
+	if len(arguments) < 1 || len(arguments) > 3 {
+		return Symbol{}, newEvaluationError(ArityMismatchError, span, "%s expects a name, an optional value and an optional documentation string but got %s", formName, countArguments(len(arguments)))
+	}
+
+	symbol, ok := arguments[0].(Symbol)
+	if !ok || symbol.IsKeyword() {
+		return Symbol{}, newEvaluationError(InvalidFormError, arguments[0].GetSpan(), "%s expects a variable name but got %s", formName, describe(arguments[0]))
+	}
+	if len(arguments) == 3 && arguments[2].GetType() != "string" {
+		return Symbol{}, newEvaluationError(InvalidFormError, arguments[2].GetSpan(), "%s expects a documentation string but got %s", formName, describe(arguments[2]))
+	}
+
+	return symbol, nil
+}
+
+// defineVariable implements (defvar name [value [documentation]]), which
+// only assigns the value when the variable is unbound, and (defparameter
+// name value [documentation]), which always does.
+func defineVariable(formName string, arguments []Expression, span Span, context EvaluationContext, always bool) EvaluationResult {
+	symbol, failure := parseDefinition(formName, arguments, span, always)
+	if failure != nil {
+		return failure
+	}
+	if failure := checkAssignable(formName, symbol, context); failure != nil {
+		return failure
+	}
+
+	global := context.Global()
+	global.declarations[symbol.Name()] = dynamicVariable
+
+	if _, bound := global.variables[symbol.Name()]; len(arguments) >= 2 && (always || !bound) {
+		evaluationResult := arguments[1].Evaluate(context)
+		if !evaluationResult.IsSuccessful() {
+			return evaluationResult
+		}
+		global.variables[symbol.Name()] = evaluationResult.(SuccessfulEvaluationResult).Expression
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: symbol,
+	}
+}
+
+// defconstantFunction implements (defconstant name value [documentation]).
+// A constant may be defined again with an equal value only.
+func defconstantFunction(arguments []Expression, span Span, context EvaluationContext) EvaluationResult {
+	symbol, failure := parseDefinition("defconstant", arguments, span, true)
+	if failure != nil {
+		return failure
+	}
+
+	evaluationResult := arguments[1].Evaluate(context)
+	if !evaluationResult.IsSuccessful() {
+		return evaluationResult
+	}
+	value := evaluationResult.(SuccessfulEvaluationResult).Expression
+
+	global := context.Global()
+	switch global.declarations[symbol.Name()] {
+	case constantVariable:
+		if !isEqual(global.variables[symbol.Name()], value) {
+			return newEvaluationError(InvalidFormError, symbol.GetSpan(), "defconstant cannot change the value of the constant %s", symbol.Name())
+		}
+	case dynamicVariable:
+		return newEvaluationError(InvalidFormError, symbol.GetSpan(), "defconstant cannot define %s, which is already a dynamic variable", symbol.Name())
+	}
+
+	global.declarations[symbol.Name()] = constantVariable
+	global.variables[symbol.Name()] = value
+
+	return SuccessfulEvaluationResult{
+		Expression: symbol,
+	}
+}
@@ -0,0 +1,58 @@
+package lisp
+
+import (
+	"testing"
+)
+
+func TestVariables(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "defvar", source: "(defvar *x* 1) *x*", want: "1"},
+		{name: "defvar returns the name", source: "(defvar *x* 1)", want: "*x*"},
+		{name: "defvar keeps the value", source: "(defvar *x* 1) (defvar *x* 2) *x*", want: "1"},
+		{name: "defvar without a value", source: "(defvar *x*) (defvar *x* 3) *x*", want: "3"},
+		{name: "defparameter assigns the value", source: "(defparameter *x* 1) (defparameter *x* 2) *x*", want: "2"},
+		{name: "a documentation string", source: "(defparameter *x* 1 \"the x\") *x*", want: "1"},
+		{name: "let rebinds dynamically", source: "(defvar *depth* 0) (defun depth () *depth*) (list (let ((*depth* 1)) (depth)) (depth))", want: "(1 0)"},
+		{name: "let* rebinds dynamically", source: "(defvar *a* 1) (defun a () *a*) (let* ((*a* 2) (b (a))) b)", want: "2"},
+		{name: "nested rebinding", source: "(defvar *v* :outer) (defun v () *v*) (let ((*v* :middle)) (list (v) (let ((*v* :inner)) (v)) (v)))", want: "(:middle :inner :middle)"},
+		{name: "setq inside a rebinding", source: "(defvar *v* 1) (let ((*v* 2)) (setq *v* 3)) *v*", want: "1"},
+		{name: "the binding is restored on error", source: "(defvar *v* :global) (ignore-errors (let ((*v* :local)) (car 1))) *v*", want: ":global"},
+		{name: "the binding is restored on return", source: "(defvar *v* :global) (dotimes (i 1) (let ((*v* :local)) (return))) *v*", want: ":global"},
+		{name: "the binding is restored after a tail call", source: "(defvar *v* :global) (defun v () *v*) (defun f () (let ((*v* :local)) (v))) (list (f) *v*)", want: "(:local :global)"},
+		{name: "lexical variables are not dynamic", source: "(setq x :global) (defun x () x) (let ((x :local)) (x))", want: ":global"},
+		{name: "closures see the dynamic value", source: "(defvar *v* 1) (setq f (lambda () *v*)) (let ((*v* 2)) (funcall f))", want: "2"},
+		{name: "parameters rebind dynamically", source: "(defvar *x* 1) (defun get-x () *x*) (defun f (*x*) (get-x)) (list (f 5) *x*)", want: "(5 1)"},
+		{name: "optional and key parameters rebind dynamically", source: "(defvar *x* 1) (defun get-x () *x*) (defun f (&optional (*x* 2)) (get-x)) (defun g (&key (*x* 3)) (get-x)) (list (f) (g) (g :*x* 4) *x*)", want: "(2 3 4 1)"},
+		{name: "a rest parameter rebinds dynamically", source: "(defvar *x* 1) (defun get-x () *x*) (defun f (&rest *x*) (get-x)) (list (f 2 3) *x*)", want: "((2 3) 1)"},
+		{name: "a parameter is restored on error", source: "(defvar *x* 1) (defun f (*x*) (car *x*)) (ignore-errors (f 5)) *x*", want: "1"},
+		{name: "dotimes rebinds dynamically", source: "(defvar *i* :global) (defun i () *i*) (setq seen NIL) (dotimes (*i* 3) (push (i) seen)) (list seen *i*)", want: "((2 1 0) :global)"},
+		{name: "dolist rebinds dynamically", source: "(defvar *i* :global) (defun i () *i*) (setq seen NIL) (dolist (*i* '(:a :b)) (push (i) seen)) (list seen *i*)", want: "((:b :a) :global)"},
+		{name: "do rebinds dynamically", source: "(defvar *i* :global) (defun i () *i*) (list (do ((*i* 0 (+ *i* 1))) ((= *i* 2) (i))) *i*)", want: "(2 :global)"},
+		{name: "loop rebinds dynamically", source: "(defvar *i* :global) (defun i () *i*) (list (loop for *i* in '(:a :b) collect (i)) *i*)", want: "((:a :b) :global)"},
+		{name: "a loop variable is restored on return", source: "(defvar *i* :global) (dolist (*i* '(1 2)) (return)) *i*", want: ":global"},
+		{name: "handler-case rebinds dynamically", source: "(defvar *c* NIL) (defun message () (error-message *c*)) (list (handler-case (error \"boom\") (error (*c*) (message))) *c*)", want: "(boom NIL)"},
+		{name: "defconstant", source: "(defconstant +limit+ 10) (* +limit+ 2)", want: "20"},
+		{name: "defconstant again with an equal value", source: "(defconstant +name+ \"x\") (defconstant +name+ \"x\") +name+", want: "x"},
+		{name: "*features*", source: "(listp *features*)", want: "T"},
+
+		{name: "setq of a constant", source: "(defconstant +limit+ 10) (setq +limit+ 11)", want: "setq cannot change the constant +limit+", wantError: "invalid-form"},
+		{name: "setf of a constant", source: "(defconstant +limit+ 10) (setf +limit+ 11)", want: "cannot change the constant +limit+", wantError: "invalid-form"},
+		{name: "incf of a constant", source: "(defconstant +limit+ 10) (incf +limit+)", want: "cannot change the constant +limit+", wantError: "invalid-form"},
+		{name: "let of a constant", source: "(defconstant +limit+ 10) (let ((+limit+ 1)) +limit+)", want: "let cannot change the constant +limit+", wantError: "invalid-form"},
+		{name: "a parameter named as a constant", source: "(defconstant +limit+ 10) (defun f (+limit+) +limit+)", want: "f cannot bind the constant +limit+", wantError: "invalid-form"},
+		{name: "a lambda parameter named as a constant", source: "(defconstant +limit+ 10) (lambda (&key (+limit+ 1)) 1)", want: "lambda cannot bind the constant +limit+", wantError: "invalid-form"},
+		{name: "dotimes of a constant", source: "(defconstant +k+ 1) (dotimes (+k+ 2) +k+)", want: "dotimes cannot change the constant +k+", wantError: "invalid-form"},
+		{name: "dolist of a constant", source: "(defconstant +k+ 1) (dolist (+k+ '(1 2)) +k+)", want: "dolist cannot change the constant +k+", wantError: "invalid-form"},
+		{name: "do of a constant", source: "(defconstant +k+ 1) (do ((+k+ 0 (+ +k+ 1))) ((> +k+ 2)))", want: "do cannot change the constant +k+", wantError: "invalid-form"},
+		{name: "loop of a constant", source: "(defconstant +k+ 1) (loop for +k+ from 1 to 2 collect +k+)", want: "loop cannot change the constant +k+", wantError: "invalid-form"},
+		{name: "handler-case of a constant", source: "(defconstant +k+ 1) (handler-case (error \"boom\") (error (+k+) +k+))", want: "handler-case cannot change the constant +k+", wantError: "invalid-form"},
+		{name: "changing a constant", source: "(defconstant +limit+ 10) (defconstant +limit+ 11)", want: "defconstant cannot change the value of the constant +limit+", wantError: "invalid-form"},
+		{name: "defparameter of a constant", source: "(defconstant +limit+ 10) (defparameter +limit+ 11)", want: "cannot change the constant +limit+", wantError: "invalid-form"},
+		{name: "defconstant of a dynamic variable", source: "(defvar *x* 1) (defconstant *x* 1)", want: "defconstant cannot define *x*, which is already a dynamic variable", wantError: "invalid-form"},
+		{name: "defvar without a name", source: "(defvar)", want: "defvar expects a name, an optional value and an optional documentation string but got 0 arguments", wantError: "arity-mismatch"},
+		{name: "defparameter without a value", source: "(defparameter *x*)", want: "defparameter expects a name, a value and an optional documentation string but got 1 argument", wantError: "arity-mismatch"},
+		{name: "defvar of a number", source: "(defvar 1 2)", want: "defvar expects a variable name but got 1", wantError: "invalid-form"},
+		{name: "a documentation that is not a string", source: "(defvar *x* 1 2)", want: "defvar expects a documentation string but got 2", wantError: "invalid-form"},
+		{name: "an error in the value", source: "(defvar *x* (car 1)) *x*", wantError: "type-error"},
+	})
+}
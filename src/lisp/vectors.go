@@ -0,0 +1,177 @@
+package lisp
+
+// Vector is a one-dimensional array. Every vector can grow with
+// vector-push-extend, as if it were adjustable and had a fill pointer.
+type Vector struct {
+	BaseTypeExpression
+	elements []Expression
+	Span     Span
+}
+
+func (v *Vector) GetType() string {
+	return "vector"
+}
+
+func (v *Vector) GetSpan() Span {
+	return v.Span
+}
+
+func (v *Vector) Print() string {
+	return printExpression(v)
+}
+
+func (v *Vector) Evaluate(context EvaluationContext) EvaluationResult {
+	return SuccessfulEvaluationResult{
+		Expression: v,
+	}
+}
+
+func expectVector(functionName string, argument Expression) (*Vector, EvaluationResult) {
+	vector, ok := argument.(*Vector)
+	if !ok {
+		return nil, newEvaluationError(TypeMismatchError, Span{}, "%s expects a vector but got %s", functionName, describe(argument))
+	}
+	return vector, nil
+}
+
+// expectVectorIndex returns an index of an element of a vector.
+func expectVectorIndex(functionName string, vector *Vector, argument Expression) (int, EvaluationResult) {
+	index, failure := expectIndex(functionName, argument)
+	if failure != nil {
+		return 0, failure
+	}
+	if index >= len(vector.elements) {
+		return 0, newEvaluationError(TypeMismatchError, Span{}, "%s expects an index below %d but got %d", functionName, len(vector.elements), index)
+	}
+	return index, nil
+}
+
+// maxArraySize bounds the size of the vectors made by make-array, so that
+// a large size fails instead of exhausting the memory.
+const maxArraySize = 1 << 24
+
+// makeArray implements (make-array size &key initial-element
+// initial-contents adjustable fill-pointer). A fill pointer smaller than
+// the size makes the vector start with that many elements.
+func makeArray(arguments []Expression) EvaluationResult {
+	positional, keywords, failure := keywordArguments("make-array", arguments, 1, ":initial-element", ":initial-contents", ":adjustable", ":fill-pointer")
+	if failure != nil {
+		return failure
+	}
+	if failure := checkArity("make-array", positional, 1); failure != nil {
+		return failure
+	}
+
+	size, failure := expectIndex("make-array", positional[0])
+	if failure != nil {
+		return failure
+	}
+	if size > maxArraySize {
+		return newEvaluationError(TypeMismatchError, Span{}, "make-array expects a size up to %d but got %d", maxArraySize, size)
+	}
+
+	elements := make([]Expression, size)
+
+	if contents, ok := keywords[":initial-contents"]; ok {
+		values, ok := listToSlice(contents)
+		if vector, isVector := contents.(*Vector); isVector {
+			values, ok = vector.elements, true
+		}
+		if !ok || len(values) != size {
+			return newEvaluationError(TypeMismatchError, Span{}, "make-array expects initial contents of %d elements but got %s", size, describe(contents))
+		}
+		copy(elements, values)
+	} else {
+		initialElement, ok := keywords[":initial-element"]
+		if !ok {
+			initialElement = Boolean{Value: false}
+		}
+		for i := range elements {
+			elements[i] = initialElement
+		}
+	}
+
+	if fillPointer, ok := keywords[":fill-pointer"]; ok && fillPointer.GetType() != "boolean" {
+		length, failure := expectIndex("make-array", fillPointer)
+		if failure != nil {
+			return failure
+		}
+		if length > size {
+			return newEvaluationError(TypeMismatchError, Span{}, "make-array expects a fill pointer up to %d but got %d", size, length)
+		}
+		elements = elements[:length]
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: &Vector{elements: elements},
+	}
+}
+
+func vector(arguments []Expression) EvaluationResult {
+	return SuccessfulEvaluationResult{
+		Expression: &Vector{elements: append([]Expression{}, arguments...)},
+	}
+}
+
+// aref implements (aref vector index).
+func aref(arguments []Expression) EvaluationResult {
+	if failure := checkArity("aref", arguments, 2); failure != nil {
+		return failure
+	}
+
+	vector, failure := expectVector("aref", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	index, failure := expectVectorIndex("aref", vector, arguments[1])
+	if failure != nil {
+		return failure
+	}
+
+	return SuccessfulEvaluationResult{
+		Expression: vector.elements[index],
+	}
+}
+
+func arefSetter(arguments []Expression, value Expression, global EvaluationContext) EvaluationResult {
+	if failure := checkArity("aref", arguments, 2); failure != nil {
+		return failure
+	}
+
+	vector, failure := expectVector("aref", arguments[0])
+	if failure != nil {
+		return failure
+	}
+
+	index, failure := expectVectorIndex("aref", vector, arguments[1])
+	if failure != nil {
+		return failure
+	}
+
+	vector.elements[index] = value
+
+	return SuccessfulEvaluationResult{
+		Expression: value,
+	}
+}
+
+// vectorPushExtend implements (vector-push-extend element vector
+// [extension]), which appends an element to a vector and returns its
+// index. The extension is accepted for compatibility and ignored.
+func vectorPushExtend(arguments []Expression) EvaluationResult {
+	if failure := checkArityRange("vector-push-extend", arguments, 2, 3); failure != nil {
+		return failure
+	}
+
+	vector, failure := expectVector("vector-push-extend", arguments[1])
+	if failure != nil {
+		return failure
+	}
+
+	vector.elements = append(vector.elements, arguments[0])
+
+	return SuccessfulEvaluationResult{
+		Expression: Int{Value: len(vector.elements) - 1},
+	}
+}
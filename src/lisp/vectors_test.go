@@ -0,0 +1,32 @@
+package lisp
+
+import (
+	"testing"
+)
+
+func TestVectors(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "vector", source: "(vector 1 :a \"s\")", want: "#(1 :a s)"},
+		{name: "an empty vector", source: "(vector)", want: "#()"},
+		{name: "aref", source: "(setq v (vector 'a 'b 'c)) (aref v 1)", want: "b"},
+		{name: "make-array", source: "(make-array 3)", want: "#(NIL NIL NIL)"},
+		{name: "make-array with an initial element", source: "(make-array 2 :initial-element 0)", want: "#(0 0)"},
+		{name: "make-array with initial contents", source: "(list (make-array 2 :initial-contents '(a b)) (make-array 1 :initial-contents (vector :v)))", want: "(#(a b) #(:v))"},
+		{name: "make-array with a fill pointer", source: "(make-array 5 :fill-pointer 0 :adjustable T)", want: "#()"},
+		{name: "vector-push-extend", source: "(setq v (make-array 0 :fill-pointer 0)) (list (vector-push-extend :a v) (vector-push-extend :b v) v)", want: "(0 1 #(:a :b))"},
+		{name: "length of a vector", source: "(length (make-array 4))", want: "4"},
+		{name: "vectors are mutable", source: "(setq v (vector 1 2)) (setq w v) (setf (aref w 0) :x) v", want: "#(:x 2)"},
+		{name: "a large array", source: "(length (make-array 100000))", want: "100000"},
+
+		{name: "aref past the end", source: "(aref (vector 1 2) 2)", want: "aref expects an index below 2 but got 2", wantError: "type-error"},
+		{name: "aref with a negative index", source: "(aref (vector 1) -1)", want: "aref expects a non negative int", wantError: "type-error"},
+		{name: "aref of a list", source: "(aref '(1 2) 0)", want: "aref expects a vector but got (1 2)", wantError: "type-error"},
+		{name: "make-array with a negative size", source: "(make-array -1)", want: "make-array expects a non negative int", wantError: "type-error"},
+		{name: "make-array too large", source: "(make-array 100000000000)", want: "make-array expects a size up to 16777216 but got 100000000000", wantError: "type-error"},
+		{name: "make-array with too few contents", source: "(make-array 3 :initial-contents '(1))", want: "make-array expects initial contents of 3 elements but got (1)", wantError: "type-error"},
+		{name: "a fill pointer past the size", source: "(make-array 2 :fill-pointer 3)", want: "make-array expects a fill pointer up to 2 but got 3", wantError: "type-error"},
+		{name: "make-array with an unknown keyword", source: "(make-array 2 :element-type 'fixnum)", wantError: "arity-mismatch"},
+		{name: "vector-push-extend onto a list", source: "(vector-push-extend 1 '(1))", want: "vector-push-extend expects a vector", wantError: "type-error"},
+		{name: "vector-push-extend arity", source: "(vector-push-extend 1)", want: "vector-push-extend expects between 2 and 3 arguments but got 1", wantError: "arity-mismatch"},
+	})
+}
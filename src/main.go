@@ -2,10 +2,25 @@ package main
 
 import (
 	"./lisp"
+	"os"
 )
 
 func main() {
 
+	// golisp file.lisp... loads the given files in turn
+	if len(os.Args) > 1 {
+		interpreter := lisp.NewInterpreter(lisp.InterpreterOptions{})
+		for _, file := range os.Args[1:] {
+			evaluationResult := interpreter.LoadFile(file)
+			if !evaluationResult.IsSuccessful() {
+				print("Error: ")
+				println(evaluationResult.(lisp.UnsuccessfulEvaluationResult).Error.Report())
+				os.Exit(1)
+			}
+		}
+		return
+	}
+
 	testingLispExpressions := []string{
 		"(+ 1 2)",
 		"(+ 1 2)(+ 1 2)",
@@ -27,22 +42,30 @@ func main() {
 		"(defun multiply_by_seven (number) (* 7 number)(* 7 number)) (multiply_by_seven 8)",
 		"(defun add (a b) (if (> a 0) (add (- a 1) (+ b 1)) b))(add 10 5)",
 		"(defun list_length_ (list n) (if list (list_length_ (cdr list) (+ n 1) ) n))(defun list_length (list) (list_length_ list 0))(list_length '(1 2 3 4))",
+		"(/ 1 0)",
+		"(defun average (a b) (/ (+ a b) count))(average 1 2)",
+		"(setq x 1) x",
+		"(+ toto x)",
+		"(multiply_by_seven x)",
 	}
 
+	interpreter := lisp.NewInterpreter(lisp.InterpreterOptions{})
+
 	for _, testingLispExpression := range testingLispExpressions {
 		parseResult := lisp.Parse(testingLispExpression)
 
 		if parseResult.IsSucccessful() {
 			successfulParseResult := parseResult.(lisp.SuccessfulParseResult)
-			evaluationResult := lisp.Evaluate(successfulParseResult.Expression)
+			evaluationResult := interpreter.EvalExpr(successfulParseResult.Expression)
 			if evaluationResult.IsSuccessful() {
 				successfulEvaluationResult := evaluationResult.(lisp.SuccessfulEvaluationResult)
 				strResult := successfulEvaluationResult.Expression.Print()
 				print("Result: ")
 				println(strResult)
 			} else {
+				unsuccessfulEvaluationResult := evaluationResult.(lisp.UnsuccessfulEvaluationResult)
 				print("Error: ")
-				println(parseResult)
+				println(unsuccessfulEvaluationResult.Error.Report())
 			}
 		} else {
 			print("Compilation error: ")
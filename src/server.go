@@ -2,11 +2,77 @@ package main
 
 import (
 	"./lisp"
-	"fmt"
+	"encoding/json"
 	"log"
 	"net/http"
+	"sync"
+	"time"
 )
 
+// session is the interpreter of a client that sent a 'session' form value,
+// so that its definitions are kept from one request to the next. Its mutex
+// serializes the requests of the session, as an Interpreter is not safe for
+// concurrent use.
+type session struct {
+	mutex       sync.Mutex
+	interpreter *lisp.Interpreter
+	lastUsed    time.Time
+}
+
+// maxSessions bounds the number of interpreters kept; the least recently
+// used session is dropped to make room for a new one.
+const maxSessions = 1000
+
+var sessions = map[string]*session{}
+var sessionsMutex sync.Mutex
+
+// The expressions come from anyone, so they must not read the files of the
+// server, nor keep it busy for long.
+var interpreterOptions = lisp.InterpreterOptions{DisableLoad: true, Timeout: 5 * time.Second}
+
+// findSession returns the session of a name, creating it when needed. The
+// sessions mutex is only held while the map is used, so that sessions
+// evaluate concurrently.
+func findSession(name string) *session {
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+
+	s, ok := sessions[name]
+	if !ok {
+		if len(sessions) >= maxSessions {
+			evictLeastRecentlyUsedSession()
+		}
+		s = &session{interpreter: lisp.NewInterpreter(interpreterOptions)}
+		sessions[name] = s
+	}
+	s.lastUsed = time.Now()
+	return s
+}
+
+func evictLeastRecentlyUsedSession() {
+	oldestName := ""
+	var oldest *session
+	for name, s := range sessions {
+		if oldest == nil || s.lastUsed.Before(oldest.lastUsed) {
+			oldestName, oldest = name, s
+		}
+	}
+	delete(sessions, oldestName)
+}
+
+// evalInSession reads and evaluates a source text with the interpreter of a
+// session, so that the dispatch macros it defined apply.
+func evalInSession(name string, source string) lisp.EvaluationResult {
+	if name == "" {
+		return lisp.NewInterpreter(interpreterOptions).Eval(source)
+	}
+
+	s := findSession(name)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.interpreter.Eval(source)
+}
+
 func viewHandler(w http.ResponseWriter, r *http.Request) {
 
 	status := 0
@@ -19,29 +85,25 @@ func viewHandler(w http.ResponseWriter, r *http.Request) {
 		status = -1
 		message = "Please provide an 'expression' POST form value"
 	} else {
-		parseResult := lisp.Parse(expression)
-		if parseResult.IsSucccessful() {
-			successfulParseResult := parseResult.(lisp.SuccessfulParseResult)
-			evaluationResult := lisp.Evaluate(successfulParseResult.Expression)
-			if evaluationResult.IsSuccessful() {
-				successfulEvaluationResult := evaluationResult.(lisp.SuccessfulEvaluationResult)
-				strResult := successfulEvaluationResult.Expression.Print()
-				print("Result: ")
-				println(strResult)
-				status = 0
-				message = "Compilation and evaluation were successful"
-				requestResult = strResult
-			} else {
-				print("Error: ")
-				println(parseResult)
-				status = 1
-				message = "Compilation was successful but evaluation was not successful"
-			}
-		} else {
+		evaluationResult := evalInSession(r.FormValue("session"), expression)
+		if evaluationResult.IsSuccessful() {
+			successfulEvaluationResult := evaluationResult.(lisp.SuccessfulEvaluationResult)
+			strResult := successfulEvaluationResult.Expression.Print()
+			print("Result: ")
+			println(strResult)
+			status = 0
+			message = "Compilation and evaluation were successful"
+			requestResult = strResult
+		} else if evaluationError := evaluationResult.(lisp.UnsuccessfulEvaluationResult).Error; evaluationError.Kind == lisp.ReaderError {
 			print("Compilation error: ")
-			println(parseResult.(lisp.UnsuccessfulParseResult).Message)
+			println(evaluationError.Report())
 			status = 2
-			message = "Compilation was not successful"
+			message = "Compilation was not successful: " + evaluationError.Report()
+		} else {
+			print("Error: ")
+			println(evaluationError.Report())
+			status = 1
+			message = "Compilation was successful but evaluation was not successful: " + evaluationError.Report()
 		}
 	}
 
@@ -49,7 +111,12 @@ func viewHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 
-	fmt.Fprintf(w, "{\"status:\": %d, \"result\": \"%s\", \"msg\": \"%s\"}", status, requestResult, message)
+	response, _ := json.Marshal(map[string]interface{}{
+		"status:": status,
+		"result":  requestResult,
+		"msg":     message,
+	})
+	w.Write(response)
 }
 
 func main() {